@@ -0,0 +1,1820 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConcurrency is the number of feeds that are processed at the same
+// time when multiple feeds are configured.
+const defaultConcurrency = 4
+
+// shortcodeName matches the names of Hugo shortcodes, which can include
+// the path of the shortcode template relative to the shortcodes directory.
+var shortcodeName = regexp.MustCompile(`^[A-Za-z0-9_-]+(/[A-Za-z0-9_-]+)*$`)
+
+// configFileNames are the names of the configuration files that are loaded
+// from the working directory when the config input is not set.
+var configFileNames = []string{
+	"blueskyrss.yaml",
+	"blueskyrss.yml",
+	"blueskyrss.toml",
+}
+
+// config contains the settings for a run of the program. The settings can be
+// loaded from a YAML or TOML configuration file and are overridden by the
+// INPUT_* environment variables.
+type config struct {
+	Source    string `yaml:"source" toml:"source"`
+	Format    string `yaml:"format" toml:"format"`
+	LinkCards string `yaml:"link_cards" toml:"link_cards"`
+	Shortcode string `yaml:"shortcode" toml:"shortcode"`
+	Template  string `yaml:"template" toml:"template"`
+
+	FrontMatter output.FrontMatterFormat `yaml:"front_matter" toml:"front_matter"`
+	Archetype   string                   `yaml:"archetype" toml:"archetype"`
+
+	// TaxonomyMap maps hashtags to the Hugo taxonomies that they are
+	// assigned to, formatted as taxonomy or taxonomy:term. Taxonomy is the
+	// taxonomy of the hashtags that are not in TaxonomyMap.
+	Taxonomy    string            `yaml:"taxonomy" toml:"taxonomy"`
+	TaxonomyMap map[string]string `yaml:"taxonomy_map" toml:"taxonomy_map"`
+
+	TitleStyle    output.TitleStyle `yaml:"title_style" toml:"title_style"`
+	TitleWords    int               `yaml:"title_words" toml:"title_words"`
+	TitleTemplate string            `yaml:"title_template" toml:"title_template"`
+
+	SlugStyle    output.SlugStyle `yaml:"slug_style" toml:"slug_style"`
+	SlugTemplate string           `yaml:"slug_template" toml:"slug_template"`
+
+	SummaryLength int                   `yaml:"summary_length" toml:"summary_length"`
+	SummaryUnit   transform.SummaryUnit `yaml:"summary_unit" toml:"summary_unit"`
+
+	Language    string   `yaml:"language" toml:"language"`
+	DateFormat  string   `yaml:"date_format" toml:"date_format"`
+	DateLayouts []string `yaml:"date_layouts" toml:"date_layouts"`
+	Timezone    string   `yaml:"timezone" toml:"timezone"`
+	Concurrency int      `yaml:"concurrency" toml:"concurrency"`
+	StateFile   string   `yaml:"state_file" toml:"state_file"`
+	CacheDir    string   `yaml:"cache_dir" toml:"cache_dir"`
+	ReportFile  string   `yaml:"report_file" toml:"report_file"`
+	LogLevel    string   `yaml:"log_level" toml:"log_level"`
+	LogFormat   string   `yaml:"log_format" toml:"log_format"`
+
+	OnError    transform.ErrorPolicy `yaml:"on_error" toml:"on_error"`
+	GUIDPolicy transform.GUIDPolicy  `yaml:"guid_policy" toml:"guid_policy"`
+	Pipeline   []string              `yaml:"pipeline" toml:"pipeline"`
+
+	SkipUnchanged bool `yaml:"skip_unchanged" toml:"skip_unchanged"`
+	DryRun        bool `yaml:"dry_run" toml:"dry_run"`
+	Stream        bool `yaml:"stream" toml:"stream"`
+	ExitUnchanged bool `yaml:"exit_unchanged" toml:"exit_unchanged"`
+	Gzip          bool `yaml:"gzip" toml:"gzip"`
+
+	// SigningKey is the OpenSSH private key that the checksum files are
+	// signed with. Setting SigningKey also enables Checksums.
+	Checksums        bool   `yaml:"checksums" toml:"checksums"`
+	SigningKey       string `yaml:"signing_key" toml:"signing_key"`
+	SigningNamespace string `yaml:"signing_namespace" toml:"signing_namespace"`
+
+	Sanitize    bool     `yaml:"sanitize" toml:"sanitize"`
+	AllowedTags []string `yaml:"allowed_tags" toml:"allowed_tags"`
+
+	NormalizeUnicode bool                  `yaml:"normalize_unicode" toml:"normalize_unicode"`
+	Emoji            transform.EmojiPolicy `yaml:"emoji" toml:"emoji"`
+
+	ExcludeReplies bool `yaml:"exclude_replies" toml:"exclude_replies"`
+	ExcludeReposts bool `yaml:"exclude_reposts" toml:"exclude_reposts"`
+
+	LabelPolicy transform.LabelPolicy `yaml:"label_policy" toml:"label_policy"`
+	Labels      []string              `yaml:"labels" toml:"labels"`
+
+	IncludeTags    []string `yaml:"include_tags" toml:"include_tags"`
+	ExcludeTags    []string `yaml:"exclude_tags" toml:"exclude_tags"`
+	IncludePattern string   `yaml:"include_pattern" toml:"include_pattern"`
+	ExcludePattern string   `yaml:"exclude_pattern" toml:"exclude_pattern"`
+	FilterTemplate string   `yaml:"filter_template" toml:"filter_template"`
+	Languages      []string `yaml:"languages" toml:"languages"`
+	Since          string   `yaml:"since" toml:"since"`
+	Until          string   `yaml:"until" toml:"until"`
+
+	// Rewrite contains the templates that rewrite the fields of the posts,
+	// keyed by the name of the field.
+	Rewrite map[string]string `yaml:"rewrite" toml:"rewrite"`
+
+	MaxItems    int  `yaml:"max_items" toml:"max_items"`
+	MaxPages    int  `yaml:"max_pages" toml:"max_pages"`
+	Threads     bool `yaml:"threads" toml:"threads"`
+	Merge       bool `yaml:"merge" toml:"merge"`
+	Incremental bool `yaml:"incremental" toml:"incremental"`
+
+	LinkPreviews       bool          `yaml:"link_previews" toml:"link_previews"`
+	LinkPreviewTimeout time.Duration `yaml:"link_preview_timeout" toml:"link_preview_timeout"`
+	LinkPreviewTTL     time.Duration `yaml:"link_preview_ttl" toml:"link_preview_ttl"`
+
+	DeletedPolicy transform.DeletedPolicy `yaml:"deleted_policy" toml:"deleted_policy"`
+
+	Future     transform.FuturePolicy `yaml:"future" toml:"future"`
+	DraftAfter string                 `yaml:"draft_after" toml:"draft_after"`
+
+	Engagement        bool `yaml:"engagement" toml:"engagement"`
+	RefreshEngagement bool `yaml:"refresh_engagement" toml:"refresh_engagement"`
+
+	Sort   transform.SortOrder    `yaml:"sort" toml:"sort"`
+	Pinned transform.PinnedPolicy `yaml:"pinned" toml:"pinned"`
+
+	ImageDir     string `yaml:"image_dir" toml:"image_dir"`
+	ImageBaseURL string `yaml:"image_base_url" toml:"image_base_url"`
+	PageBundles  bool   `yaml:"page_bundles" toml:"page_bundles"`
+
+	ImageConcurrency  int           `yaml:"image_concurrency" toml:"image_concurrency"`
+	ImageHostInterval time.Duration `yaml:"image_host_interval" toml:"image_host_interval"`
+
+	WebhookURL    string `yaml:"webhook_url" toml:"webhook_url"`
+	WebhookFormat string `yaml:"webhook_format" toml:"webhook_format"`
+	WebhookSecret string `yaml:"webhook_secret" toml:"webhook_secret"`
+
+	GitCommit        bool   `yaml:"git_commit" toml:"git_commit"`
+	GitCommitMessage string `yaml:"git_commit_message" toml:"git_commit_message"`
+	GitPush          bool   `yaml:"git_push" toml:"git_push"`
+	GitAuthorName    string `yaml:"git_author_name" toml:"git_author_name"`
+	GitAuthorEmail   string `yaml:"git_author_email" toml:"git_author_email"`
+
+	AppViewURL string `yaml:"appview_url" toml:"appview_url"`
+	PDSURL     string `yaml:"pds_url" toml:"pds_url"`
+
+	UserAgent string            `yaml:"user_agent" toml:"user_agent"`
+	Headers   map[string]string `yaml:"headers" toml:"headers"`
+	CAFile    string            `yaml:"ca_file" toml:"ca_file"`
+
+	Timeout       time.Duration `yaml:"timeout" toml:"timeout"`
+	Retries       int           `yaml:"retries" toml:"retries"`
+	RetryDelay    time.Duration `yaml:"retry_delay" toml:"retry_delay"`
+	RetryMaxDelay time.Duration `yaml:"retry_max_delay" toml:"retry_max_delay"`
+	RateLimitWait time.Duration `yaml:"rate_limit_wait" toml:"rate_limit_wait"`
+
+	// MaxResponseSize is the maximum size of a response in bytes.
+	MaxResponseSize int64 `yaml:"max_response_size" toml:"max_response_size"`
+	MaxRedirects    int   `yaml:"max_redirects" toml:"max_redirects"`
+
+	Feeds []feedConfig `yaml:"feeds" toml:"feeds"`
+
+	// Identifier and AppPassword are the credentials that are used to sign
+	// in to Bluesky. They are only read from the BSKY_IDENTIFIER and
+	// BSKY_APP_PASSWORD environment variables so that the password is not
+	// stored in a configuration file.
+	Identifier  string `yaml:"-" toml:"-"`
+	AppPassword string `yaml:"-" toml:"-"`
+
+	// Location is the time zone that is loaded using the name in Timezone,
+	// or nil if Timezone is not set.
+	Location *time.Location `yaml:"-" toml:"-"`
+}
+
+// feedConfig identifies a feed to transform and the path that the
+// transformed feed is written to. URL is used when the source is rss,
+// mastodon, microblog, or generic and Actor is used when the source is
+// xrpc. Handle is a shortcut for either one: it is the actor, and the URL
+// of the feed of the account is derived from it. Source and Format default
+// to the values in the config when they are not set for the feed. SelfURL
+// is the URL that the RSS output is published at, if it is known.
+type feedConfig struct {
+	Source  string `yaml:"source" toml:"source"`
+	Format  string `yaml:"format" toml:"format"`
+	URL     string `yaml:"url" toml:"url"`
+	Actor   string `yaml:"actor" toml:"actor"`
+	Handle  string `yaml:"handle" toml:"handle"`
+	Path    string `yaml:"path" toml:"path"`
+	SelfURL string `yaml:"self_url" toml:"self_url"`
+
+	// Sinks are the additional outputs that the feed is written to.
+	Sinks []sinkConfig `yaml:"sinks" toml:"sinks"`
+}
+
+// sinkConfig is an additional output that a feed is written to using a
+// different format or path than the output of the feed. The output is
+// written to standard output if the path is "-".
+type sinkConfig struct {
+	Format string `yaml:"format" toml:"format"`
+	Path   string `yaml:"path" toml:"path"`
+}
+
+// loadConfig loads the configuration file, if there is one, and then applies
+// the command-line flags and the INPUT_* environment variables that GitHub
+// Actions sets for the inputs of the action.
+func loadConfig() (config, error) {
+	cfg := config{
+		Source:      "rss",
+		Format:      "rss",
+		LinkCards:   "content",
+		FrontMatter: output.YAMLFrontMatter,
+		Shortcode:   output.DefaultShortcode,
+		DateFormat:  transform.DefaultDateFormat,
+		Concurrency: defaultConcurrency,
+		LogLevel:    "info",
+		LogFormat:   "text",
+		TitleStyle:  output.NoTitle,
+		SlugStyle:   output.RKeySlug,
+		TitleWords:  output.DefaultTitleWords,
+		SummaryUnit: transform.SummaryCharacters,
+		Emoji:       transform.KeepEmoji,
+		OnError:     transform.Fail,
+		GUIDPolicy:  transform.URIGUID,
+		Pipeline:    slices.Clone(pipelineSteps),
+		AllowedTags: transform.DefaultAllowedTags,
+		LabelPolicy: transform.KeepLabeled,
+		Sort:        transform.AsFetched,
+
+		DeletedPolicy: transform.KeepDeleted,
+		Future:        transform.KeepFuture,
+		Pinned:        transform.IgnorePinned,
+		Labels:        transform.DefaultLabels,
+		MaxPages:      1,
+
+		LinkPreviewTimeout: transform.DefaultLinkPreviewTimeout,
+		LinkPreviewTTL:     transform.DefaultLinkPreviewTTL,
+		ImageConcurrency:   transform.DefaultImageConcurrency,
+		WebhookFormat:      "generic",
+
+		GitCommitMessage: defaultGitCommitMessage,
+		GitAuthorName:    defaultGitAuthorName,
+		GitAuthorEmail:   defaultGitAuthorEmail,
+
+		UserAgent:     feed.DefaultUserAgent,
+		Timeout:       feed.DefaultTimeout,
+		Retries:       feed.DefaultRetries,
+		RetryDelay:    feed.DefaultRetryDelay,
+		RetryMaxDelay: feed.DefaultRetryMaxDelay,
+		RateLimitWait: feed.DefaultRateLimitWait,
+
+		MaxResponseSize: feed.DefaultMaxResponseSize,
+		MaxRedirects:    feed.DefaultMaxRedirects,
+	}
+
+	name, ok := lookupInput("CONFIG")
+	if !ok {
+		name = findConfigFile()
+	}
+
+	if name != "" {
+		if err := readConfigFile(name, &cfg); err != nil {
+			return config{}, err
+		}
+	}
+
+	if value, ok := lookupInput("SOURCE"); ok {
+		cfg.Source = value
+	}
+
+	if value, ok := lookupInput("APPVIEW_URL"); ok {
+		cfg.AppViewURL = value
+	}
+
+	if value, ok := lookupInput("PDS_URL"); ok {
+		cfg.PDSURL = value
+	}
+
+	if value, ok := lookupInput("FORMAT"); ok {
+		cfg.Format = value
+	}
+
+	if value, ok := lookupInput("LINK_CARDS"); ok {
+		cfg.LinkCards = value
+	}
+
+	if value, ok := lookupInput("SHORTCODE"); ok {
+		cfg.Shortcode = value
+	}
+
+	if value, ok := lookupInput("TEMPLATE"); ok {
+		cfg.Template = value
+	}
+
+	if value, ok := lookupInput("TITLE_STYLE"); ok {
+		cfg.TitleStyle = output.TitleStyle(value)
+	}
+
+	if err := lookupInt("TITLE_WORDS", &cfg.TitleWords); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("TITLE_TEMPLATE"); ok {
+		cfg.TitleTemplate = value
+	}
+
+	if value, ok := lookupInput("FRONT_MATTER"); ok {
+		cfg.FrontMatter = output.FrontMatterFormat(value)
+	}
+
+	if value, ok := lookupInput("ARCHETYPE"); ok {
+		cfg.Archetype = value
+	}
+
+	if value, ok := lookupInput("SLUG_STYLE"); ok {
+		cfg.SlugStyle = output.SlugStyle(value)
+	}
+
+	if value, ok := lookupInput("SLUG_TEMPLATE"); ok {
+		cfg.SlugTemplate = value
+	}
+
+	if err := lookupInt("SUMMARY_LENGTH", &cfg.SummaryLength); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("SUMMARY_UNIT"); ok {
+		cfg.SummaryUnit = transform.SummaryUnit(value)
+	}
+
+	if value, ok := lookupInput("LANGUAGE"); ok {
+		cfg.Language = value
+	}
+
+	if value, ok := lookupInput("DATE_FORMAT"); ok {
+		cfg.DateFormat = value
+	}
+
+	if value, ok := lookupInput("DATE_LAYOUTS"); ok {
+		cfg.DateLayouts = parseLines(value)
+	}
+
+	if value, ok := lookupInput("TIMEZONE"); ok {
+		cfg.Timezone = value
+	}
+
+	if value, ok := lookupInput("CONCURRENCY"); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return config{}, fmt.Errorf(
+				"the concurrency input %q must be a positive integer",
+				value,
+			)
+		}
+
+		cfg.Concurrency = n
+	}
+
+	if value, ok := lookupInput("LOG_LEVEL"); ok {
+		cfg.LogLevel = value
+	}
+
+	if value, ok := lookupInput("LOG_FORMAT"); ok {
+		cfg.LogFormat = value
+	}
+
+	if value, ok := lookupInput("ON_ERROR"); ok {
+		cfg.OnError = transform.ErrorPolicy(value)
+	}
+
+	if value, ok := lookupInput("GUID_POLICY"); ok {
+		cfg.GUIDPolicy = transform.GUIDPolicy(value)
+	}
+
+	if value, ok := lookupInput("PIPELINE"); ok {
+		cfg.Pipeline = splitList(value)
+	}
+
+	if value, ok := lookupInput("STATE_FILE"); ok {
+		cfg.StateFile = value
+	}
+
+	if value, ok := lookupInput("CACHE_DIR"); ok {
+		cfg.CacheDir = value
+	}
+
+	if value, ok := lookupInput("REPORT_FILE"); ok {
+		cfg.ReportFile = value
+	}
+
+	if err := lookupBool("SKIP_UNCHANGED", &cfg.SkipUnchanged); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("EXIT_UNCHANGED", &cfg.ExitUnchanged); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("GZIP", &cfg.Gzip); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("CHECKSUMS", &cfg.Checksums); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("SIGNING_KEY"); ok {
+		cfg.SigningKey = value
+	}
+
+	if value, ok := lookupInput("SIGNING_NAMESPACE"); ok {
+		cfg.SigningNamespace = value
+	}
+
+	if err := lookupBool("DRY_RUN", &cfg.DryRun); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("STREAM", &cfg.Stream); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("USER_AGENT"); ok {
+		cfg.UserAgent = value
+	}
+
+	if value, ok := lookupInput("HEADERS"); ok {
+		headers, err := parseHeaders(value)
+		if err != nil {
+			return config{}, err
+		}
+
+		cfg.Headers = headers
+	}
+
+	if value, ok := lookupInput("CA_FILE"); ok {
+		cfg.CAFile = value
+	}
+
+	if err := lookupDuration("TIMEOUT", &cfg.Timeout); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupInt("RETRIES", &cfg.Retries); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupDuration("RETRY_DELAY", &cfg.RetryDelay); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupDuration("RETRY_MAX_DELAY", &cfg.RetryMaxDelay); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupDuration("RATE_LIMIT_WAIT", &cfg.RateLimitWait); err != nil {
+		return config{}, err
+	}
+
+	err := lookupSize("MAX_RESPONSE_SIZE", &cfg.MaxResponseSize)
+	if err != nil {
+		return config{}, err
+	}
+
+	if err = lookupInt("MAX_REDIRECTS", &cfg.MaxRedirects); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("SANITIZE", &cfg.Sanitize); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("ALLOWED_TAGS"); ok {
+		cfg.AllowedTags = splitList(value)
+	}
+
+	err = lookupBool("NORMALIZE_UNICODE", &cfg.NormalizeUnicode)
+	if err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("EMOJI"); ok {
+		cfg.Emoji = transform.EmojiPolicy(value)
+	}
+
+	if err := lookupBool("EXCLUDE_REPLIES", &cfg.ExcludeReplies); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("EXCLUDE_REPOSTS", &cfg.ExcludeReposts); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("LABEL_POLICY"); ok {
+		cfg.LabelPolicy = transform.LabelPolicy(value)
+	}
+
+	if value, ok := lookupInput("LABELS"); ok {
+		cfg.Labels = splitList(value)
+	}
+
+	if value, ok := lookupInput("INCLUDE_TAGS"); ok {
+		cfg.IncludeTags = splitList(value)
+	}
+
+	if value, ok := lookupInput("EXCLUDE_TAGS"); ok {
+		cfg.ExcludeTags = splitList(value)
+	}
+
+	if value, ok := lookupInput("INCLUDE_PATTERN"); ok {
+		cfg.IncludePattern = value
+	}
+
+	if value, ok := lookupInput("FILTER_TEMPLATE"); ok {
+		cfg.FilterTemplate = value
+	}
+
+	if value, ok := lookupInput("TAXONOMY"); ok {
+		cfg.Taxonomy = value
+	}
+
+	if value, ok := lookupInput("TAXONOMY_MAP"); ok {
+		taxonomies, err := parseTaxonomyMap(value)
+		if err != nil {
+			return config{}, err
+		}
+
+		cfg.TaxonomyMap = taxonomies
+	}
+
+	if value, ok := lookupInput("REWRITE"); ok {
+		rewrite, err := parseRewrite(value)
+		if err != nil {
+			return config{}, err
+		}
+
+		cfg.Rewrite = rewrite
+	}
+
+	if value, ok := lookupInput("EXCLUDE_PATTERN"); ok {
+		cfg.ExcludePattern = value
+	}
+
+	if value, ok := lookupInput("LANGUAGES"); ok {
+		cfg.Languages = splitList(value)
+	}
+
+	if value, ok := lookupInput("SINCE"); ok {
+		cfg.Since = value
+	}
+
+	if value, ok := lookupInput("UNTIL"); ok {
+		cfg.Until = value
+	}
+
+	if value, ok := lookupInput("DRAFT_AFTER"); ok {
+		cfg.DraftAfter = value
+	}
+
+	for _, bound := range [][2]string{
+		{"since", cfg.Since},
+		{"until", cfg.Until},
+		{"draft_after", cfg.DraftAfter},
+	} {
+		if bound[1] == "" {
+			continue
+		}
+
+		if _, err := transform.ParseDateBound(bound[1], time.Now()); err != nil {
+			return config{}, fmt.Errorf(
+				"the %s input is invalid: %w",
+				bound[0],
+				err,
+			)
+		}
+	}
+
+	if err := lookupInt("MAX_ITEMS", &cfg.MaxItems); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupInt("MAX_PAGES", &cfg.MaxPages); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("THREADS", &cfg.Threads); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("LINK_PREVIEWS", &cfg.LinkPreviews); err != nil {
+		return config{}, err
+	}
+
+	err = lookupDuration("LINK_PREVIEW_TIMEOUT", &cfg.LinkPreviewTimeout)
+	if err != nil {
+		return config{}, err
+	}
+
+	err = lookupDuration("LINK_PREVIEW_TTL", &cfg.LinkPreviewTTL)
+	if err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("MERGE", &cfg.Merge); err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("INCREMENTAL", &cfg.Incremental); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("FUTURE"); ok {
+		cfg.Future = transform.FuturePolicy(value)
+	}
+
+	if value, ok := lookupInput("DELETED_POLICY"); ok {
+		cfg.DeletedPolicy = transform.DeletedPolicy(value)
+	}
+
+	if err := lookupBool("ENGAGEMENT", &cfg.Engagement); err != nil {
+		return config{}, err
+	}
+
+	err = lookupBool("REFRESH_ENGAGEMENT", &cfg.RefreshEngagement)
+	if err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("SORT"); ok {
+		cfg.Sort = transform.SortOrder(value)
+	}
+
+	if value, ok := lookupInput("PINNED"); ok {
+		cfg.Pinned = transform.PinnedPolicy(value)
+	}
+
+	if value, ok := lookupInput("IMAGE_DIR"); ok {
+		cfg.ImageDir = value
+	}
+
+	if value, ok := lookupInput("IMAGE_BASE_URL"); ok {
+		cfg.ImageBaseURL = value
+	}
+
+	if err := lookupInt("IMAGE_CONCURRENCY", &cfg.ImageConcurrency); err != nil {
+		return config{}, err
+	}
+
+	err = lookupDuration("IMAGE_HOST_INTERVAL", &cfg.ImageHostInterval)
+	if err != nil {
+		return config{}, err
+	}
+
+	if err := lookupBool("PAGE_BUNDLES", &cfg.PageBundles); err != nil {
+		return config{}, err
+	}
+
+	if cfg.PageBundles && cfg.ImageDir != "" {
+		return config{}, errors.New(
+			"the image_dir input cannot be used with page bundles",
+		)
+	}
+
+	if cfg.ImageDir != "" && cfg.ImageBaseURL == "" {
+		cfg.ImageBaseURL = transform.DefaultImageBaseURL(cfg.ImageDir)
+	}
+
+	if value, ok := lookupInput("WEBHOOK_URL"); ok {
+		cfg.WebhookURL = value
+	}
+
+	if value, ok := lookupInput("WEBHOOK_FORMAT"); ok {
+		cfg.WebhookFormat = value
+	}
+
+	if value, ok := lookupInput("WEBHOOK_SECRET"); ok {
+		cfg.WebhookSecret = value
+	}
+
+	if err := lookupBool("GIT_COMMIT", &cfg.GitCommit); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("GIT_COMMIT_MESSAGE"); ok {
+		cfg.GitCommitMessage = value
+	}
+
+	if err := lookupBool("GIT_PUSH", &cfg.GitPush); err != nil {
+		return config{}, err
+	}
+
+	if value, ok := lookupInput("GIT_AUTHOR_NAME"); ok {
+		cfg.GitAuthorName = value
+	}
+
+	if value, ok := lookupInput("GIT_AUTHOR_EMAIL"); ok {
+		cfg.GitAuthorEmail = value
+	}
+
+	if cfg.GitPush && !cfg.GitCommit {
+		return config{}, errors.New(
+			"the git_push input cannot be used without the git_commit input",
+		)
+	}
+
+	for _, u := range []struct {
+		input string
+		value *string
+	}{
+		{"appview_url", &cfg.AppViewURL},
+		{"pds_url", &cfg.PDSURL},
+	} {
+		if *u.value == "" {
+			continue
+		}
+
+		serviceURL, err := url.Parse(*u.value)
+		if err != nil || (serviceURL.Scheme != "http" &&
+			serviceURL.Scheme != "https") || serviceURL.Host == "" {
+			return config{}, fmt.Errorf(
+				"the %s input %q must be an http or https URL",
+				u.input,
+				*u.value,
+			)
+		}
+
+		*u.value = strings.TrimSuffix(*u.value, "/")
+	}
+
+	cfg.LinkCards = strings.ToLower(cfg.LinkCards)
+	if cfg.LinkCards != "content" && cfg.LinkCards != "front_matter" {
+		return config{}, fmt.Errorf(
+			"the link_cards input %q is not supported",
+			cfg.LinkCards,
+		)
+	}
+
+	if !shortcodeName.MatchString(cfg.Shortcode) {
+		return config{}, fmt.Errorf(
+			"the shortcode input %q is not a valid shortcode name",
+			cfg.Shortcode,
+		)
+	}
+
+	if err := transform.ValidateDateFormat(cfg.DateFormat); err != nil {
+		return config{}, err
+	}
+
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return config{}, fmt.Errorf(
+				"the timezone input %q is not supported: %w",
+				cfg.Timezone,
+				err,
+			)
+		}
+
+		cfg.Location = loc
+	}
+
+	cfg.OnError = transform.ErrorPolicy(strings.ToLower(string(cfg.OnError)))
+	switch cfg.OnError {
+	case transform.Fail, transform.SkipItem, transform.Passthrough:
+	default:
+		return config{}, fmt.Errorf(
+			"the on_error input %q is not supported",
+			cfg.OnError,
+		)
+	}
+
+	if _, err := cfg.taxonomies(); err != nil {
+		return config{}, err
+	}
+
+	for field := range cfg.Rewrite {
+		if !slices.Contains(output.RewriteFields, field) {
+			return config{}, fmt.Errorf(
+				"the %s field cannot be rewritten",
+				field,
+			)
+		}
+	}
+
+	for i, step := range cfg.Pipeline {
+		step = strings.ToLower(step)
+		if !slices.Contains(pipelineSteps, step) {
+			return config{}, fmt.Errorf(
+				"the pipeline step %q is not supported",
+				step,
+			)
+		}
+
+		if slices.Contains(cfg.Pipeline[:i], step) {
+			return config{}, fmt.Errorf(
+				"the pipeline step %q is used more than once",
+				step,
+			)
+		}
+
+		cfg.Pipeline[i] = step
+	}
+
+	cfg.TitleStyle = output.TitleStyle(strings.ToLower(string(cfg.TitleStyle)))
+	if !slices.Contains(output.TitleStyles, cfg.TitleStyle) {
+		return config{}, fmt.Errorf(
+			"the title_style input %q is not supported",
+			cfg.TitleStyle,
+		)
+	}
+
+	if cfg.TitleWords < 1 {
+		return config{}, errors.New("the title words must be a positive integer")
+	}
+
+	if cfg.TitleStyle == output.TemplateTitle && cfg.TitleTemplate == "" {
+		return config{}, errors.New(
+			"the title_template input is required for the template title style",
+		)
+	}
+
+	cfg.FrontMatter = output.FrontMatterFormat(
+		strings.ToLower(string(cfg.FrontMatter)),
+	)
+	if !slices.Contains(output.FrontMatterFormats, cfg.FrontMatter) {
+		return config{}, fmt.Errorf(
+			"the front_matter input %q is not supported",
+			cfg.FrontMatter,
+		)
+	}
+
+	cfg.SlugStyle = output.SlugStyle(strings.ToLower(string(cfg.SlugStyle)))
+	if !slices.Contains(output.SlugStyles, cfg.SlugStyle) {
+		return config{}, fmt.Errorf(
+			"the slug_style input %q is not supported",
+			cfg.SlugStyle,
+		)
+	}
+
+	if cfg.SlugStyle == output.TemplateSlug && cfg.SlugTemplate == "" {
+		return config{}, errors.New(
+			"the slug_template input is required for the template slug style",
+		)
+	}
+
+	if cfg.SummaryLength < 0 {
+		return config{}, errors.New("the summary length cannot be negative")
+	}
+
+	cfg.Emoji = transform.EmojiPolicy(strings.ToLower(string(cfg.Emoji)))
+	switch cfg.Emoji {
+	case transform.KeepEmoji, transform.StripEmoji, transform.ShortcodeEmoji:
+	default:
+		return config{}, fmt.Errorf(
+			"the emoji input %q is not supported",
+			cfg.Emoji,
+		)
+	}
+
+	cfg.SummaryUnit = transform.SummaryUnit(
+		strings.ToLower(string(cfg.SummaryUnit)),
+	)
+	switch cfg.SummaryUnit {
+	case transform.SummaryCharacters, transform.SummaryWords:
+	default:
+		return config{}, fmt.Errorf(
+			"the summary_unit input %q is not supported",
+			cfg.SummaryUnit,
+		)
+	}
+
+	cfg.GUIDPolicy = transform.GUIDPolicy(
+		strings.ToLower(string(cfg.GUIDPolicy)),
+	)
+	switch cfg.GUIDPolicy {
+	case transform.URIGUID, transform.HashGUID, transform.LinkGUID:
+	default:
+		return config{}, fmt.Errorf(
+			"the guid_policy input %q is not supported",
+			cfg.GUIDPolicy,
+		)
+	}
+
+	cfg.WebhookFormat = strings.ToLower(cfg.WebhookFormat)
+	if !slices.Contains(webhookFormats, cfg.WebhookFormat) {
+		return config{}, fmt.Errorf(
+			"the webhook_format input %q is not supported",
+			cfg.WebhookFormat,
+		)
+	}
+
+	cfg.LabelPolicy = transform.LabelPolicy(
+		strings.ToLower(string(cfg.LabelPolicy)),
+	)
+	switch cfg.LabelPolicy {
+	case transform.KeepLabeled, transform.WarnLabeled, transform.DropLabeled:
+	default:
+		return config{}, fmt.Errorf(
+			"the label_policy input %q is not supported",
+			cfg.LabelPolicy,
+		)
+	}
+
+	cfg.DeletedPolicy = transform.DeletedPolicy(
+		strings.ToLower(string(cfg.DeletedPolicy)),
+	)
+	switch cfg.DeletedPolicy {
+	case transform.KeepDeleted, transform.MarkDeleted, transform.DropDeleted:
+	default:
+		return config{}, fmt.Errorf(
+			"the deleted_policy input %q is not supported",
+			cfg.DeletedPolicy,
+		)
+	}
+
+	cfg.Future = transform.FuturePolicy(strings.ToLower(string(cfg.Future)))
+	switch cfg.Future {
+	case transform.KeepFuture, transform.ClampFuture, transform.DraftFuture,
+		transform.DropFuture:
+	default:
+		return config{}, fmt.Errorf(
+			"the future input %q is not supported",
+			cfg.Future,
+		)
+	}
+
+	cfg.Sort = transform.SortOrder(strings.ToLower(string(cfg.Sort)))
+	switch cfg.Sort {
+	case transform.NewestFirst, transform.OldestFirst, transform.AsFetched:
+	default:
+		return config{}, fmt.Errorf(
+			"the sort input %q is not supported",
+			cfg.Sort,
+		)
+	}
+
+	cfg.Pinned = transform.PinnedPolicy(strings.ToLower(string(cfg.Pinned)))
+	switch cfg.Pinned {
+	case transform.IgnorePinned, transform.FlagPinned, transform.FirstPinned:
+	default:
+		return config{}, fmt.Errorf(
+			"the pinned input %q is not supported",
+			cfg.Pinned,
+		)
+	}
+
+	if cfg.Concurrency < 1 {
+		return config{}, errors.New("the concurrency must be a positive integer")
+	}
+
+	if cfg.ImageConcurrency < 1 {
+		return config{}, errors.New(
+			"the image concurrency must be a positive integer",
+		)
+	}
+
+	if cfg.ImageHostInterval < 0 {
+		return config{}, errors.New("the image host interval cannot be negative")
+	}
+
+	if cfg.MaxItems < 0 || cfg.MaxPages < 0 {
+		return config{}, errors.New(
+			"the maximum number of items and pages cannot be negative",
+		)
+	}
+
+	if cfg.Timeout < 0 {
+		return config{}, errors.New("the timeout cannot be negative")
+	}
+
+	if cfg.LinkPreviewTimeout < 0 || cfg.LinkPreviewTTL < 0 {
+		return config{}, errors.New(
+			"the link preview timeout and TTL cannot be negative",
+		)
+	}
+
+	if cfg.Retries < 0 {
+		return config{}, errors.New("the number of retries cannot be negative")
+	}
+
+	if cfg.RetryDelay <= 0 || cfg.RetryMaxDelay < cfg.RetryDelay {
+		return config{}, errors.New(
+			"the retry delay must be positive and less than the maximum delay",
+		)
+	}
+
+	if cfg.RateLimitWait < 0 {
+		return config{}, errors.New("the rate limit wait cannot be negative")
+	}
+
+	if cfg.MaxResponseSize < 0 {
+		return config{}, errors.New(
+			"the maximum response size cannot be negative",
+		)
+	}
+
+	if cfg.MaxRedirects < 0 {
+		return config{}, errors.New(
+			"the maximum number of redirects cannot be negative",
+		)
+	}
+
+	cfg.Identifier = os.Getenv("BSKY_IDENTIFIER")
+	cfg.AppPassword = os.Getenv("BSKY_APP_PASSWORD")
+	if (cfg.Identifier == "") != (cfg.AppPassword == "") {
+		return config{}, errors.New(
+			"BSKY_IDENTIFIER and BSKY_APP_PASSWORD must be set together",
+		)
+	}
+
+	cfg.Source = strings.ToLower(cfg.Source)
+	cfg.Format = strings.ToLower(cfg.Format)
+	return cfg, nil
+}
+
+// loadFeeds applies the feeds input, or the url, actor, and path inputs, to
+// the feeds of the configuration and validates the feeds. The feeds are not
+// loaded by loadConfig because the serve command does not use them.
+func (cfg *config) loadFeeds() error {
+	if err := cfg.lookupFeeds(); err != nil {
+		return err
+	}
+
+	if value, ok := lookupInput("SINKS"); ok {
+		if len(cfg.Feeds) > 1 {
+			return errors.New(
+				"the sinks input can only be used with a single feed",
+			)
+		}
+
+		sinks, err := parseSinks(value)
+		if err != nil {
+			return err
+		}
+
+		cfg.Feeds[0].Sinks = sinks
+	}
+
+	for i := range cfg.Feeds {
+		if err := cfg.Feeds[i].validate(*cfg); err != nil {
+			if len(cfg.Feeds) == 1 {
+				return err
+			}
+
+			return fmt.Errorf("feed %d: %w", i+1, err)
+		}
+	}
+
+	stdin := 0
+	for _, f := range cfg.Feeds {
+		if f.Source != "xrpc" && f.URL == feed.StdinURL {
+			stdin++
+		}
+	}
+
+	if stdin > 1 {
+		return errors.New("only one feed can be read from standard input")
+	}
+
+	for _, group := range cfg.feedGroups() {
+		if cfg.Stream && len(group) > 1 {
+			return fmt.Errorf(
+				"the feeds that are written to %s cannot be combined when "+
+					"streaming",
+				group[0].Path,
+			)
+		}
+
+		for _, f := range group[1:] {
+			if f.Format != group[0].Format {
+				return fmt.Errorf(
+					"the feeds that are written to %s must use the same "+
+						"format",
+					f.Path,
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadOutputs applies the feeds input, or the path input, to the feeds of
+// the configuration like loadFeeds, but only validates the formats and the
+// paths of the feeds. The validate command uses loadOutputs because it
+// reads the output that was written for each feed and does not need to know
+// where the feeds are downloaded from.
+func (cfg *config) loadOutputs() error {
+	if err := cfg.lookupFeeds(); err != nil {
+		return err
+	}
+
+	for i := range cfg.Feeds {
+		if err := cfg.Feeds[i].validateOutput(*cfg); err != nil {
+			if len(cfg.Feeds) == 1 {
+				return err
+			}
+
+			return fmt.Errorf("feed %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// lookupFeeds sets the feeds of the configuration using the feeds input,
+// or the url, actor, handle, and path inputs. The feeds of the
+// configuration file are kept if none of the inputs are set.
+func (cfg *config) lookupFeeds() error {
+	if value, ok := lookupInput("FEEDS"); ok {
+		feeds, err := parseFeeds(cfg.Source, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.Feeds = feeds
+	} else if feed, ok := lookupFeed(); ok {
+		cfg.Feeds = []feedConfig{feed}
+	} else if len(cfg.Feeds) == 0 {
+		cfg.Feeds = []feedConfig{{}}
+	}
+
+	return nil
+}
+
+// feedGroups groups the feeds by the path that they are written to. The
+// feeds that are written to the same path are combined into a single feed,
+// which aggregates the posts of multiple accounts. The groups are returned
+// in the order of the first feed of each group.
+func (cfg config) feedGroups() [][]feedConfig {
+	var groups [][]feedConfig
+	index := map[string]int{}
+	for _, f := range cfg.Feeds {
+		i, ok := index[f.Path]
+		if !ok {
+			i = len(groups)
+			index[f.Path] = i
+			groups = append(groups, nil)
+		}
+
+		groups[i] = append(groups[i], f)
+	}
+
+	return groups
+}
+
+// validateOutput applies the default format from cfg to the feed and
+// verifies that the feed has a supported format and a path.
+func (f *feedConfig) validateOutput(cfg config) error {
+	if f.Format == "" {
+		f.Format = cfg.Format
+	}
+
+	f.Format = strings.ToLower(f.Format)
+	if !slices.Contains(output.Formats, f.Format) {
+		return fmt.Errorf("the format input %q is not supported", f.Format)
+	}
+
+	if f.Path == "" {
+		return errors.New("the path input is required")
+	}
+
+	return nil
+}
+
+// validate applies the default source and format from cfg to the feed and
+// verifies that the feed has all of the settings that it needs.
+func (f *feedConfig) validate(cfg config) error {
+	if f.Source == "" {
+		f.Source = cfg.Source
+	}
+
+	f.Source = strings.ToLower(f.Source)
+	f.Handle = strings.TrimPrefix(f.Handle, "@")
+	if err := f.applyHandle(); err != nil {
+		return err
+	}
+
+	if err := f.validateOutput(cfg); err != nil {
+		return err
+	}
+
+	if f.Path == "-" {
+		if err := f.validateStdout(cfg); err != nil {
+			return err
+		}
+	}
+
+	if isRemoteURL(f.Path) {
+		if err := f.validateRemoteURL(cfg); err != nil {
+			return err
+		}
+	}
+
+	for i := range f.Sinks {
+		if err := f.Sinks[i].validate(cfg, f.Path); err != nil {
+			return fmt.Errorf("sink %d: %w", i+1, err)
+		}
+	}
+
+	if cfg.Stream && len(f.Sinks) > 0 {
+		return errors.New("sinks are not supported when streaming")
+	}
+
+	if f.Format == "template" && cfg.Template == "" {
+		return errors.New(
+			"the template input is required for the template format",
+		)
+	}
+
+	if cfg.PageBundles && f.Format != "content" {
+		return errors.New(
+			"page bundles are only supported for the content format",
+		)
+	}
+
+	if cfg.Stream && (f.Source != "rss" || f.Format != "rss") {
+		return errors.New(
+			"streaming is only supported for the rss source and the rss format",
+		)
+	}
+
+	if cfg.Stream && cfg.Merge {
+		return errors.New("merging is not supported when streaming")
+	}
+
+	if cfg.Stream && cfg.Sort != transform.AsFetched {
+		return errors.New("sorting is not supported when streaming")
+	}
+
+	if cfg.Stream && (cfg.Future != transform.KeepFuture ||
+		cfg.DraftAfter != "") {
+		return errors.New("drafts are not supported when streaming")
+	}
+
+	if cfg.Stream && cfg.Gzip {
+		return errors.New("compressed output is not supported when streaming")
+	}
+
+	if cfg.Stream && (cfg.Checksums || cfg.SigningKey != "") {
+		return errors.New("checksums are not supported when streaming")
+	}
+
+	if cfg.Merge && (f.Format == "content" || f.Format == "shortcode" ||
+		f.Format == "template") {
+		return fmt.Errorf(
+			"merging is not supported for the %s format",
+			f.Format,
+		)
+	}
+
+	if cfg.Incremental && f.Source != "xrpc" {
+		return errors.New(
+			"incremental syncs are only supported for the xrpc source",
+		)
+	}
+
+	if cfg.Incremental && (f.Format == "content" ||
+		f.Format == "shortcode" || f.Format == "template") {
+		return fmt.Errorf(
+			"incremental syncs are not supported for the %s format",
+			f.Format,
+		)
+	}
+
+	return nil
+}
+
+// validateStdout verifies that the feed, whose output is written to
+// standard output, does not use any of the settings that need the output
+// to be a file.
+func (f *feedConfig) validateStdout(cfg config) error {
+	switch {
+	case f.Format == "content":
+		return errors.New(
+			"the content format cannot be written to standard output",
+		)
+	case cfg.Stream:
+		return errors.New(
+			"streaming is not supported when writing to standard output",
+		)
+	case cfg.Merge || cfg.Incremental:
+		return errors.New(
+			"merging is not supported when writing to standard output",
+		)
+	case cfg.Gzip:
+		return errors.New(
+			"compressed output is not supported when writing to standard " +
+				"output",
+		)
+	case cfg.Checksums || cfg.SigningKey != "":
+		return errors.New(
+			"checksums are not supported when writing to standard output",
+		)
+	}
+
+	return nil
+}
+
+// validateRemoteURL verifies that the feed, whose output is uploaded to an
+// object store or to an SFTP server, can be uploaded and does not use any
+// of the settings that need to read the existing output.
+func (f *feedConfig) validateRemoteURL(cfg config) error {
+	if err := checkRemoteURL(f.Path); err != nil {
+		return err
+	}
+
+	target := "an object store"
+	if output.IsSFTPURL(f.Path) {
+		target = "an SFTP server"
+	}
+
+	switch {
+	case cfg.Stream:
+		return fmt.Errorf(
+			"streaming is not supported when writing to %s",
+			target,
+		)
+	case cfg.Merge || cfg.Incremental:
+		return fmt.Errorf("merging is not supported when writing to %s", target)
+	case cfg.PageBundles:
+		return fmt.Errorf(
+			"page bundles are not supported when writing to %s",
+			target,
+		)
+	}
+
+	return nil
+}
+
+// checkRemoteURL verifies that the output can be uploaded to path, which is
+// an object URL or an SFTP URL, without connecting to the server.
+func checkRemoteURL(path string) error {
+	if output.IsSFTPURL(path) {
+		_, _, err := output.OpenSFTPServer(path)
+		return err
+	}
+
+	_, _, err := output.OpenObjectStore(path, nil)
+	return err
+}
+
+// applyHandle derives the URL or the actor of the feed from the handle if
+// they are not set, and verifies that the source of the feed is supported
+// and that the feed identifies where its posts are read from.
+func (f *feedConfig) applyHandle() error {
+	switch f.Source {
+	case "rss":
+		if f.URL == "" && f.Handle != "" {
+			url, err := feed.FeedURL(f.Handle)
+			if err != nil {
+				return err
+			}
+
+			f.URL = url
+		}
+
+		if f.URL == "" {
+			return errors.New("the url or handle input is required")
+		}
+	case "mastodon":
+		if f.URL == "" && f.Handle != "" {
+			url, err := feed.MastodonFeedURL(f.Handle)
+			if err != nil {
+				return err
+			}
+
+			f.URL = url
+		}
+
+		if f.URL == "" {
+			return errors.New("the url or handle input is required")
+		}
+	case "microblog":
+		if f.URL == "" && f.Handle != "" {
+			f.URL = feed.MicroblogFeedURL(f.Handle)
+		}
+
+		if f.URL == "" {
+			return errors.New("the url or handle input is required")
+		}
+	case "generic":
+		if f.URL == "" {
+			return errors.New("the url input is required")
+		}
+	case "xrpc":
+		if f.Actor == "" {
+			f.Actor = f.Handle
+		}
+
+		if f.Actor == "" {
+			return errors.New("the actor or handle input is required")
+		}
+	default:
+		return fmt.Errorf("the source input %q is not supported", f.Source)
+	}
+
+	return nil
+}
+
+// validate verifies that the sink of a feed that is written to path has all
+// of the settings that it needs.
+func (s *sinkConfig) validate(cfg config, path string) error {
+	s.Format = strings.ToLower(s.Format)
+	if !slices.Contains(output.Formats, s.Format) {
+		return fmt.Errorf("the format %q is not supported", s.Format)
+	}
+
+	switch s.Path {
+	case "":
+		return errors.New("the path is required")
+	case path:
+		return fmt.Errorf(
+			"the output of the feed is already written to %s",
+			path,
+		)
+	case "-":
+		if s.Format == "content" {
+			return errors.New(
+				"the content format cannot be written to standard output",
+			)
+		}
+	}
+
+	if s.Format == "template" && cfg.Template == "" {
+		return errors.New(
+			"the template input is required for the template format",
+		)
+	}
+
+	// The images of the page bundles are only downloaded into the output
+	// directory of the feed.
+	if s.Format == "content" && cfg.PageBundles {
+		return errors.New("page bundles are not supported for sinks")
+	}
+
+	if isRemoteURL(s.Path) {
+		if err := checkRemoteURL(s.Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupFeed returns the feed that is configured using the url, actor, and
+// path inputs. The second result is false if none of the inputs are set.
+func lookupFeed() (feedConfig, bool) {
+	url, hasURL := lookupInput("URL")
+	actor, hasActor := lookupInput("ACTOR")
+	handle, hasHandle := lookupInput("HANDLE")
+	path, hasPath := lookupInput("PATH")
+	selfURL, _ := lookupInput("SELF_URL")
+	return feedConfig{
+		URL:     url,
+		Actor:   actor,
+		Handle:  handle,
+		Path:    path,
+		SelfURL: selfURL,
+	}, hasURL || hasActor || hasHandle || hasPath
+}
+
+// parseFeeds parses the value of the feeds input. Each non-empty line of the
+// value contains the URL of the feed, or the handle or DID of the account
+// when the source is xrpc, followed by whitespace and the path that the
+// transformed feed is written to. The path can be followed by the URL that
+// the RSS output is published at.
+func parseFeeds(source string, value string) ([]feedConfig, error) {
+	var feeds []feedConfig
+	for n, line := range strings.Split(value, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf(
+				"line %d of the feeds input must contain a feed and a path",
+				n+1,
+			)
+		}
+
+		feed := feedConfig{Path: fields[1]}
+		if len(fields) == 3 {
+			feed.SelfURL = fields[2]
+		}
+		if source == "xrpc" {
+			feed.Actor = fields[0]
+		} else {
+			feed.URL = fields[0]
+		}
+
+		feeds = append(feeds, feed)
+	}
+
+	if len(feeds) == 0 {
+		return nil, errors.New("the feeds input does not contain any feeds")
+	}
+
+	return feeds, nil
+}
+
+// parseSinks parses the value of the sinks input. Each non-empty line of the
+// value contains the format of the sink followed by whitespace and the path
+// that the sink is written to.
+func parseSinks(value string) ([]sinkConfig, error) {
+	var sinks []sinkConfig
+	for n, line := range strings.Split(value, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) != 2 {
+			return nil, fmt.Errorf(
+				"line %d of the sinks input must contain a format and a path",
+				n+1,
+			)
+		}
+
+		sinks = append(sinks, sinkConfig{Format: fields[0], Path: fields[1]})
+	}
+
+	return sinks, nil
+}
+
+// findConfigFile returns the name of the first file in configFileNames that
+// exists in the working directory, or an empty string if there is none.
+func findConfigFile() string {
+	for _, name := range configFileNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// readConfigFile decodes the YAML or TOML configuration file name into cfg.
+// The format of the file is determined by the file extension. Settings that
+// are not present in the file keep their current values.
+func readConfigFile(name string, cfg *config) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read the configuration file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		err = toml.Unmarshal(data, cfg)
+	default:
+		return fmt.Errorf(
+			"the configuration file %s must be a YAML or TOML file",
+			name,
+		)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// lookupDuration parses the value of the action input name as a Go duration
+// string and stores it in d. d is not changed if the input is not set.
+func lookupDuration(name string, d *time.Duration) error {
+	value, ok := lookupInput(name)
+	if !ok {
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf(
+			"the %s input %q is not a valid duration",
+			strings.ToLower(name),
+			value,
+		)
+	}
+
+	*d = parsed
+	return nil
+}
+
+// sizeUnits are the multipliers of the units of the sizes that lookupSize
+// parses. The units are powers of 1024.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+}
+
+// lookupSize parses the value of the action input name as a size in bytes,
+// which can be followed by a unit such as KB or MB, and stores it in n. n
+// is not changed if the input is not set.
+func lookupSize(name string, n *int64) error {
+	value, ok := lookupInput(name)
+	if !ok {
+		return nil
+	}
+
+	number := strings.TrimSpace(value)
+	unit := strings.TrimLeft(number, "0123456789")
+	number = strings.TrimSuffix(number, unit)
+	multiplier, ok := sizeUnits[strings.ToLower(strings.TrimSpace(unit))]
+	parsed, err := strconv.ParseInt(number, 10, 64)
+	if !ok || err != nil || parsed > math.MaxInt64/multiplier {
+		return fmt.Errorf(
+			"the %s input %q is not a valid size",
+			strings.ToLower(name),
+			value,
+		)
+	}
+
+	*n = parsed * multiplier
+	return nil
+}
+
+// lookupInt parses the value of the action input name as an integer and
+// stores it in n. n is not changed if the input is not set.
+func lookupInt(name string, n *int) error {
+	value, ok := lookupInput(name)
+	if !ok {
+		return nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf(
+			"the %s input %q must be an integer",
+			strings.ToLower(name),
+			value,
+		)
+	}
+
+	*n = parsed
+	return nil
+}
+
+// lookupBool parses the value of the action input name as a boolean and
+// stores it in b. b is not changed if the input is not set.
+func lookupBool(name string, b *bool) error {
+	value, ok := lookupInput(name)
+	if !ok {
+		return nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf(
+			"the %s input %q must be true or false",
+			strings.ToLower(name),
+			value,
+		)
+	}
+
+	*b = parsed
+	return nil
+}
+
+// parseLines splits an input value that contains one value on each line,
+// such as the date_layouts input. It is used instead of splitList for the
+// inputs whose values can contain commas. Empty lines are removed.
+func parseLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// parseHeaders parses the value of the headers input, which contains one
+// "Name: value" header on each line.
+func parseHeaders(value string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, line := range parseLines(value) {
+		name, v, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf(
+				"the header %q must be formatted as \"Name: value\"",
+				line,
+			)
+		}
+
+		headers[name] = strings.TrimSpace(v)
+	}
+
+	return headers, nil
+}
+
+// parseRewrite parses the value of the rewrite input, which contains one
+// "field=template" rewrite on each line.
+func parseRewrite(value string) (map[string]string, error) {
+	rewrite := make(map[string]string)
+	for _, line := range parseLines(value) {
+		field, tmpl, ok := strings.Cut(line, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || field == "" {
+			return nil, fmt.Errorf(
+				"the rewrite %q must be formatted as \"field=template\"",
+				line,
+			)
+		}
+
+		rewrite[field] = tmpl
+	}
+
+	return rewrite, nil
+}
+
+// parseTaxonomyMap parses the value of the taxonomy_map input, which contains
+// one "hashtag=taxonomy" or "hashtag=taxonomy:term" mapping on each line.
+func parseTaxonomyMap(value string) (map[string]string, error) {
+	taxonomies := make(map[string]string)
+	for _, line := range parseLines(value) {
+		hashtag, taxonomy, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(hashtag) == "" {
+			return nil, fmt.Errorf(
+				"the taxonomy mapping %q must be formatted as "+
+					"\"hashtag=taxonomy\"",
+				line,
+			)
+		}
+
+		taxonomies[hashtag] = taxonomy
+	}
+
+	return taxonomies, nil
+}
+
+// taxonomies returns the assignment of hashtags to Hugo taxonomies that is
+// configured by the taxonomy and taxonomy_map inputs. The hashtags are
+// compared without regard to case or a leading #.
+func (cfg config) taxonomies() (output.Taxonomies, error) {
+	taxonomies := output.Taxonomies{
+		Default: strings.TrimSpace(cfg.Taxonomy),
+		Terms:   make(map[string]output.TaxonomyTerm, len(cfg.TaxonomyMap)),
+	}
+	if taxonomies.Default != "" {
+		err := output.ValidateTaxonomy(taxonomies.Default)
+		if err != nil {
+			return output.Taxonomies{}, err
+		}
+	}
+
+	for hashtag, value := range cfg.TaxonomyMap {
+		term, err := output.ParseTaxonomyTerm(value)
+		if err != nil {
+			return output.Taxonomies{}, err
+		}
+
+		hashtag = strings.TrimPrefix(strings.TrimSpace(hashtag), "#")
+		taxonomies.Terms[strings.ToLower(hashtag)] = term
+	}
+
+	return taxonomies, nil
+}
+
+// splitList splits a comma or newline separated input value into a list of
+// values. Empty values are removed.
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// The exit codes of the program. Each class of failure has its own exit
+// code so that workflow steps and scripts can react to the failure without
+// parsing the log. The flag package also exits with exitConfig when the
+// command-line arguments cannot be parsed.
+const (
+	// exitFailure is the exit code for failures that do not belong to
+	// one of the other classes, or when feeds failed for different reasons.
+	exitFailure = 1
+
+	// exitConfig is the exit code when the arguments, the configuration,
+	// or the state file are not valid.
+	exitConfig = 2
+
+	// exitFetch is the exit code when a feed cannot be downloaded or the
+	// program cannot sign in to Bluesky.
+	exitFetch = 3
+
+	// exitParse is the exit code when a downloaded feed or the dates of
+	// its posts cannot be parsed.
+	exitParse = 4
+
+	// exitWrite is the exit code when the output, the state file, or the
+	// step outputs cannot be written, or the output cannot be committed.
+	exitWrite = 5
+
+	// exitUnchanged is the exit code when none of the output has changed
+	// and the exit_unchanged input is set.
+	exitUnchanged = 6
+
+	// exitInterrupted is the exit code when the program received an
+	// interrupt or termination signal before all of the feeds were
+	// transformed.
+	exitInterrupted = 7
+)
+
+// exitError is an error that determines the exit code of the program.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode returns err with the exit code code.
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// fetchError returns err, which is an error that was returned while a feed
+// was downloaded, with the exit code of its class. A feed.ParseError is a
+// parse error, and any other error is a fetch error.
+func fetchError(err error) error {
+	var parseErr *feed.ParseError
+	if errors.As(err, &parseErr) {
+		return withExitCode(exitParse, err)
+	}
+
+	return withExitCode(exitFetch, err)
+}
+
+// exitCode returns the exit code for err. exitFailure is returned if err
+// does not have an exit code.
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	return exitFailure
+}
+
+// fatal logs msg as an error and exits the program with code.
+func fatal(code int, msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(code)
+}
@@ -0,0 +1,175 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// export runs the export command. The export command downloads every post
+// in the author feed of the account given by the actor or handle input and
+// writes the posts to the file given by the path input as JSON Lines, which
+// creates a complete archive of the account that can be processed again
+// later. Each line contains a post as the app.bsky.feed.getAuthorFeed
+// endpoint returned it, including the raw post record. The archive is
+// written to standard output if the path input is not set or is "-".
+//
+// The archive is written to a temporary file that replaces the file at the
+// path once every post has been written, so a failed export does not
+// overwrite the previous archive.
+func export(args []string) {
+	flags := newFlagSet("blueskyrss export", "blueskyrss export [flags]")
+	cfg := setup(flags, args)
+	fc, _ := lookupFeed()
+	actor := strings.TrimPrefix(cmp.Or(fc.Actor, fc.Handle), "@")
+	if actor == "" {
+		err := errors.New("the actor or handle input is required")
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	r, err := newRunner(cfg, nil)
+	if err != nil {
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+	)
+	defer stop()
+
+	if err = r.signIn(ctx); err != nil {
+		fatal(exitFetch, "Failed to sign in to Bluesky.", "error", err)
+	}
+
+	count, err := r.exportPosts(ctx, actor, fc.Path)
+	if err != nil {
+		fatal(exitCode(err), "Failed to export the posts.", "error", err)
+	}
+
+	slog.Info("Exported the posts.", "actor", actor, "posts", count)
+}
+
+// exportPosts writes the posts in the author feed of actor to path, or to
+// standard output if path is empty or "-", and returns the number of posts
+// that were written.
+func (r *runner) exportPosts(
+	ctx context.Context,
+	actor string,
+	path string,
+) (int, error) {
+	if path == "" || path == "-" {
+		return r.exportTo(ctx, actor, os.Stdout)
+	}
+
+	tmp, err := os.CreateTemp(
+		filepath.Dir(path),
+		"."+filepath.Base(path)+".*",
+	)
+	if err != nil {
+		return 0, withExitCode(
+			exitWrite,
+			fmt.Errorf("failed to create the archive: %w", err),
+		)
+	}
+
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	count, err := r.exportTo(ctx, actor, tmp)
+	if err != nil {
+		return count, err
+	}
+
+	if err = tmp.Chmod(0o644); err == nil {
+		err = tmp.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+
+	if err != nil {
+		return count, withExitCode(
+			exitWrite,
+			fmt.Errorf("failed to write %s: %w", path, err),
+		)
+	}
+
+	return count, nil
+}
+
+// exportTo writes the posts in the author feed of actor to w. The posts
+// are buffered because each post is written as a separate line. The errors
+// that are returned by w have the exit code exitWrite and the other errors
+// have the exit code of their class.
+func (r *runner) exportTo(
+	ctx context.Context,
+	actor string,
+	w io.Writer,
+) (int, error) {
+	buf := bufio.NewWriter(exportWriter{w})
+	count, err := r.fetcher.ExportAuthorFeed(ctx, actor, buf)
+	if err != nil && exitCode(err) != exitWrite {
+		err = fetchError(
+			fmt.Errorf("failed to download the author feed: %w", err),
+		)
+	}
+
+	if flushErr := buf.Flush(); err == nil {
+		err = flushErr
+	}
+
+	return count, err
+}
+
+// exportWriter is an io.Writer that gives the errors that are returned by
+// the wrapped writer the exit code exitWrite, which distinguishes them from
+// the errors that are returned while the posts are downloaded.
+type exportWriter struct {
+	w io.Writer
+}
+
+func (w exportWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil {
+		err = withExitCode(
+			exitWrite,
+			fmt.Errorf("failed to write the archive: %w", err),
+		)
+	}
+
+	return n, err
+}
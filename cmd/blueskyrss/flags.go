@@ -0,0 +1,471 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// inputFlags describes the command-line flags that mirror the inputs of the
+// action. The name of each flag is the name of the input with the
+// underscores replaced by hyphens. Inputs that accept a list of values can
+// be repeated on the command line.
+var inputFlags = []struct {
+	input    string
+	usage    string
+	boolean  bool
+	multiple bool
+}{
+	{input: "CONFIG", usage: "the path to a YAML or TOML configuration `file`"},
+	{
+		input: "SOURCE",
+		usage: "the `source` of the posts: rss, xrpc, mastodon, microblog, or generic",
+	},
+	{
+		input: "URL",
+		usage: "the `URL` of the Bluesky RSS feed, or - for standard input",
+	},
+	{input: "ACTOR", usage: "the `handle` or DID of the account to fetch"},
+	{
+		input: "HANDLE",
+		usage: "the `handle` of the account whose feed is transformed",
+	},
+	{input: "APPVIEW_URL", usage: "the `URL` of the AppView for xrpc"},
+	{input: "PDS_URL", usage: "the `URL` of the PDS that is signed in to"},
+	{
+		input: "PATH",
+		usage: "the `path` that the output is written to, or - for standard " +
+			"output",
+	},
+	{input: "SELF_URL", usage: "the `URL` that the RSS output is published at"},
+	{
+		input:    "SINKS",
+		usage:    "an additional output as \"<format> <path>\"",
+		multiple: true,
+	},
+	{
+		input:    "FEEDS",
+		usage:    "a `feed` to transform as \"<url-or-actor> <path> [url]\"",
+		multiple: true,
+	},
+	{input: "CONCURRENCY", usage: "the `number` of feeds processed at once"},
+	{input: "FORMAT", usage: "the output `format`"},
+	{
+		input: "LINK_CARDS",
+		usage: "where link cards are written: content or front_matter",
+	},
+	{
+		input: "TAXONOMY",
+		usage: "the Hugo `taxonomy` that the hashtags are assigned to",
+	},
+	{
+		input:    "TAXONOMY_MAP",
+		usage:    "assign a hashtag to a taxonomy as \"<hashtag>=<taxonomy>\"",
+		multiple: true,
+	},
+	{input: "SHORTCODE", usage: "the `name` of the shortcode written per post"},
+	{input: "TEMPLATE", usage: "the Go template `file` of the template format"},
+	{
+		input: "TITLE_STYLE",
+		usage: "how the titles of the posts are created: " +
+			"none, line, sentence, words, or template",
+	},
+	{input: "TITLE_WORDS", usage: "the `number` of words of a words title"},
+	{input: "TITLE_TEMPLATE", usage: "the Go `template` of the post titles"},
+	{
+		input: "FRONT_MATTER",
+		usage: "the format of the front matter: yaml, toml, or json",
+	},
+	{input: "ARCHETYPE", usage: "the Go template `file` of the content pages"},
+	{
+		input: "SLUG_STYLE",
+		usage: "how the slugs of the content pages are created: " +
+			"rkey, date-rkey, words, or template",
+	},
+	{input: "SLUG_TEMPLATE", usage: "the Go `template` of the page slugs"},
+	{input: "SUMMARY_LENGTH", usage: "the `length` of the post summaries"},
+	{
+		input: "SUMMARY_UNIT",
+		usage: "the unit of the summary length: characters or words",
+	},
+	{
+		input: "LANGUAGE",
+		usage: "the `language` of the RSS output, such as en-us",
+	},
+	{input: "DATE_FORMAT", usage: "the `layout` used to rewrite the dates"},
+	{
+		input:    "DATE_LAYOUTS",
+		usage:    "an additional `layout` used to parse the dates",
+		multiple: true,
+	},
+	{input: "TIMEZONE", usage: "the IANA time `zone` that the dates use"},
+	{input: "STATE_FILE", usage: "the `path` of the state file"},
+	{input: "CACHE_DIR", usage: "the `directory` that the posts are cached in"},
+	{input: "REPORT_FILE", usage: "the `path` that the run report is written to"},
+	{input: "LOG_LEVEL", usage: "the minimum `level` of the logged messages"},
+	{input: "LOG_FORMAT", usage: "the `format` of the log: text or json"},
+	{
+		input: "ON_ERROR",
+		usage: "how a post that cannot be transformed is handled: " +
+			"fail, skip-item, or passthrough",
+	},
+	{
+		input:    "PIPELINE",
+		usage:    "a `step` of the transformation pipeline, in order",
+		multiple: true,
+	},
+	{
+		input: "GUID_POLICY",
+		usage: "how the GUIDs of the posts are created: uri, hash, or link",
+	},
+	{
+		input:   "SKIP_UNCHANGED",
+		usage:   "only write the files that have changed",
+		boolean: true,
+	},
+	{
+		input:   "EXIT_UNCHANGED",
+		usage:   "exit with code 6 when none of the output has changed",
+		boolean: true,
+	},
+	{
+		input:   "GZIP",
+		usage:   "write a gzip-compressed copy of the output file",
+		boolean: true,
+	},
+	{
+		input:   "CHECKSUMS",
+		usage:   "write the SHA-256 checksums of the output files",
+		boolean: true,
+	},
+	{
+		input: "SIGNING_KEY",
+		usage: "the OpenSSH private `key` that signs the checksums",
+	},
+	{
+		input: "SIGNING_NAMESPACE",
+		usage: "the `namespace` of the signatures of the checksums",
+	},
+	{
+		input:   "DRY_RUN",
+		usage:   "print a diff of the output instead of writing it",
+		boolean: true,
+	},
+	{
+		input:   "STREAM",
+		usage:   "rewrite the dates of an RSS feed while it is downloaded",
+		boolean: true,
+	},
+	{input: "USER_AGENT", usage: "the User-Agent header of the requests"},
+	{
+		input:    "HEADERS",
+		usage:    "an additional request header as \"Name: value\"",
+		multiple: true,
+	},
+	{
+		input: "CA_FILE",
+		usage: "a PEM `file` of additional trusted certificate authorities",
+	},
+	{input: "TIMEOUT", usage: "the `duration` after which a request is canceled"},
+	{input: "RETRIES", usage: "the `number` of times a failed request is retried"},
+	{input: "RETRY_DELAY", usage: "the `delay` before the first retry"},
+	{input: "RETRY_MAX_DELAY", usage: "the maximum `delay` between retries"},
+	{
+		input: "MAX_RESPONSE_SIZE",
+		usage: "the maximum `size` of a response, such as 32MB",
+	},
+	{
+		input: "MAX_REDIRECTS",
+		usage: "the maximum `number` of redirects a request follows",
+	},
+	{
+		input: "RATE_LIMIT_WAIT",
+		usage: "the `duration` a request waits for a rate limit to reset",
+	},
+	{
+		input:   "SANITIZE",
+		usage:   "sanitize the descriptions of the posts",
+		boolean: true,
+	},
+	{
+		input:    "ALLOWED_TAGS",
+		usage:    "the HTML `elements` kept by the sanitizer",
+		multiple: true,
+	},
+	{
+		input:   "NORMALIZE_UNICODE",
+		usage:   "convert the text of the posts into Unicode NFC",
+		boolean: true,
+	},
+	{input: "EMOJI", usage: "what happens to emoji: keep, strip, or shortcode"},
+	{
+		input:   "EXCLUDE_REPLIES",
+		usage:   "remove the posts that are replies",
+		boolean: true,
+	},
+	{
+		input:   "EXCLUDE_REPOSTS",
+		usage:   "remove the posts that were reposted",
+		boolean: true,
+	},
+	{
+		input: "LABEL_POLICY",
+		usage: "how labeled posts are handled: keep, warn, or drop",
+	},
+	{
+		input:    "LABELS",
+		usage:    "a `label` that the label policy applies to",
+		multiple: true,
+	},
+	{
+		input:    "INCLUDE_TAGS",
+		usage:    "keep only the posts that have one of the `hashtags`",
+		multiple: true,
+	},
+	{
+		input:    "EXCLUDE_TAGS",
+		usage:    "remove the posts that have one of the `hashtags`",
+		multiple: true,
+	},
+	{
+		input: "INCLUDE_PATTERN",
+		usage: "keep only the posts whose text matches the `regexp`",
+	},
+	{
+		input: "EXCLUDE_PATTERN",
+		usage: "remove the posts whose text matches the `regexp`",
+	},
+	{
+		input: "FILTER_TEMPLATE",
+		usage: "keep only the posts for which the Go `template` is true",
+	},
+	{
+		input:    "REWRITE",
+		usage:    "rewrite a field of the posts as \"<field>=<template>\"",
+		multiple: true,
+	},
+	{
+		input:    "LANGUAGES",
+		usage:    "keep only the posts written in one of the `languages`",
+		multiple: true,
+	},
+	{input: "SINCE", usage: "remove the posts published before the `date`"},
+	{input: "UNTIL", usage: "remove the posts published after the `date`"},
+	{input: "MAX_ITEMS", usage: "the maximum `number` of posts that are written"},
+	{input: "MAX_PAGES", usage: "the maximum `number` of pages that are fetched"},
+	{
+		input:   "THREADS",
+		usage:   "combine self-reply threads into a single post",
+		boolean: true,
+	},
+	{
+		input:   "LINK_PREVIEWS",
+		usage:   "build link cards for the links in the text of posts",
+		boolean: true,
+	},
+	{
+		input: "LINK_PREVIEW_TIMEOUT",
+		usage: "how long the download of a linked page can take",
+	},
+	{input: "LINK_PREVIEW_TTL", usage: "how long a link card is cached"},
+	{
+		input:   "MERGE",
+		usage:   "merge the posts into the existing output",
+		boolean: true,
+	},
+	{
+		input:   "INCREMENTAL",
+		usage:   "only download the posts that are newer than the last run",
+		boolean: true,
+	},
+	{
+		input: "FUTURE",
+		usage: "how future posts are handled: keep, clamp, draft, or drop",
+	},
+	{
+		input: "DRAFT_AFTER",
+		usage: "mark the posts published after the `date` as drafts",
+	},
+	{
+		input: "DELETED_POLICY",
+		usage: "how deleted archived posts are handled: keep, mark, or drop",
+	},
+	{
+		input:   "ENGAGEMENT",
+		usage:   "write the reply, repost, like, and quote counts",
+		boolean: true,
+	},
+	{
+		input:   "REFRESH_ENGAGEMENT",
+		usage:   "download the current counts of every post",
+		boolean: true,
+	},
+	{
+		input: "SORT",
+		usage: "the order of the posts: newest, oldest, or as-fetched",
+	},
+	{
+		input: "PINNED",
+		usage: "how the pinned post is handled: ignore, flag, or first",
+	},
+	{input: "IMAGE_DIR", usage: "the `directory` that images are downloaded to"},
+	{input: "IMAGE_BASE_URL", usage: "the `URL` of the image directory"},
+	{
+		input:   "PAGE_BUNDLES",
+		usage:   "write the content pages as page bundles with their images",
+		boolean: true,
+	},
+	{
+		input: "IMAGE_CONCURRENCY",
+		usage: "the `number` of images that are downloaded at once",
+	},
+	{
+		input: "IMAGE_HOST_INTERVAL",
+		usage: "the minimum `duration` between downloads from a host",
+	},
+	{input: "WEBHOOK_URL", usage: "the `URL` that is notified about new posts"},
+	{
+		input: "WEBHOOK_FORMAT",
+		usage: "the `format` of the notification: generic, slack, or discord",
+	},
+	{input: "WEBHOOK_SECRET", usage: "the `secret` that signs the notification"},
+	{
+		input:   "GIT_COMMIT",
+		usage:   "commit the output to the Git repository after each run",
+		boolean: true,
+	},
+	{
+		input: "GIT_COMMIT_MESSAGE",
+		usage: "the `template` of the commit message",
+	},
+	{
+		input:   "GIT_PUSH",
+		usage:   "push the commit to the origin remote",
+		boolean: true,
+	},
+	{input: "GIT_AUTHOR_NAME", usage: "the `name` of the author of the commit"},
+	{
+		input: "GIT_AUTHOR_EMAIL",
+		usage: "the email `address` of the author of the commit",
+	},
+}
+
+// flagInputs contains the values of the command-line flags that were set,
+// keyed by the name of the input that the flag mirrors. The values take
+// precedence over the INPUT_* environment variables.
+var flagInputs = map[string]string{}
+
+// inputValue is a flag.Value that stores the value of a flag in flagInputs.
+type inputValue struct {
+	input    string
+	multiple bool
+}
+
+func (v inputValue) String() string {
+	return ""
+}
+
+// Set stores the value of the flag. The values of a flag that accepts a
+// list of values are joined using newlines, which is how multiple values
+// are specified for the action inputs.
+func (v inputValue) Set(value string) error {
+	if existing, ok := flagInputs[v.input]; ok && v.multiple {
+		value = existing + "\n" + value
+	}
+
+	flagInputs[v.input] = value
+	return nil
+}
+
+// boolInputValue is an inputValue for a flag that can be set without a
+// value, such as -sanitize.
+type boolInputValue struct {
+	inputValue
+}
+
+func (boolInputValue) IsBoolFlag() bool {
+	return true
+}
+
+// newFlagSet returns a flag set named name that defines the command-line
+// flags that mirror the inputs of the action. usage is the synopsis of the
+// command that is printed before the flags by -help.
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(
+			flags.Output(),
+			"Usage: %s\n\n"+
+				"Every flag that mirrors an action input can also be set "+
+				"using the\nINPUT_* environment variable for the input, such "+
+				"as INPUT_DATE_FORMAT\nfor -date-format.\n\n",
+			usage,
+		)
+		flags.PrintDefaults()
+	}
+
+	for _, f := range inputFlags {
+		name := strings.ReplaceAll(strings.ToLower(f.input), "_", "-")
+		value := inputValue{input: f.input, multiple: f.multiple}
+		if f.boolean {
+			flags.Var(boolInputValue{value}, name, f.usage)
+		} else {
+			flags.Var(value, name, f.usage)
+		}
+	}
+
+	return flags
+}
+
+// parseFlags parses the command-line arguments using flags. The values of
+// the flags that mirror the action inputs are stored in flagInputs.
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	return nil
+}
+
+// lookupInput returns the value of the action input name. The value of the
+// command-line flag for the input is used if the flag was set. Otherwise,
+// the INPUT_* environment variable is used. GitHub Actions sets inputs that
+// have no value to an empty string, so an empty value is treated the same
+// as an input that is not set.
+func lookupInput(name string) (string, bool) {
+	value, ok := flagInputs[name]
+	if !ok {
+		value, ok = os.LookupEnv("INPUT_" + name)
+	}
+
+	if !ok || value == "" {
+		return "", false
+	}
+
+	return value, true
+}
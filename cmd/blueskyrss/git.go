@@ -0,0 +1,273 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+)
+
+// The default author of the commits that are created by the git_commit
+// input. GitHub shows the commits as commits by the github-actions bot.
+const (
+	defaultGitAuthorName  = "github-actions[bot]"
+	defaultGitAuthorEmail = "41898283+github-actions[bot]" +
+		"@users.noreply.github.com"
+)
+
+// defaultGitCommitMessage is the default template of the message of the
+// commits that are created by the git_commit input.
+const defaultGitCommitMessage = "Update the Bluesky feeds"
+
+// gitCommitter commits the output that a run wrote to the Git repository
+// in the working directory, and pushes the commit if push is true. The new
+// posts are collected while the feeds are processed, and the commit is
+// created after the run.
+type gitCommitter struct {
+	paths   []string
+	message *template.Template
+	name    string
+	email   string
+	push    bool
+
+	mu    sync.Mutex
+	posts []feed.Item
+}
+
+// newGitCommitter returns a gitCommitter for the output of cfg. The paths
+// that are committed are the output paths of the feeds and their sinks,
+// the image directory, the state file, and the report file.
+func newGitCommitter(cfg config) (*gitCommitter, error) {
+	message, err := template.New("commit").
+		Funcs(output.TemplateFuncs).
+		Parse(cfg.GitCommitMessage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the commit message: %w", err)
+	}
+
+	g := &gitCommitter{
+		message: message,
+		name:    cfg.GitAuthorName,
+		email:   cfg.GitAuthorEmail,
+		push:    cfg.GitPush,
+	}
+	for _, fc := range cfg.Feeds {
+		g.paths = append(g.paths, fc.Path)
+		for _, s := range fc.Sinks {
+			g.paths = append(g.paths, s.Path)
+		}
+	}
+
+	g.paths = append(g.paths, cfg.ImageDir, cfg.StateFile, cfg.ReportFile)
+	g.paths = slices.DeleteFunc(g.paths, func(path string) bool {
+		return path == "" || !isFilePath(path)
+	})
+	slices.Sort(g.paths)
+	g.paths = slices.Compact(g.paths)
+	return g, nil
+}
+
+// commit commits the output of the run and records the hash of the commit
+// in the step outputs. Like the notification, the commit is created for
+// the output that was written even if the program is stopping.
+func (r *runner) commit(ctx context.Context) error {
+	hash, err := r.git.commit(context.WithoutCancel(ctx))
+	if hash != "" {
+		r.outputs.recordCommit(hash)
+	}
+
+	return err
+}
+
+// gitCommitData is the data that the template of the commit message is
+// executed with. Count is the number of posts that were added to the
+// output, and Posts are the posts.
+type gitCommitData struct {
+	Count int
+	Posts []output.TemplatePost
+	Date  time.Time
+}
+
+// add adds posts to the posts that the commit message is rendered for.
+func (g *gitCommitter) add(posts []feed.Item) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.posts = append(g.posts, posts...)
+}
+
+// commit stages the output paths and commits them. Only the output paths
+// are committed, so other changes in the working tree or the index are
+// left alone. Paths that do not exist or that are ignored by Git are
+// skipped. commit returns the hash of the commit, or an empty string if
+// none of the output has changed since the last commit.
+func (g *gitCommitter) commit(ctx context.Context) (string, error) {
+	if g == nil {
+		return "", nil
+	}
+
+	g.mu.Lock()
+	posts := g.posts
+	g.posts = nil
+	g.mu.Unlock()
+
+	var paths []string
+	for _, path := range g.paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		// git add fails for a path that is ignored, which is common for
+		// the state file when it is restored from a cache.
+		_, err := g.git(ctx, "check-ignore", "--quiet", "--", path)
+		if err == nil {
+			continue
+		}
+
+		paths = append(paths, path)
+	}
+
+	if len(paths) == 0 {
+		return "", nil
+	}
+
+	_, err := g.git(ctx, append([]string{"add", "--all", "--"}, paths...)...)
+	if err != nil {
+		return "", err
+	}
+
+	// git diff exits with exit code 1 when there are staged changes.
+	_, err = g.git(
+		ctx,
+		append([]string{"diff", "--cached", "--quiet", "--"}, paths...)...,
+	)
+	var exitErr *exec.ExitError
+	if err == nil {
+		slog.Info("None of the output needs to be committed.")
+		return "", nil
+	} else if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		return "", err
+	}
+
+	message, err := g.render(posts)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = g.git(
+		ctx,
+		append(
+			[]string{"commit", "--quiet", "--message", message, "--"},
+			paths...,
+		)...,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := g.git(ctx, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	slog.Info("Committed the output.", "commit", hash)
+	if g.push {
+		_, err = g.git(ctx, "push", "--quiet", "origin", "HEAD")
+		if err != nil {
+			return hash, err
+		}
+
+		slog.Info("Pushed the commit.", "commit", hash)
+	}
+
+	return hash, nil
+}
+
+// render renders the commit message for posts.
+func (g *gitCommitter) render(posts []feed.Item) (string, error) {
+	data := gitCommitData{
+		Count: len(posts),
+		Posts: make([]output.TemplatePost, 0, len(posts)),
+		Date:  time.Now(),
+	}
+	for _, post := range posts {
+		data.Posts = append(data.Posts, output.NewTemplatePost(post))
+	}
+
+	var buf bytes.Buffer
+	if err := g.message.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render the commit message: %w", err)
+	}
+
+	message := strings.TrimSpace(buf.String())
+	if message == "" {
+		return "", errors.New("the commit message is empty")
+	}
+
+	return message, nil
+}
+
+// git runs the git command with args and returns its output. The commits
+// are created by the configured author. In GitHub Actions, the workspace
+// is owned by a different user than the container of the action, so the
+// workspace is marked as a safe directory.
+func (g *gitCommitter) git(
+	ctx context.Context,
+	args ...string,
+) (string, error) {
+	config := []string{
+		"-c", "user.name=" + g.name,
+		"-c", "user.email=" + g.email,
+	}
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		config = append(config, "-c", "safe.directory="+workspace)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append(config, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, msg)
+		}
+
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// inGitHubActions reports whether the program is running in a GitHub
+// Actions workflow.
+func inGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// annotationHandler is a slog.Handler that writes warnings and errors as
+// GitHub Actions workflow commands, which GitHub shows as annotations on
+// the workflow run. All messages are also passed to the wrapped handler.
+type annotationHandler struct {
+	slog.Handler
+	w     io.Writer
+	mu    *sync.Mutex
+	attrs []slog.Attr
+}
+
+func newAnnotationHandler(h slog.Handler, w io.Writer) *annotationHandler {
+	return &annotationHandler{Handler: h, w: w, mu: &sync.Mutex{}}
+}
+
+func (h *annotationHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelWarn {
+		command := "warning"
+		if r.Level >= slog.LevelError {
+			command = "error"
+		}
+
+		var b strings.Builder
+		b.WriteString(r.Message)
+		attrs := slices.Clone(h.attrs)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a)
+			return true
+		})
+		for _, a := range attrs {
+			fmt.Fprintf(&b, " %s=%s", a.Key, a.Value)
+		}
+
+		h.mu.Lock()
+		_, err := fmt.Fprintf(
+			h.w,
+			"::%s::%s\n",
+			command,
+			escapeWorkflowCommand(b.String()),
+		)
+		h.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *annotationHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &annotationHandler{
+		Handler: h.Handler.WithAttrs(attrs),
+		w:       h.w,
+		mu:      h.mu,
+		attrs:   append(slices.Clone(h.attrs), attrs...),
+	}
+}
+
+func (h *annotationHandler) WithGroup(name string) slog.Handler {
+	return &annotationHandler{
+		Handler: h.Handler.WithGroup(name),
+		w:       h.w,
+		mu:      h.mu,
+		attrs:   h.attrs,
+	}
+}
+
+// escapeWorkflowCommand escapes the characters that have a special meaning
+// in the message of a workflow command.
+func escapeWorkflowCommand(s string) string {
+	return strings.NewReplacer(
+		"%", "%25",
+		"\r", "%0D",
+		"\n", "%0A",
+	).Replace(s)
+}
+
+// stepOutputs collects the outputs of the action step for the feeds that
+// are processed during a run.
+type stepOutputs struct {
+	mu            sync.Mutex
+	changed       bool
+	itemsWritten  int
+	latest        time.Time
+	latestPostURL string
+	commit        string
+}
+
+// record records the items of a transformed feed. changed reports whether
+// the output of the feed was written.
+func (o *stepOutputs) record(items []feed.Item, changed bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if changed {
+		o.changed = true
+		o.itemsWritten += len(items)
+	}
+
+	for _, item := range items {
+		if item.Published.After(o.latest) {
+			o.latest = item.Published
+			o.latestPostURL = item.Link
+		}
+	}
+}
+
+// recordCommit records hash as the commit that the output was committed in.
+func (o *stepOutputs) recordCommit(hash string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.commit = hash
+}
+
+// write appends the outputs to the file name, which is the file that GitHub
+// Actions names in the GITHUB_OUTPUT environment variable. Nothing is
+// written if name is empty.
+func (o *stepOutputs) write(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_, err = fmt.Fprintf(
+		file,
+		"changed=%t\nitems_written=%d\nlatest_post_url=%s\ncommit=%s\n",
+		o.changed,
+		o.itemsWritten,
+		o.latestPostURL,
+		o.commit,
+	)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
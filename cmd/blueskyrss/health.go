@@ -0,0 +1,120 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// defaultStaleAfter is how long the feeds are allowed to fail before the
+// serve command reports that it is not ready when the -stale-after flag is
+// not set.
+const defaultStaleAfter = time.Hour
+
+// health tracks the downloads of the feeds for the /healthz and /readyz
+// endpoints of the serve command, which let a container orchestrator probe
+// the server.
+type health struct {
+	started    time.Time
+	staleAfter time.Duration
+
+	lastSuccess time.Time
+	lastFailure time.Time
+}
+
+// healthStatus is the body of the responses of the /healthz and /readyz
+// endpoints.
+type healthStatus struct {
+	Status      string `json:"status"`
+	LastSuccess string `json:"lastSuccess,omitempty"`
+	LastFailure string `json:"lastFailure,omitempty"`
+	StaleAfter  string `json:"staleAfter"`
+}
+
+// record records a download of a feed at now that failed with err.
+func (h *health) record(now time.Time, err error) {
+	if err != nil {
+		h.lastFailure = now
+	} else {
+		h.lastSuccess = now
+	}
+}
+
+// stale reports whether the feeds have been failing for longer than the
+// staleness threshold at now. The server is not stale while the last
+// download succeeded, and the threshold starts when the server starts if
+// no feed has been downloaded yet. A staleness threshold of zero disables
+// the check.
+func (h *health) stale(now time.Time) bool {
+	if h.staleAfter == 0 || !h.lastFailure.After(h.lastSuccess) {
+		return false
+	}
+
+	since := h.started
+	if h.lastSuccess.After(since) {
+		since = h.lastSuccess
+	}
+
+	return now.Sub(since) > h.staleAfter
+}
+
+func (h *health) status(status string) healthStatus {
+	s := healthStatus{Status: status, StaleAfter: h.staleAfter.String()}
+	if !h.lastSuccess.IsZero() {
+		s.LastSuccess = h.lastSuccess.UTC().Format(time.RFC3339)
+	}
+
+	if !h.lastFailure.IsZero() {
+		s.LastFailure = h.lastFailure.UTC().Format(time.RFC3339)
+	}
+
+	return s
+}
+
+// serveHealth handles the liveness probe, which succeeds as long as the
+// server is able to respond.
+func (s *server) serveHealth(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	status := s.health.status("ok")
+	s.mu.Unlock()
+	writeHealth(w, http.StatusOK, status)
+}
+
+// serveReady handles the readiness probe, which fails with status code 503
+// while the feeds have been failing for longer than the staleness threshold.
+func (s *server) serveReady(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	code, status := http.StatusOK, s.health.status("ok")
+	if s.health.stale(time.Now()) {
+		code, status = http.StatusServiceUnavailable, s.health.status("stale")
+	}
+	s.mu.Unlock()
+	writeHealth(w, code, status)
+}
+
+func writeHealth(w http.ResponseWriter, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
+}
@@ -25,100 +25,1335 @@
 // field is not formatted in a way that Hugo can parse the date and time from
 // the pubDate field. This GitHub Action program will parse and rewrite the
 // pubDate field into a format that Hugo can use.
+//
+// The transformed feed can be written back out as RSS, Atom, or JSON Feed, or
+// it can be written as a Hugo data file in JSON, YAML, or TOML format by
+// setting the format input. The content format writes one Markdown page per
+// post into the directory named by the path input, and the shortcode format
+// writes a Markdown fragment that calls a Hugo shortcode for each post. Any
+// other format can be written using the template format, which executes the
+// Go template file given by the template input.
+//
+// By default the feed is downloaded from the RSS URL given by the url input.
+// When the source input is set to xrpc, the posts for the handle or DID given
+// by the actor input are instead fetched from the AT Protocol
+// app.bsky.feed.getAuthorFeed endpoint and the RSS feed is synthesized from
+// the post records.
+//
+// Multiple feeds can be transformed in a single run by listing them in the
+// feeds input. The feeds are downloaded and transformed concurrently. Feeds
+// that are written to the same path are combined into a single feed, which
+// aggregates the posts of multiple accounts.
+//
+// The settings can also be loaded from a blueskyrss.yaml or blueskyrss.toml
+// configuration file. The inputs of the action override the values in the
+// configuration file. When the program is run outside of GitHub Actions,
+// the inputs can be set using command-line flags such as -url and -path
+// instead of the INPUT_* environment variables.
+//
+// When a state file is configured, the ETag and Last-Modified headers of the
+// downloaded feeds and a hash of the transformed output are stored in the
+// state file. The next run sends conditional requests and does not rewrite
+// output that has not changed, which prevents unnecessary Hugo rebuilds.
+//
+// The posts are fetched from the xrpc source without authentication unless
+// the BSKY_IDENTIFIER and BSKY_APP_PASSWORD environment variables are set to
+// the handle of an account and an app password for the account. The posts
+// are then fetched using an authenticated session, which includes posts
+// that are only visible to signed-in users and applies the rate limits of
+// the account instead of the anonymous rate limits.
+//
+// The -watch flag keeps the program running and transforms the feeds again
+// after each interval given by the -interval flag. This is useful when the
+// program runs on a server next to hugo server --watch. While a source is
+// rate limiting the requests, the runs are delayed until the time that the
+// source asked for, or for longer intervals if it did not say. The
+// -metrics-addr flag serves metrics about the runs at /metrics on the given
+// address in the Prometheus text format.
+//
+// The serve command runs the program as an HTTP server instead. The server
+// responds to requests for /feed?handle=<handle> with the transformed feed
+// for the account and caches the transformed feeds in memory. The metrics
+// of the server are served at /metrics, and /healthz and /readyz report
+// the health of the server to container orchestrators.
+//
+// The validate command checks the output that was written for the feeds
+// against the RSS 2.0, Atom, and JSON Feed specifications and exits with a
+// nonzero exit code if the output is not valid.
+//
+// The export command writes every post in the author feed of the account
+// given by the actor input to the file given by the path input as JSON
+// Lines, which keeps a complete archive of the account next to the feed.
+//
+// The program exits with a distinct exit code for each class of failure: 1
+// for an unexpected failure or when feeds failed for different reasons, 2
+// when the arguments or the configuration are not valid, 3 when a feed
+// cannot be downloaded, 4 when a feed or the dates of its posts cannot be
+// parsed, and 5 when the output cannot be written. When the exit_unchanged
+// input is set, the program exits with 6 if none of the output changed.
+//
+// The transformation itself is implemented by the feed, transform, and
+// output packages so that other Go programs can embed it.
 package main
 
 import (
-	"encoding/xml"
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
 	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"sync"
+	"syscall"
+	"text/template"
 	"time"
+
+	// The time zone database is embedded so that the timezone input works
+	// in the container image, which does not include the database.
+	_ "time/tzdata"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
 )
 
-type rss struct {
-	XMLName xml.Name `xml:"rss"`
-	Version string   `xml:"version,attr"`
-	Channel channel  `xml:"channel"`
-}
+// defaultWatchInterval is the time between the runs in watch mode when the
+// -interval flag is not set.
+const defaultWatchInterval = 15 * time.Minute
+
+// maxWatchBackoff is the longest time between the runs in watch mode while
+// a source is rate limiting the requests without saying when to try again,
+// unless the -interval flag is longer.
+const maxWatchBackoff = 6 * time.Hour
+
+// uploadTimeout is how long an upload of a file to an object store can
+// take.
+const uploadTimeout = 5 * time.Minute
+
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "serve":
+			serve(args[1:])
+			return
+		case "validate":
+			validate(args[1:])
+			return
+		case "export":
+			export(args[1:])
+			return
+		}
+	}
+
+	flags := newFlagSet(
+		"blueskyrss",
+		"blueskyrss [flags]\n"+
+			"       blueskyrss serve [flags]\n"+
+			"       blueskyrss validate [flags]\n"+
+			"       blueskyrss export [flags]",
+	)
+	watch := flags.Bool(
+		"watch",
+		false,
+		"keep running and transform the feeds again after each interval",
+	)
+	interval := flags.Duration(
+		"interval",
+		defaultWatchInterval,
+		"the `duration` between the runs in watch mode",
+	)
+	metricsAddr := flags.String(
+		"metrics-addr",
+		"",
+		"the `address` that the metrics are served on in watch mode",
+	)
+	cfg := setup(flags, args)
+	if *watch && *interval <= 0 {
+		err := errors.New("the interval must be positive")
+		fatal(exitConfig, "Invalid arguments.", "error", err)
+	}
+
+	if *metricsAddr != "" && !*watch {
+		err := errors.New("the metrics are only served in watch mode")
+		fatal(exitConfig, "Invalid arguments.", "error", err)
+	}
+
+	if err := cfg.loadFeeds(); err != nil {
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	if *watch && slices.ContainsFunc(cfg.Feeds, func(f feedConfig) bool {
+		return f.Source != "xrpc" && f.URL == feed.StdinURL
+	}) {
+		err := errors.New("standard input cannot be read in watch mode")
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	var state *stateFile
+	if cfg.StateFile != "" {
+		var err error
+		if state, err = loadState(cfg.StateFile); err != nil {
+			fatal(exitConfig, "Failed to load the state.", "error", err)
+		}
+	} else if *watch {
+		// The state is kept in memory so that the runs after the first
+		// run send conditional requests and do not rewrite output that
+		// has not changed.
+		state = &stateFile{Feeds: make(map[string]feedState)}
+	}
+
+	r, err := newRunner(cfg, state)
+	if err != nil {
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	// When the program receives an interrupt or termination signal, the
+	// feeds that are in progress are finished and the state is saved, but
+	// no more feeds are started. Restoring the default behavior lets a
+	// second signal stop the program immediately.
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+	)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err = r.signIn(ctx); err != nil {
+		fatal(exitFetch, "Failed to sign in to Bluesky.", "error", err)
+	}
+
+	code := 0
+	if *watch {
+		if *metricsAddr != "" {
+			r.metrics = newMetrics()
+			stopMetrics := serveMetrics(*metricsAddr, r.metrics)
+			defer stopMetrics()
+		}
+
+		r.watch(ctx, *interval)
+	} else {
+		code = r.run(ctx)
+		if err = r.saveState(); err != nil {
+			fatal(exitWrite, "Failed to save the state.", "error", err)
+		}
 
-type channel struct {
-	Description string `xml:"description"`
-	Link        string `xml:"link"`
-	Title       string `xml:"title"`
-	Items       []item `xml:"item"`
+		if err = r.report.write(cfg.ReportFile); err != nil {
+			fatal(exitWrite, "Failed to write the report.", "error", err)
+		}
+
+		if code == 0 {
+			if err = r.commit(ctx); err != nil {
+				fatal(exitWrite, "Failed to commit the output.", "error", err)
+			}
+
+			// The notification is sent for the output that was written,
+			// even if the program is stopping.
+			err = r.webhook.send(context.WithoutCancel(ctx))
+			if err != nil {
+				fatal(
+					exitFailure,
+					"Failed to send the notification.",
+					"error", err,
+				)
+			}
+		}
+	}
+
+	if err = r.outputs.write(os.Getenv("GITHUB_OUTPUT")); err != nil {
+		fatal(exitWrite, "Failed to write the step outputs.", "error", err)
+	}
+
+	if code == exitInterrupted {
+		fatal(code, "Stopped before all of the feeds were transformed.")
+	}
+
+	if code != 0 {
+		fatal(code, "Failed to transform one or more feeds.")
+	}
+
+	if cfg.ExitUnchanged && !*watch && !r.outputs.changed {
+		slog.Info("None of the output has changed.")
+		os.Exit(exitUnchanged)
+	}
 }
 
-type item struct {
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
-	Guid        guid   `xml:"guid"`
+// setup parses the command-line arguments using flags, loads the
+// configuration, and installs the logger that is configured by the log
+// level and log format inputs as the default logger.
+func setup(flags *flag.FlagSet, args []string) config {
+	if err := parseFlags(flags, args); err != nil {
+		fatal(exitConfig, "Invalid arguments.", "error", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	logger, err := newLogger(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	if inGitHubActions() {
+		logger = slog.New(newAnnotationHandler(logger.Handler(), os.Stdout))
+	}
+
+	slog.SetDefault(logger)
+	return cfg
 }
 
-type guid struct {
-	IsPermaLink string `xml:"isPermaLink,attr"`
-	Value       string `xml:",chardata"`
+// runner holds the state that is shared by the feeds that are processed
+// during a run.
+type runner struct {
+	cfg      config
+	fetcher  *feed.Fetcher
+	resolver *feed.Resolver
+	state    *stateFile
+
+	// uploads sends the uploads to object stores. The uploads do not use
+	// the fetcher, so that they are not sent with the headers of the feed
+	// requests and are not retried using the retry policy of the feeds.
+	uploads *http.Client
+
+	allowedTags map[string]bool
+	warnLabels  map[string]bool
+	template    *template.Template
+	archetype   *template.Template
+	title       *template.Template
+	slug        *template.Template
+	taxonomies  output.Taxonomies
+	signer      *output.Signer
+	hooks       transform.Pipeline
+	filter      transform.Filter
+	images      *transform.ImageMirror
+	threads     *transform.ThreadExpander
+	links       *transform.LinkPreviewer
+	webhook     *webhook
+	git         *gitCommitter
+	report      *runReport
+	metrics     *metrics
+
+	// stdout serializes the output of dry runs so that the output of
+	// feeds that are processed concurrently is not interleaved.
+	stdout sync.Mutex
+
+	outputs stepOutputs
+
+	// rateLimited is the rate limit that failed a feed during the last run
+	// and that resets last, or nil if no feed was rate limited.
+	rateLimited *feed.RateLimitError
 }
 
-func main() {
-	url, ok := os.LookupEnv("INPUT_URL")
-	if !ok {
-		log.Fatal("The url input is required.")
+func newRunner(cfg config, state *stateFile) (*runner, error) {
+	client, err := feed.NewClient(cfg.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	client.CheckRedirect = feed.RedirectPolicy(cfg.MaxRedirects)
+
+	fetcher := &feed.Fetcher{
+		Client:        client,
+		Timeout:       cfg.Timeout,
+		UserAgent:     cfg.UserAgent,
+		Header:        make(http.Header, len(cfg.Headers)),
+		Retries:       cfg.Retries,
+		RetryDelay:    cfg.RetryDelay,
+		RetryMaxDelay: cfg.RetryMaxDelay,
+		RateLimitWait: cfg.RateLimitWait,
+		AppViewURL:    cfg.AppViewURL,
+		PDSURL:        cfg.PDSURL,
+
+		MaxResponseSize: cfg.MaxResponseSize,
+	}
+	for name, value := range cfg.Headers {
+		fetcher.Header.Set(name, value)
+	}
+
+	resolver := feed.NewResolver(fetcher)
+	if cfg.CacheDir != "" {
+		fetcher.Cache = &feed.Cache{Dir: cfg.CacheDir}
+		resolver.Cache = fetcher.Cache
+	}
+
+	r := &runner{
+		cfg:      cfg,
+		fetcher:  fetcher,
+		resolver: resolver,
+		state:    state,
+		uploads: &http.Client{
+			Transport: client.Transport,
+			Timeout:   uploadTimeout,
+		},
+
+		allowedTags: transform.TagSet(cfg.AllowedTags),
+		filter: transform.Filter{
+			ExcludeReplies: cfg.ExcludeReplies,
+			ExcludeReposts: cfg.ExcludeReposts,
+			IncludeTags:    transform.HashtagSet(cfg.IncludeTags),
+			ExcludeTags:    transform.HashtagSet(cfg.ExcludeTags),
+			Languages:      transform.LanguageSet(cfg.Languages),
+		},
+	}
+	switch cfg.LabelPolicy {
+	case transform.WarnLabeled:
+		r.warnLabels = transform.LabelSet(cfg.Labels)
+	case transform.DropLabeled:
+		r.filter.ExcludeLabels = transform.LabelSet(cfg.Labels)
+	}
+
+	if cfg.IncludePattern != "" {
+		r.filter.IncludePattern, err = regexp.Compile(cfg.IncludePattern)
+		if err != nil {
+			return nil, fmt.Errorf("the include pattern is invalid: %w", err)
+		}
+	}
+
+	if cfg.ExcludePattern != "" {
+		r.filter.ExcludePattern, err = regexp.Compile(cfg.ExcludePattern)
+		if err != nil {
+			return nil, fmt.Errorf("the exclude pattern is invalid: %w", err)
+		}
+	}
+
+	if cfg.TitleTemplate != "" {
+		r.title, err = output.ParseTitleTemplate(cfg.TitleTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the title template: %w", err)
+		}
+	}
+
+	if cfg.SlugTemplate != "" {
+		r.slug, err = output.ParseSlugTemplate(cfg.SlugTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the slug template: %w", err)
+		}
+	}
+
+	if r.taxonomies, err = cfg.taxonomies(); err != nil {
+		return nil, err
+	}
+
+	if cfg.FilterTemplate != "" {
+		tmpl, err := output.ParseHookTemplate("filter", cfg.FilterTemplate)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to parse the filter template: %w",
+				err,
+			)
+		}
+
+		r.hooks = append(r.hooks, output.FilterTransformer(tmpl))
+	}
+
+	for _, field := range output.RewriteFields {
+		text, ok := cfg.Rewrite[field]
+		if !ok {
+			continue
+		}
+
+		tmpl, err := output.ParseHookTemplate(field, text)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to parse the %s rewrite template: %w",
+				field,
+				err,
+			)
+		}
+
+		r.hooks = append(r.hooks, output.RewriteTransformer(field, tmpl))
+	}
+
+	if cfg.Template != "" {
+		if r.template, err = output.ParseTemplate(cfg.Template); err != nil {
+			return nil, fmt.Errorf("failed to parse the template: %w", err)
+		}
+	}
+
+	if cfg.SigningKey != "" {
+		r.signer, err = output.ParseSigner(
+			[]byte(cfg.SigningKey),
+			cfg.SigningNamespace,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the signing key: %w", err)
+		}
+	}
+
+	if cfg.Archetype != "" {
+		r.archetype, err = output.ParseTemplate(cfg.Archetype)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the archetype: %w", err)
+		}
+	}
+
+	if cfg.Threads {
+		r.threads = &transform.ThreadExpander{
+			Fetcher: fetcher,
+			Cache:   fetcher.Cache,
+		}
 	}
 
-	path, ok := os.LookupEnv("INPUT_PATH")
-	if !ok {
-		log.Fatal("The path input is required.")
+	if cfg.LinkPreviews {
+		r.links = &transform.LinkPreviewer{
+			Fetcher: fetcher,
+			Cache:   fetcher.Cache,
+			Timeout: cfg.LinkPreviewTimeout,
+			TTL:     cfg.LinkPreviewTTL,
+		}
+	}
+
+	// Dry runs do not write any output, so there are no new posts to send
+	// notifications about.
+	if cfg.WebhookURL != "" && !cfg.DryRun {
+		r.webhook = &webhook{
+			url:    cfg.WebhookURL,
+			format: cfg.WebhookFormat,
+			secret: cfg.WebhookSecret,
+			client: &http.Client{
+				Transport: client.Transport,
+				Timeout:   webhookTimeout,
+			},
+		}
+	}
+
+	if cfg.ReportFile != "" && !cfg.DryRun {
+		r.report = &runReport{}
+	}
+
+	// Dry runs do not write any output, so there is nothing to commit.
+	if cfg.GitCommit && !cfg.DryRun {
+		if r.git, err = newGitCommitter(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.ImageDir != "" {
+		r.images = &transform.ImageMirror{
+			Fetcher: fetcher,
+			Dir:     cfg.ImageDir,
+			BaseURL: cfg.ImageBaseURL,
+			DryRun:  cfg.DryRun,
+
+			Concurrency:  cfg.ImageConcurrency,
+			HostInterval: cfg.ImageHostInterval,
+		}
+	}
+
+	return r, nil
+}
+
+// signIn creates an authenticated session for the account that is given by
+// the BSKY_IDENTIFIER and BSKY_APP_PASSWORD environment variables. The
+// session is used for the requests to the AT Protocol XRPC endpoints. If
+// the environment variables are not set, the requests are not
+// authenticated.
+func (r *runner) signIn(ctx context.Context) error {
+	if r.cfg.Identifier == "" {
+		return nil
 	}
 
-	resp, err := http.Get(url)
+	session, err := r.fetcher.CreateSession(
+		ctx,
+		r.cfg.Identifier,
+		r.cfg.AppPassword,
+	)
 	if err != nil {
-		log.Fatalf("Failed to download the RSS feed: %v", err)
+		return err
 	}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	r.fetcher.Session = session
+	slog.Info("Signed in to Bluesky.", "handle", session.Handle)
+	return nil
+}
+
+// run transforms each of the configured feeds once. The feeds are processed
+// concurrently by up to the configured number of workers. run returns zero
+// if all of the feeds were transformed successfully. Otherwise, the exit
+// code of the failures is returned, or exitFailure if the feeds failed for
+// different reasons. If ctx is canceled before all of the feeds were
+// started and none of the feeds failed, exitInterrupted is returned.
+func (r *runner) run(ctx context.Context) int {
+	groups := r.cfg.feedGroups()
+	feeds := make(chan []feedConfig)
+	var mu sync.Mutex
+	code := 0
+	var rateLimited *feed.RateLimitError
+	var wg sync.WaitGroup
+
+	// The feeds that are in progress when ctx is canceled are allowed to
+	// finish so that their output and state are consistent, but no more
+	// feeds are started.
+	work := context.WithoutCancel(ctx)
+	for range min(r.cfg.Concurrency, len(groups)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range feeds {
+				if err := r.processFeed(work, group); err != nil {
+					r.metrics.feedFailed()
+					slog.Error(
+						"Failed to transform the feed.",
+						"path", group[0].Path,
+						"error", err,
+					)
+					r.report.add(feedReport{
+						Path:  group[0].Path,
+						Error: err.Error(),
+					})
+
+					mu.Lock()
+					if code == 0 {
+						code = exitCode(err)
+					} else if code != exitCode(err) {
+						code = exitFailure
+					}
+
+					var limit *feed.RateLimitError
+					if errors.As(err, &limit) && (rateLimited == nil ||
+						limit.Reset.After(rateLimited.Reset)) {
+						rateLimited = limit
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	skipped := 0
+dispatch:
+	for i, group := range groups {
+		select {
+		case feeds <- group:
+		case <-ctx.Done():
+			skipped = len(groups) - i
+			break dispatch
+		}
+	}
+
+	close(feeds)
+	if skipped > 0 {
+		slog.Warn(
+			"Stopping after the feeds that are in progress.",
+			"skipped", skipped,
+		)
+	}
+
+	wg.Wait()
+	r.rateLimited = rateLimited
+	if skipped > 0 && code == 0 {
+		code = exitInterrupted
+	}
+
+	return code
+}
+
+// watch transforms the feeds every interval until ctx is canceled. Output
+// is only rewritten when it has changed since the previous run, so a Hugo
+// server that is watching the output only rebuilds the site when there are
+// new posts. A failed run is logged and the feeds are transformed again
+// after the next interval.
+//
+// If a source was rate limiting the requests, the next run waits until the
+// rate limit resets. Sources that do not say when the rate limit resets
+// are given twice as long as before after each rate limited run, up to
+// maxWatchBackoff. The interval is used again after a run that was not
+// rate limited.
+func (r *runner) watch(ctx context.Context, interval time.Duration) {
+	backoff := interval
+	limited := false
+	for {
+		start := time.Now()
+		code := r.run(ctx)
+		if err := r.saveState(); err != nil {
+			slog.Error("Failed to save the state.", "error", err)
+		}
+
+		if err := r.report.write(r.cfg.ReportFile); err != nil {
+			slog.Error("Failed to write the report.", "error", err)
+		}
+
+		switch code {
+		case 0:
+			if err := r.commit(ctx); err != nil {
+				slog.Error("Failed to commit the output.", "error", err)
+			}
+
+			err := r.webhook.send(context.WithoutCancel(ctx))
+			if err != nil {
+				slog.Error(
+					"Failed to send the notification.",
+					"error", err,
+				)
+			}
+		case exitInterrupted:
+			// The skipped feeds were logged by run.
+		default:
+			slog.Error("Failed to transform one or more feeds.")
+		}
+
+		next := start.Add(interval)
+		if limit := r.rateLimited; limit != nil {
+			limited = true
+			if limit.Reset.IsZero() {
+				backoff = max(min(2*backoff, maxWatchBackoff), interval)
+				next = start.Add(backoff)
+			} else if limit.Reset.After(next) {
+				next = limit.Reset
+			}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Fatalf(
-			"Failed to download RSS feed. Status code: %d",
-			resp.StatusCode,
+			slog.Warn(
+				"The feeds are being rate limited. Delaying the next run.",
+				"host", limit.Host,
+				"next", next.UTC().Format(time.RFC3339),
+			)
+		} else if limited {
+			limited = false
+			backoff = interval
+			slog.Info(
+				"The feeds are no longer rate limited. Resuming the interval.",
+				"interval", interval,
+			)
+		}
+
+		delay := max(time.Until(next), 0)
+		slog.Debug("Waiting for the next run.", "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			slog.Info("Stopped watching the feeds.")
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// saveState saves the state of the feeds. The state is not saved by a dry
+// run because no output was written.
+func (r *runner) saveState() error {
+	if r.cfg.DryRun {
+		return nil
+	}
+
+	return r.state.save()
+}
+
+// processFeed downloads and transforms the feeds of a group of feeds that
+// are written to the same path and writes the result to the path. The
+// items of the feeds of a group are combined into a single feed that is
+// sorted from newest to oldest. If a single feed has not been modified
+// since the previous run, or the transformed output is the same as the
+// output of the previous run, the output is not rewritten.
+func (r *runner) processFeed(ctx context.Context, group []feedConfig) error {
+	fc := group[0]
+	if r.cfg.Stream {
+		return r.streamFeed(ctx, fc)
+	}
+
+	prev := r.state.get(fc.Path)
+	if len(group) > 1 {
+		// Conditional requests are not used for a group because the
+		// output is rebuilt from all of the feeds whenever any of the
+		// feeds has changed.
+		prev.URL, prev.ETag, prev.LastModified = "", "", ""
+	} else if prev.URL != fc.URL {
+		prev = feedState{URL: fc.URL}
+	}
+
+	// The output of a sink that was added since the previous run, or whose
+	// output was deleted, is written even if the feed has not changed.
+	if sinkMissing(fc.Sinks) {
+		prev.ETag, prev.LastModified = "", ""
+	}
+
+	// An incremental sync only downloads the posts that are newer than the
+	// newest post of the previous run and merges them into the existing
+	// output, so every post is downloaded again if the output is missing.
+	if _, err := os.Stat(fc.Path); err != nil || !r.cfg.Incremental {
+		prev.Newest = nil
+	}
+
+	next := prev
+	next.Newest = maps.Clone(prev.Newest)
+	var rss feed.RSS
+	pinned := make(map[string]bool)
+	for i, f := range group {
+		source := r.source(ctx, f, prev.newest(f.Actor))
+		start := time.Now()
+		fetched, validators, err := source.Fetch(ctx, feed.Validators{
+			ETag:         prev.ETag,
+			LastModified: prev.LastModified,
+		})
+		r.metrics.observeFetch(
+			f.Source,
+			prev.ETag != "" || prev.LastModified != "",
+			time.Since(start),
+			err,
 		)
+		next.ETag = validators.ETag
+		next.LastModified = validators.LastModified
+		if errors.Is(err, feed.ErrNotModified) {
+			slog.Info("The feed has not been modified.", "path", fc.Path)
+			r.report.add(feedReport{Path: fc.Path, Hash: prev.Hash})
+			return nil
+		}
+
+		if err != nil {
+			return fetchError(
+				fmt.Errorf("failed to download the RSS feed: %w", err),
+			)
+		}
+
+		if r.cfg.Incremental {
+			next.setNewest(f.Actor, fetched.Channel.Items)
+		}
+
+		if fetched.Channel.Pinned != "" {
+			pinned[fetched.Channel.Pinned] = true
+		}
+
+		log := slog.With("path", fc.Path)
+		items, err := r.transformItems(ctx, log, fetched.Channel.Items)
+		if err != nil {
+			return err
+		}
+
+		r.mirrorImages(ctx, log, r.images, items)
+
+		if i == 0 {
+			rss = fetched
+			rss.Channel.Items = items
+			continue
+		}
+
+		rss.Channel.Title += ", " + fetched.Channel.Title
+		rss.Channel.Items = append(rss.Channel.Items, items...)
 	}
 
-	var rss rss
-	decoder := xml.NewDecoder(resp.Body)
-	if err = decoder.Decode(&rss); err != nil {
-		log.Fatalf("Failed to parse the RSS feed: %v", err)
+	items := rss.Channel.Items
+	if len(group) > 1 {
+		items = transform.Merge(items, nil)
+		items = transform.Limit(items, r.cfg.MaxItems)
 	}
 
-	for i := range rss.Channel.Items {
-		pubDate, err := time.Parse(
-			"02 Jan 2006 15:04 -0700",
-			rss.Channel.Items[i].PubDate,
+	if r.cfg.Merge || r.cfg.Incremental {
+		existing, err := r.readExistingItems(fc.Format, fc.Path)
+		if err != nil {
+			return withExitCode(
+				exitParse,
+				fmt.Errorf("failed to read the existing output: %w", err),
+			)
+		}
+
+		log := slog.With("path", fc.Path)
+		deleted := r.deletedPosts(ctx, log, items, existing)
+		existing = r.cfg.DeletedPolicy.Apply(existing, deleted)
+		items = transform.Limit(transform.Merge(items, existing), r.cfg.MaxItems)
+	}
+
+	now := time.Now()
+	items = r.schedule(now).Apply(items, now)
+	items = r.cfg.Pinned.Apply(r.cfg.Sort.Sort(items), pinned)
+	r.engagement(ctx, slog.With("path", fc.Path), items)
+	rss.Channel.Items = items
+
+	// The output of the feed is rendered from the bundled items, and the
+	// sinks are rendered from rss.
+	bundled := rss
+	bundled.Channel.Items = r.bundleImages(ctx, slog.With("path", fc.Path),
+		fc, items)
+	opts := r.renderOptions(fc)
+	sink := r.newSink(fc.Format, fc.Path, opts)
+	files, err := sink.Render(bundled)
+	if err != nil {
+		return withExitCode(
+			exitWrite,
+			fmt.Errorf("failed to write the RSS feed: %w", err),
 		)
+	}
+
+	r.metrics.itemsEmitted(len(items))
+
+	sinks, err := r.renderSinks(fc, rss)
+	if err != nil {
+		return withExitCode(exitWrite, err)
+	}
+
+	next.Hash = sinksHash(files, sinks)
+
+	// The output that is written to standard output is always written,
+	// while the output that is uploaded is assumed to exist.
+	exists := isRemoteURL(fc.Path) || files.Exists(fc.Path)
+	if r.state != nil && next.Hash == prev.Hash && exists && sinksExist(sinks) {
+		slog.Info("The output has not changed.", "path", fc.Path)
+		r.outputs.record(items, false)
+		r.report.add(newFeedReport(fc.Path, items, outputChanges{}, next.Hash))
+		r.state.set(fc.Path, next)
+		return nil
+	}
+
+	if r.cfg.DryRun {
+		var changed bool
+		if isFilePath(fc.Path) {
+			changed, err = r.preview(fc.Path, files)
+		}
+
+		for _, s := range sinks {
+			if err == nil && isFilePath(s.Path) {
+				var c bool
+				c, err = r.preview(s.Path, s.files)
+				changed = changed || c
+			}
+		}
+
+		r.outputs.record(items, changed)
+		return err
+	}
+
+	var changes outputChanges
+	if r.webhook != nil || r.report != nil || r.git != nil {
+		changes = compareOutput(fc.Format, fc.Path, opts, items, files)
+	}
+
+	written, err := sink.Write(files)
+	if err != nil {
+		return withExitCode(exitWrite, err)
+	}
+
+	changed := written > 0
+	for _, s := range sinks {
+		n, err := s.sink.Write(s.files)
 		if err != nil {
-			log.Fatalf("Failed to parse the pubDate field: %v", err)
+			return withExitCode(exitWrite, err)
 		}
 
-		rss.Channel.Items[i].PubDate = pubDate.Format(
-			"2006-01-02T15:04:05-07:00",
+		if n > 0 && s.Path != "-" {
+			slog.Info("Wrote the output.", "path", s.Path, "files", n)
+			changed = true
+		}
+	}
+
+	r.webhook.add(changes.added)
+	r.git.add(changes.added)
+	r.report.add(newFeedReport(fc.Path, items, changes, next.Hash))
+	r.outputs.record(items, changed)
+
+	if written == 0 {
+		slog.Info("The output has not changed.", "path", fc.Path)
+	} else {
+		slog.Info("Wrote the output.", "path", fc.Path, "files", written)
+	}
+
+	r.state.set(fc.Path, next)
+	return nil
+}
+
+// source returns the source that the posts of the feed f are read from.
+// When the source is xrpc and since is not zero, only the posts that are
+// newer than since are fetched.
+func (r *runner) source(
+	ctx context.Context,
+	f feedConfig,
+	since time.Time,
+) feed.Source {
+	switch f.Source {
+	case "xrpc":
+		return feed.XRPCSource{
+			Fetcher:  r.fetcher,
+			Actor:    r.actorDID(ctx, f.Actor),
+			MaxPages: r.cfg.MaxPages,
+			Since:    since,
+		}
+	case "mastodon":
+		return feed.MastodonSource{Fetcher: r.fetcher, URL: f.URL}
+	case "microblog", "generic":
+		return feed.GenericSource{Fetcher: r.fetcher, URL: f.URL}
+	default:
+		return feed.BlueskyRSSSource{Fetcher: r.fetcher, URL: f.URL}
+	}
+}
+
+// actorDID returns the DID of actor, which is a handle or a DID, so that the
+// API calls identify the account by its DID. If the handle cannot be
+// resolved, the handle is used instead.
+func (r *runner) actorDID(ctx context.Context, actor string) string {
+	did, err := r.resolver.Resolve(ctx, actor)
+	if err != nil {
+		slog.Warn(
+			"Failed to resolve the handle. The handle is used instead.",
+			"handle", actor,
+			"error", err,
 		)
+		return actor
 	}
 
-	file, err := os.Create(path)
+	return did
+}
+
+// transformItems replaces the GUIDs of the items using the GUID policy and
+// passes the items through the transformation pipeline, which rewrites the
+// dates of the items, removes the items that are excluded by the filters,
+// adds content warnings to the labeled items, and sanitizes the remaining
+// items. Threads are combined and link cards are built before the pipeline
+// runs when the configuration enables it. Problems with individual items
+// are logged using log.
+func (r *runner) transformItems(
+	ctx context.Context,
+	log *slog.Logger,
+	items []feed.Item,
+) ([]feed.Item, error) {
+	r.setGUIDs(ctx, log, items)
+	if r.threads != nil {
+		var err error
+		if items, err = r.threads.Expand(ctx, items); err != nil {
+			log.Warn(
+				"Failed to download a thread. The posts of the thread are "+
+					"kept as separate posts.",
+				"error", err,
+			)
+		}
+	}
+
+	if r.links != nil {
+		for _, itemErr := range r.links.Preview(ctx, items) {
+			log.Warn(
+				"Failed to build the link card of a post. The post is "+
+					"written without a link card.",
+				"post", itemErr.Item.Link,
+				"error", itemErr.Err,
+			)
+		}
+	}
+
+	items, itemErrors, err := r.pipeline(log, time.Now()).Run(
+		items,
+		r.cfg.OnError,
+	)
 	if err != nil {
-		log.Fatalf("Failed to create the file: %v", err)
+		return nil, withExitCode(exitParse, err)
 	}
 
-	defer func() {
-		_ = file.Close()
-	}()
+	r.logItemErrors(log, itemErrors)
+	return items, nil
+}
+
+// mirrorImages downloads the images of the items into the image directory
+// when the image_dir input is set.
+func (r *runner) mirrorImages(
+	ctx context.Context,
+	log *slog.Logger,
+	images *transform.ImageMirror,
+	items []feed.Item,
+) {
+	if images == nil {
+		return
+	}
+
+	for _, itemErr := range images.Mirror(ctx, items) {
+		log.Warn(
+			"Failed to download an image. The post references the "+
+				"original image instead.",
+			"post", itemErr.Item.Link,
+			"error", itemErr.Err,
+		)
+	}
+}
+
+// bundleImages returns the items of the feed with their images downloaded
+// into the page bundles of the posts in the output directory of the feed
+// when the page_bundles input is set. The images are referenced by their
+// file names, which only resolve relative to the content pages, so a copy
+// of the items is returned and the sinks of the feed are rendered from the
+// items that still reference the original images.
+func (r *runner) bundleImages(
+	ctx context.Context,
+	log *slog.Logger,
+	fc feedConfig,
+	items []feed.Item,
+) []feed.Item {
+	if !r.cfg.PageBundles {
+		return items
+	}
+
+	bundled := slices.Clone(items)
+	for i := range bundled {
+		bundled[i].Media = slices.Clone(bundled[i].Media)
+	}
+
+	r.mirrorImages(ctx, log, &transform.ImageMirror{
+		Fetcher: r.fetcher,
+		Dir:     fc.Path,
+		Bundle:  r.bundle,
+		DryRun:  r.cfg.DryRun,
+
+		Concurrency:  r.cfg.ImageConcurrency,
+		HostInterval: r.cfg.ImageHostInterval,
+	}, bundled)
+	return bundled
+}
+
+// engagement removes the engagement counts from the items unless the
+// engagement input is set. When the refresh_engagement input is set, the
+// current counts of the items are downloaded instead. The items keep the
+// counts that they already have if the counts cannot be downloaded.
+func (r *runner) engagement(
+	ctx context.Context,
+	log *slog.Logger,
+	items []feed.Item,
+) {
+	if !r.cfg.Engagement {
+		for i := range items {
+			items[i].Engagement = nil
+		}
+
+		return
+	}
+
+	if !r.cfg.RefreshEngagement {
+		return
+	}
+
+	if err := r.fetcher.RefreshEngagement(ctx, items); err != nil {
+		log.Warn(
+			"Failed to download the engagement counts. The posts keep the "+
+				"counts that were already known.",
+			"error", err,
+		)
+	}
+}
+
+// deletedPosts returns the AT URIs of the posts of the existing items that
+// no longer exist on Bluesky, unless the deleted_policy input is keep. The
+// posts of the new items are not checked because they were just
+// downloaded. No posts are returned if the posts cannot be checked.
+func (r *runner) deletedPosts(
+	ctx context.Context,
+	log *slog.Logger,
+	items []feed.Item,
+	existing []feed.Item,
+) map[string]bool {
+	if r.cfg.DeletedPolicy == transform.KeepDeleted {
+		return nil
+	}
+
+	fetched := make(map[string]bool, len(items))
+	for _, item := range items {
+		fetched[item.URI()] = true
+	}
+
+	var uris []string
+	for _, item := range existing {
+		if uri := item.URI(); uri != "" && !fetched[uri] {
+			uris = append(uris, uri)
+		}
+	}
+
+	posts, err := r.fetcher.FetchPosts(ctx, uris)
+	if err != nil {
+		log.Warn(
+			"Failed to check whether the archived posts still exist. The "+
+				"posts are kept.",
+			"error", err,
+		)
+		return nil
+	}
+
+	deleted := make(map[string]bool, len(uris))
+	for _, uri := range uris {
+		deleted[uri] = true
+	}
+
+	for _, post := range posts {
+		delete(deleted, post.URI)
+	}
+
+	for uri := range deleted {
+		log.Info("An archived post was deleted.", "uri", uri)
+	}
+
+	return deleted
+}
+
+// setGUIDs replaces the GUIDs of the items using the GUID policy. The AT
+// URI of a post that does not have one is resolved from the handle in the
+// link of the post, so the uri and hash GUIDs identify the author by their
+// DID and do not change when the author changes their handle.
+func (r *runner) setGUIDs(
+	ctx context.Context,
+	log *slog.Logger,
+	items []feed.Item,
+) {
+	for i := range items {
+		item := &items[i]
+		uri := item.URI()
+		if uri == "" && r.cfg.GUIDPolicy != transform.LinkGUID {
+			resolved, err := r.resolver.PostURI(ctx, item.Link)
+			if err == nil {
+				uri = resolved
+			} else if item.GUID.Value == "" {
+				log.Warn(
+					"Failed to resolve the AT URI of the post. The post "+
+						"does not have a GUID.",
+					"post", item.Link,
+					"error", err,
+				)
+			}
+		}
+
+		if item.Author != nil && item.Author.DID == "" {
+			item.Author.DID = feed.AuthorityDID(uri)
+		}
+
+		transform.SetGUID(item, uri, r.cfg.GUIDPolicy)
+	}
+}
+
+// bundle returns the directory of the page bundle of the item, which is
+// named after the slug of the post. The record key of the post is used if
+// the slug cannot be derived, in which case rendering the page fails.
+func (r *runner) bundle(item feed.Item) string {
+	slug, err := output.ItemSlug(item, r.renderOptions(feedConfig{}))
+	if err != nil {
+		return output.PostSlug(item)
+	}
+
+	return slug
+}
+
+// dateFilter returns the filter of the runner with the dates of the since
+// and until inputs resolved relative to now. The dates are resolved for
+// every run so that durations like 30d move forward in watch mode and
+// for the serve command. The inputs were validated by loadConfig.
+func (r *runner) dateFilter(now time.Time) transform.Filter {
+	filter := r.filter
+	if r.cfg.Since != "" {
+		filter.Since, _ = transform.ParseDateBound(r.cfg.Since, now)
+	}
+
+	if r.cfg.Until != "" {
+		filter.Until, _ = transform.ParseDateBound(r.cfg.Until, now)
+	}
+
+	return filter
+}
+
+// schedule returns the schedule that marks the drafts using the future and
+// draft_after inputs, with the date of the draft_after input resolved
+// relative to now like the dates of dateFilter.
+func (r *runner) schedule(now time.Time) transform.Schedule {
+	s := transform.Schedule{Future: r.cfg.Future, DateFormat: r.cfg.DateFormat}
+	if r.cfg.DraftAfter != "" {
+		s.DraftAfter, _ = transform.ParseDateBound(r.cfg.DraftAfter, now)
+	}
+
+	return s
+}
+
+// renderOptions returns the options that are used to render the output of
+// the feed.
+func (r *runner) renderOptions(fc feedConfig) output.Options {
+	return output.Options{
+		LinkCardFrontMatter: r.cfg.LinkCards == "front_matter",
+		Shortcode:           r.cfg.Shortcode,
+		Template:            r.template,
+		Language:            r.cfg.Language,
+		SelfURL:             fc.SelfURL,
+		TitleStyle:          r.cfg.TitleStyle,
+		TitleWords:          r.cfg.TitleWords,
+		TitleTemplate:       r.title,
+		SlugStyle:           r.cfg.SlugStyle,
+		SlugTemplate:        r.slug,
+		Taxonomies:          r.taxonomies,
+		PageBundles:         r.cfg.PageBundles,
+		FrontMatter:         r.cfg.FrontMatter,
+		Archetype:           r.archetype,
+	}
+}
+
+// preview prints the output of a dry run for the feed that is written to
+// path. When the output file does not exist yet, the output is printed as
+// is. Otherwise, a unified diff of the existing output and the new output
+// is printed. preview reports whether the output has changed.
+func (r *runner) preview(path string, files output.Files) (bool, error) {
+	data, single := files[""]
+	if !single || files.Exists(path) {
+		var err error
+		if data, err = files.Diff(path); err != nil {
+			return false, fmt.Errorf("failed to compare the output: %w", err)
+		}
+	}
+
+	if len(data) == 0 {
+		slog.Info("The output has not changed.", "path", path)
+		return false, nil
+	}
+
+	r.stdout.Lock()
+	defer r.stdout.Unlock()
+	_, err := os.Stdout.Write(data)
+	return true, err
+}
+
+// readExistingItems reads the items from the output that was written to path
+// by a previous run. No items are returned if the output does not exist.
+func (r *runner) readExistingItems(
+	format string,
+	path string,
+) ([]feed.Item, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := output.ReadItems(format, data)
+	if err != nil {
+		return nil, err
+	}
+
+	items, itemErrors, err := transform.Reformat(
+		items,
+		r.cfg.DateFormat,
+		r.cfg.DateLayouts,
+		r.cfg.Location,
+		r.cfg.OnError,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logItemErrors(slog.With("path", path), itemErrors)
+	return items, nil
+}
+
+// logItemErrors uses log to log a warning for each item that was skipped or
+// passed through because of the error policy.
+func (r *runner) logItemErrors(
+	log *slog.Logger,
+	itemErrors []transform.ItemError,
+) {
+	message := "Skipped a post that could not be transformed."
+	if r.cfg.OnError == transform.Passthrough {
+		message = "Passed through a post that could not be transformed."
+	}
 
-	encoder := xml.NewEncoder(file)
-	encoder.Indent("", "  ")
-	if err = encoder.Encode(rss); err != nil {
-		log.Fatalf("Failed to write the RSS feed: %v", err)
+	for _, e := range itemErrors {
+		log.Warn(message, "post", e.Item.Link, "error", e.Err)
 	}
 }
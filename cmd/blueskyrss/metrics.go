@@ -0,0 +1,347 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// fetchDurationBuckets are the upper bounds in seconds of the buckets of
+// the fetch duration histogram. Downloading an author feed can take many
+// requests, so the buckets go up to a minute.
+var fetchDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// metrics collects the metrics that the /metrics endpoint exposes in the
+// Prometheus text format. The methods of a nil *metrics do not record
+// anything, so runs that do not expose metrics do not have to check.
+type metrics struct {
+	mu        sync.Mutex
+	fetches   counterVec
+	durations histogramVec
+	errors    counterVec
+	items     counterVec
+	cache     counterVec
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		fetches: counterVec{
+			name:   "blueskyrss_fetches_total",
+			help:   "The number of times that a feed was downloaded.",
+			labels: []string{"source", "result"},
+		},
+		durations: histogramVec{
+			name:    "blueskyrss_fetch_duration_seconds",
+			help:    "How long it took to download a feed.",
+			labels:  []string{"source"},
+			buckets: fetchDurationBuckets,
+		},
+		errors: counterVec{
+			name: "blueskyrss_feed_errors_total",
+			help: "The number of times that a feed failed to be " +
+				"transformed.",
+		},
+		items: counterVec{
+			name: "blueskyrss_items_emitted_total",
+			help: "The number of posts that were rendered into the output.",
+		},
+		cache: counterVec{
+			name: "blueskyrss_cache_requests_total",
+			help: "The number of times that a cache was used, by whether " +
+				"the cache had the feed.",
+			labels: []string{"cache", "result"},
+		},
+	}
+}
+
+// serveMetrics serves m at /metrics on addr, which lets the metrics of
+// watch mode be scraped, and returns a function that shuts down the server.
+// The program exits if the server fails.
+func serveMetrics(addr string, m *metrics) func() {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := srv.ListenAndServe()
+		if !errors.Is(err, http.ErrServerClosed) {
+			fatal(exitFailure, "The metrics server failed.", "error", err)
+		}
+	}()
+
+	slog.Info("Serving the metrics.", "addr", addr)
+	return func() {
+		ctx, cancel := context.WithTimeout(
+			context.Background(),
+			shutdownTimeout,
+		)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}
+}
+
+// observeFetch records a download of a feed from source that took d and
+// failed with err. If the download was a conditional request, a feed that
+// has not been modified is a hit of the conditional request cache and a
+// feed that was downloaded is a miss.
+func (m *metrics) observeFetch(
+	source string,
+	conditional bool,
+	d time.Duration,
+	err error,
+) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result, cache := "ok", "miss"
+	switch {
+	case errors.Is(err, feed.ErrNotModified):
+		result, cache = "not_modified", "hit"
+	case err != nil:
+		result, conditional = "error", false
+	}
+
+	if conditional {
+		m.cache.add(1, "conditional", cache)
+	}
+
+	m.fetches.add(1, source, result)
+	m.durations.observe(d.Seconds(), source)
+}
+
+// feedFailed records a feed that failed to be transformed.
+func (m *metrics) feedFailed() {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors.add(1)
+}
+
+// itemsEmitted records n posts that were rendered into the output.
+func (m *metrics) itemsEmitted(n int) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items.add(float64(n))
+}
+
+// cacheLookup records a lookup in the cache named cache.
+func (m *metrics) cacheLookup(cache string, hit bool) {
+	if m == nil {
+		return
+	}
+
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cache.add(1, cache, result)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches.write(w)
+	m.durations.write(w)
+	m.errors.write(w)
+	m.items.write(w)
+	m.cache.write(w)
+}
+
+// counterVec is a counter that has a value for each combination of the
+// values of its labels.
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+	values map[string]float64
+}
+
+// add adds v to the counter with the label values values.
+func (c *counterVec) add(v float64, values ...string) {
+	if c.values == nil {
+		c.values = make(map[string]float64)
+	}
+
+	c.values[labelKey(values)] += v
+}
+
+func (c *counterVec) write(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	if len(c.labels) == 0 && len(c.values) == 0 {
+		fmt.Fprintf(w, "%s 0\n", c.name)
+		return
+	}
+
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(
+			w,
+			"%s%s %s\n",
+			c.name,
+			formatLabels(c.labels, strings.Split(key, labelSeparator)),
+			formatValue(c.values[key]),
+		)
+	}
+}
+
+// histogramVec is a histogram that has a series for each combination of
+// the values of its labels.
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*histogram
+}
+
+// histogram counts the observations that are less than or equal to each
+// of the buckets of its histogramVec.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// observe adds v to the series with the label values values.
+func (h *histogramVec) observe(v float64, values ...string) {
+	if h.series == nil {
+		h.series = make(map[string]*histogram)
+	}
+
+	key := labelKey(values)
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+
+	s.sum += v
+	s.count++
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	writeHeader(w, h.name, h.help, "histogram")
+	names := append(slices.Clone(h.labels), "le")
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		values := strings.Split(key, labelSeparator)
+		for i, bound := range h.buckets {
+			fmt.Fprintf(
+				w,
+				"%s_bucket%s %d\n",
+				h.name,
+				formatLabels(names, append(values, formatValue(bound))),
+				s.counts[i],
+			)
+		}
+
+		fmt.Fprintf(
+			w,
+			"%s_bucket%s %d\n",
+			h.name,
+			formatLabels(names, append(values, "+Inf")),
+			s.count,
+		)
+		labels := formatLabels(h.labels, values)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labels, formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, labels, s.count)
+	}
+}
+
+// labelSeparator separates the label values in the keys of the series of a
+// metric. It cannot appear in a valid UTF-8 label value.
+const labelSeparator = "\xff"
+
+func labelKey(values []string) string {
+	return strings.Join(values, labelSeparator)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+func writeHeader(w io.Writer, name string, help string, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// labelEscaper escapes the characters that are not allowed in the label
+// values of the Prometheus text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats the labels names with the values values, such as
+// {source="rss"}. An empty string is returned for a metric without labels.
+func formatLabels(names []string, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + labelEscaper.Replace(values[i]) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
+)
+
+// pipelineSteps are the names of the steps that the pipeline input can
+// contain, in the order that the steps run by default:
+//
+//   - dates parses the dates of the posts and rewrites them using the date
+//     format.
+//   - filter removes the posts that are excluded by the filters.
+//   - templates removes the posts that are excluded by the filter template
+//     and rewrites the fields of the posts using the rewrite templates.
+//   - limit keeps the first max_items posts.
+//   - normalize normalizes the Unicode text and the emoji of the posts.
+//   - summary adds the summaries of the posts.
+//   - labels adds the content warnings of the labeled posts.
+//   - sanitize sanitizes the descriptions of the posts.
+//
+// The steps do nothing unless the inputs that configure them are set, and
+// a step that is not listed in the pipeline input does not run.
+var pipelineSteps = []string{
+	"dates",
+	"filter",
+	"templates",
+	"limit",
+	"normalize",
+	"summary",
+	"labels",
+	"sanitize",
+}
+
+// pipeline returns the transformation pipeline that the posts of a feed are
+// passed through using the steps of the pipeline input in order. The date
+// filters are relative to now, and the posts that are removed by the
+// filters are logged using log.
+func (r *runner) pipeline(log *slog.Logger, now time.Time) transform.Pipeline {
+	var p transform.Pipeline
+	for _, step := range r.cfg.Pipeline {
+		switch step {
+		case "dates":
+			p = append(p, transform.DateTransformer(
+				r.cfg.DateLayouts,
+				r.cfg.DateFormat,
+				r.cfg.Location,
+			))
+		case "filter":
+			p = append(p, logDropped(log, r.dateFilter(now)))
+		case "templates":
+			for _, hook := range r.hooks {
+				p = append(p, logDropped(log, hook))
+			}
+		case "limit":
+			p = append(p, transform.LimitTransformer(r.cfg.MaxItems))
+		case "normalize":
+			if r.cfg.NormalizeUnicode || r.cfg.Emoji != transform.KeepEmoji {
+				p = append(p, itemStep(func(item *feed.Item) {
+					transform.NormalizeItem(
+						item,
+						r.cfg.NormalizeUnicode,
+						r.cfg.Emoji,
+					)
+				}))
+			}
+		case "summary":
+			if r.cfg.SummaryLength > 0 {
+				p = append(p, itemStep(func(item *feed.Item) {
+					item.Summary = transform.Summarize(
+						item.PlainText(),
+						r.cfg.SummaryLength,
+						r.cfg.SummaryUnit,
+					)
+				}))
+			}
+		case "labels":
+			p = append(p, itemStep(func(item *feed.Item) {
+				labels := transform.MatchingLabels(*item, r.warnLabels)
+				if len(labels) > 0 {
+					transform.WarnItem(item, labels)
+					if item.Summary != "" {
+						item.Summary = transform.LabelWarning(labels)
+					}
+				}
+			}))
+		case "sanitize":
+			if r.cfg.Sanitize {
+				p = append(p, itemStep(func(item *feed.Item) {
+					transform.SanitizeItem(item, r.allowedTags)
+				}))
+			}
+		}
+	}
+
+	return p
+}
+
+// logDropped returns a step of a pipeline that transforms the items using t
+// and logs the items that t drops using log.
+func logDropped(
+	log *slog.Logger,
+	t transform.Transformer,
+) transform.Transformer {
+	return transform.TransformerFunc(func(item feed.Item) (feed.Item, error) {
+		next, err := t.Transform(item)
+		if errors.Is(err, transform.ErrDrop) {
+			log.Debug("Removed the post.", "post", item.Link)
+		}
+
+		return next, err
+	})
+}
+
+// itemStep returns a step of a pipeline that modifies the item using fn and
+// cannot fail.
+func itemStep(fn func(item *feed.Item)) transform.Transformer {
+	return transform.TransformerFunc(func(item feed.Item) (feed.Item, error) {
+		fn(&item)
+		return item, nil
+	})
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+)
+
+// runReport collects the reports for the outputs of the feeds that are
+// processed during a run.
+type runReport struct {
+	mu    sync.Mutex
+	feeds []feedReport
+}
+
+// reportFile is the machine-readable report of a run that is written to
+// the file named by the report_file input. The report contains the number
+// of posts that were added to, removed from, or changed in the output of
+// each feed, and the totals for the run, so that a workflow can create a
+// commit message from the report.
+type reportFile struct {
+	Added   int          `json:"added"`
+	Removed int          `json:"removed"`
+	Changed int          `json:"changed"`
+	Latest  string       `json:"latest,omitempty"`
+	Feeds   []feedReport `json:"feeds"`
+}
+
+// feedReport is the part of the report for the output of a feed. Hash is
+// the hash of the output that is also stored in the state file, and Error
+// is the error that the feed failed with.
+type feedReport struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Changed int    `json:"changed"`
+	Latest  string `json:"latest,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	latest time.Time
+}
+
+// newFeedReport returns the report for the output of a feed that was
+// written to path using items.
+func newFeedReport(
+	path string,
+	items []feed.Item,
+	changes outputChanges,
+	hash string,
+) feedReport {
+	report := feedReport{
+		Path:    path,
+		Added:   len(changes.added),
+		Removed: changes.removed,
+		Changed: changes.changed,
+		Hash:    hash,
+	}
+	for _, item := range items {
+		if item.Published.After(report.latest) {
+			report.latest = item.Published
+		}
+	}
+
+	if !report.latest.IsZero() {
+		report.Latest = report.latest.Format(time.RFC3339)
+	}
+
+	return report
+}
+
+// add adds the report for the output of a feed to the report of the run.
+func (r *runReport) add(report feedReport) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.feeds = append(r.feeds, report)
+}
+
+// write writes the report to the file name and starts a new report for
+// the next run. The feeds are sorted by their paths so that the report
+// does not depend on the order that the feeds were processed in.
+func (r *runReport) write(name string) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	feeds := r.feeds
+	r.feeds = nil
+	if feeds == nil {
+		feeds = []feedReport{}
+	}
+
+	slices.SortFunc(feeds, func(a, b feedReport) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	report := reportFile{Feeds: feeds}
+	var latest time.Time
+	for _, f := range feeds {
+		report.Added += f.Added
+		report.Removed += f.Removed
+		report.Changed += f.Changed
+		if f.latest.After(latest) {
+			latest = f.latest
+			report.Latest = f.Latest
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return output.WriteFile(name, append(data, '\n'))
+}
+
+// outputChanges are the differences between the output that was written by
+// a previous run and the output of the current run.
+type outputChanges struct {
+	added   []feed.Item
+	removed int
+	changed int
+}
+
+// compareOutput compares items and files, which are the new output of a
+// feed, with the output that was written to path by a previous run. Every
+// item is added if the output does not exist. The output of the shortcode
+// and template formats cannot be read, so an item is added if its link does
+// not appear in the output, and the items that were removed or changed are
+// not counted. The content format writes a page for each post and does not
+// remove the pages of older posts, so no items are removed. The names of
+// the pages are derived using opts.
+func compareOutput(
+	format string,
+	path string,
+	opts output.Options,
+	items []feed.Item,
+	files output.Files,
+) outputChanges {
+	var changes outputChanges
+	if format == "content" {
+		for _, item := range items {
+			slug, err := output.ItemSlug(item, opts)
+			if err != nil {
+				continue
+			}
+
+			name := output.ContentFileName(slug, opts.PageBundles)
+			existing, err := os.ReadFile(filepath.Join(path, name))
+			if err != nil {
+				changes.added = append(changes.added, item)
+			} else if !bytes.Equal(existing, files[name]) {
+				changes.changed++
+			}
+		}
+
+		return changes
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		changes.added = slices.Clone(items)
+		return changes
+	}
+
+	existing, err := output.ReadItems(format, data)
+	if err != nil {
+		for _, item := range items {
+			if !bytes.Contains(data, []byte(item.Link)) {
+				changes.added = append(changes.added, item)
+			}
+		}
+
+		return changes
+	}
+
+	previous := make(map[string]feed.Item, len(existing))
+	for _, item := range existing {
+		previous[itemKey(item)] = item
+	}
+
+	for _, item := range items {
+		key := itemKey(item)
+		old, ok := previous[key]
+		switch {
+		case !ok:
+			changes.added = append(changes.added, item)
+		case old.Description != item.Description:
+			changes.changed++
+		}
+
+		delete(previous, key)
+	}
+
+	changes.removed = len(previous)
+	return changes
+}
+
+// itemKey identifies an item in the output. Items are identified by their
+// GUIDs, or by their links if they do not have one.
+func itemKey(item feed.Item) string {
+	if item.GUID.Value != "" {
+		return item.GUID.Value
+	}
+
+	return item.Link
+}
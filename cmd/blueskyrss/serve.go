@@ -0,0 +1,475 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+)
+
+const (
+	// defaultServeAddr is the address that the serve command listens on
+	// when the -addr flag is not set.
+	defaultServeAddr = ":8080"
+
+	// defaultCacheTTL is how long the serve command caches a transformed
+	// feed when the -cache-ttl flag is not set.
+	defaultCacheTTL = 5 * time.Minute
+
+	// defaultCacheEntries is the maximum number of transformed feeds that
+	// the serve command caches when the -cache-entries flag is not set.
+	defaultCacheEntries = 1000
+
+	// shutdownTimeout is how long the server waits for the requests that
+	// are in progress to finish when it is stopped.
+	shutdownTimeout = 10 * time.Second
+)
+
+// serveSources are the sources that the serve command can read the feed of
+// an account from. The account is identified by its handle, so the generic
+// source is not supported.
+var serveSources = []string{"rss", "xrpc", "mastodon", "microblog"}
+
+// usernamePattern matches the usernames of Mastodon and Micro.blog
+// accounts.
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]{1,64}$`)
+
+// contentTypes are the media types of the output formats that the serve
+// command can return. The content format is not supported because it
+// produces a file for each post.
+var contentTypes = map[string]string{
+	"rss":       "application/rss+xml; charset=utf-8",
+	"atom":      "application/atom+xml; charset=utf-8",
+	"jsonfeed":  "application/feed+json; charset=utf-8",
+	"json":      "application/json; charset=utf-8",
+	"yaml":      "application/yaml; charset=utf-8",
+	"toml":      "application/toml; charset=utf-8",
+	"shortcode": "text/markdown; charset=utf-8",
+	"template":  "text/plain; charset=utf-8",
+}
+
+// serve runs the serve command. The serve command listens for HTTP requests
+// for /feed?handle=<handle> and responds with the transformed feed for the
+// Bluesky account, which lets a Hugo site use the transformed feed as a
+// remote resource. The format parameter can be used to request a different
+// output format than the format input. The transformed feeds are cached in
+// memory for the duration given by the -cache-ttl flag, and the least
+// recently used feeds are removed from the cache when it holds the number
+// of feeds given by the -cache-entries flag. Metrics about the
+// downloaded feeds and the cache are served at /metrics in the Prometheus
+// text format, and /healthz and /readyz can be used as the liveness and
+// readiness probes of a container. The server is not ready once the feeds
+// have been failing for longer than the -stale-after flag.
+//
+// The settings that control the transformation are loaded the same way as
+// for a normal run, but the feeds, state file, merge, and image settings
+// are ignored.
+func serve(args []string) {
+	flags := newFlagSet("blueskyrss serve", "blueskyrss serve [flags]")
+	addr := flags.String(
+		"addr",
+		defaultServeAddr,
+		"the `address` that the server listens on",
+	)
+	ttl := flags.Duration(
+		"cache-ttl",
+		defaultCacheTTL,
+		"how long a transformed feed is cached",
+	)
+	cacheEntries := flags.Int(
+		"cache-entries",
+		defaultCacheEntries,
+		"the maximum `number` of transformed feeds that are cached",
+	)
+	staleAfter := flags.Duration(
+		"stale-after",
+		defaultStaleAfter,
+		"how long the feeds can fail before the server is not ready",
+	)
+	cfg := setup(flags, args)
+	if *ttl < 0 {
+		err := errors.New("the cache TTL cannot be negative")
+		fatal(exitConfig, "Invalid arguments.", "error", err)
+	}
+
+	if *cacheEntries <= 0 {
+		err := errors.New("the number of cache entries must be positive")
+		fatal(exitConfig, "Invalid arguments.", "error", err)
+	}
+
+	if *staleAfter < 0 {
+		err := errors.New("the staleness threshold cannot be negative")
+		fatal(exitConfig, "Invalid arguments.", "error", err)
+	}
+
+	if !slices.Contains(serveSources, cfg.Source) {
+		err := fmt.Errorf("the source input %q is not supported", cfg.Source)
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	if _, ok := contentTypes[cfg.Format]; !ok {
+		err := fmt.Errorf("the format input %q is not supported", cfg.Format)
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	if cfg.Format == "template" && cfg.Template == "" {
+		err := errors.New(
+			"the template input is required for the template format",
+		)
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	// The images are not mirrored because the server does not serve the
+	// image directory or page bundles, and the posts are not merged because
+	// there is no previous output to merge them into.
+	cfg.ImageDir = ""
+	cfg.PageBundles = false
+	cfg.Merge = false
+	r, err := newRunner(cfg, nil)
+	if err != nil {
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	r.metrics = newMetrics()
+
+	ctx, stop := signal.NotifyContext(
+		context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+	)
+	defer stop()
+
+	// The requests that are in progress are allowed to finish when the
+	// server is stopped, and a second signal stops the server immediately.
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err = r.signIn(ctx); err != nil {
+		fatal(exitFetch, "Failed to sign in to Bluesky.", "error", err)
+	}
+
+	s := &server{
+		runner:     r,
+		ttl:        *ttl,
+		maxEntries: *cacheEntries,
+		cache:      make(map[cacheKey]cacheEntry),
+		inflight:   make(map[cacheKey]*flight),
+		health:     health{started: time.Now(), staleAfter: *staleAfter},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /feed", s.serveFeed)
+	mux.Handle("GET /metrics", r.metrics)
+	mux.HandleFunc("GET /healthz", s.serveHealth)
+	mux.HandleFunc("GET /readyz", s.serveReady)
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	slog.Info("Listening for requests.", "addr", *addr)
+	select {
+	case err = <-errs:
+		fatal(exitFailure, "The server failed.", "error", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down the server.")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err = srv.Shutdown(ctx); err != nil {
+		fatal(exitFailure, "Failed to shut down the server.", "error", err)
+	}
+}
+
+// server serves the transformed feeds of Bluesky accounts and caches the
+// transformed feeds in memory.
+type server struct {
+	runner     *runner
+	ttl        time.Duration
+	maxEntries int
+
+	mu       sync.Mutex
+	cache    map[cacheKey]cacheEntry
+	inflight map[cacheKey]*flight
+	health   health
+}
+
+// cacheKey identifies a transformed feed in the cache of the server.
+type cacheKey struct {
+	handle string
+	format string
+}
+
+// cacheEntry is a transformed feed in the cache of the server.
+type cacheEntry struct {
+	data     []byte
+	hash     string
+	modified time.Time
+	expires  time.Time
+	used     time.Time
+}
+
+// flight is a feed that is being downloaded and transformed. The requests
+// for the feed that arrive in the meantime wait for the result instead of
+// downloading the feed again.
+type flight struct {
+	done  chan struct{}
+	entry cacheEntry
+	err   error
+}
+
+// serveFeed handles a request for the transformed feed of the account that
+// is identified by the handle parameter.
+func (s *server) serveFeed(w http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	handle := strings.TrimPrefix(query.Get("handle"), "@")
+	if handle == "" {
+		http.Error(w, "the handle parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	if !validHandle(s.runner.cfg.Source, handle) {
+		http.Error(
+			w,
+			fmt.Sprintf("%q is not the handle of an account", handle),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		format = s.runner.cfg.Format
+	}
+
+	contentType, ok := contentTypes[format]
+	if !ok || (format == "template" && s.runner.template == nil) {
+		http.Error(
+			w,
+			fmt.Sprintf("the format %q is not supported", format),
+			http.StatusBadRequest,
+		)
+		return
+	}
+
+	entry, err := s.get(req.Context(), cacheKey{handle: handle, format: format})
+	if err != nil {
+		s.runner.metrics.feedFailed()
+		slog.Error(
+			"Failed to transform the feed.",
+			"handle", handle,
+			"error", err,
+		)
+		http.Error(w, "failed to transform the feed", http.StatusBadGateway)
+		return
+	}
+
+	maxAge := int(time.Until(entry.expires).Seconds())
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", max(maxAge, 0)))
+	w.Header().Set("ETag", `"`+entry.hash+`"`)
+	http.ServeContent(w, req, "", entry.modified, bytes.NewReader(entry.data))
+}
+
+// validHandle reports whether handle identifies an account of source. The
+// handle is checked before the feed is downloaded so that a request cannot
+// make the server download a feed from an arbitrary host. A Mastodon
+// handle has the form user@instance, where the instance is a domain name.
+func validHandle(source string, handle string) bool {
+	switch source {
+	case "mastodon":
+		user, instance, ok := strings.Cut(handle, "@")
+		return ok && usernamePattern.MatchString(user) &&
+			feed.IsHandle(instance)
+	case "microblog":
+		return usernamePattern.MatchString(handle)
+	default:
+		return feed.IsHandle(handle) || feed.IsDID(handle)
+	}
+}
+
+// get returns the transformed feed for key from the cache. If the feed is
+// not in the cache or the cached feed has expired, the feed is downloaded
+// and transformed again. Concurrent requests for a feed that is not cached
+// share a single download.
+func (s *server) get(ctx context.Context, key cacheKey) (cacheEntry, error) {
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	hit := ok && now.Before(cached.expires)
+	if hit {
+		cached.used = now
+		s.cache[key] = cached
+	}
+
+	f, waiting := s.inflight[key]
+	if !hit && !waiting {
+		f = &flight{done: make(chan struct{})}
+		s.inflight[key] = f
+	}
+	s.mu.Unlock()
+	s.runner.metrics.cacheLookup("serve", hit)
+	if hit {
+		return cached, nil
+	}
+
+	if waiting {
+		select {
+		case <-f.done:
+			return f.entry, f.err
+		case <-ctx.Done():
+			return cacheEntry{}, ctx.Err()
+		}
+	}
+
+	// The feed is downloaded for every request that is waiting for it, so
+	// the download is not canceled when the request that started it is.
+	f.entry, f.err = s.fetch(context.WithoutCancel(ctx), key, cached, ok)
+	s.mu.Lock()
+	delete(s.inflight, key)
+	s.mu.Unlock()
+	close(f.done)
+	return f.entry, f.err
+}
+
+// fetch downloads and transforms the feed for key and adds it to the cache.
+// cached is the expired feed for key if ok is true. Expired feeds are
+// removed from the cache when a new feed is added, and the least recently
+// used feed is removed if the cache is full.
+func (s *server) fetch(
+	ctx context.Context,
+	key cacheKey,
+	cached cacheEntry,
+	ok bool,
+) (cacheEntry, error) {
+	now := time.Now()
+	files, err := s.render(ctx, key.handle, key.format)
+	s.mu.Lock()
+	s.health.record(time.Now(), err)
+	s.mu.Unlock()
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	entry := cacheEntry{
+		data:     files[""],
+		hash:     files.Hash(),
+		modified: now,
+		expires:  now.Add(s.ttl),
+		used:     now,
+	}
+	if ok && entry.hash == cached.hash {
+		entry.modified = cached.modified
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, e := range s.cache {
+		if !now.Before(e.expires) {
+			delete(s.cache, k)
+		}
+	}
+
+	if _, exists := s.cache[key]; !exists && len(s.cache) >= s.maxEntries {
+		// key is not in the cache, so it marks that no feed was found yet.
+		oldest := key
+		for k, e := range s.cache {
+			if oldest == key || e.used.Before(s.cache[oldest].used) {
+				oldest = k
+			}
+		}
+
+		delete(s.cache, oldest)
+	}
+
+	s.cache[key] = entry
+	return entry, nil
+}
+
+// render downloads and transforms the feed of the account identified by
+// handle and renders the feed using format.
+func (s *server) render(
+	ctx context.Context,
+	handle string,
+	format string,
+) (output.Files, error) {
+	r := s.runner
+	fc := feedConfig{
+		Source: r.cfg.Source,
+		Handle: strings.TrimPrefix(handle, "@"),
+	}
+	if err := fc.applyHandle(); err != nil {
+		return nil, err
+	}
+
+	source := r.source(ctx, fc, time.Time{})
+	start := time.Now()
+	rss, _, err := source.Fetch(ctx, feed.Validators{})
+	r.metrics.observeFetch(fc.Source, false, time.Since(start), err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the RSS feed: %w", err)
+	}
+
+	log := slog.With("handle", handle)
+	rss.Channel.Items, err = r.transformItems(ctx, log, rss.Channel.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rss.Channel.Items = r.schedule(now).Apply(rss.Channel.Items, now)
+	rss.Channel.Items = r.cfg.Pinned.Apply(
+		r.cfg.Sort.Sort(rss.Channel.Items),
+		map[string]bool{rss.Channel.Pinned: true},
+	)
+	r.engagement(ctx, log, rss.Channel.Items)
+
+	files, err := output.Render(format, rss, r.renderOptions(feedConfig{}))
+	if err != nil {
+		return nil, err
+	}
+
+	r.metrics.itemsEmitted(len(rss.Channel.Items))
+	return files, nil
+}
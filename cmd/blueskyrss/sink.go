@@ -0,0 +1,181 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+)
+
+// renderedSink is an additional output of a feed and the files that were
+// rendered for it.
+type renderedSink struct {
+	sinkConfig
+	sink  output.Sink
+	files output.Files
+}
+
+// renderSinks renders the feed for each of the sinks of the feed fc.
+func (r *runner) renderSinks(
+	fc feedConfig,
+	rss feed.RSS,
+) ([]renderedSink, error) {
+	opts := r.renderOptions(fc)
+	sinks := make([]renderedSink, 0, len(fc.Sinks))
+	for _, sc := range fc.Sinks {
+		sink := r.newSink(sc.Format, sc.Path, opts)
+		files, err := sink.Render(rss)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to write the %s sink: %w",
+				sc.Format,
+				err,
+			)
+		}
+
+		sinks = append(sinks, renderedSink{sc, sink, files})
+	}
+
+	return sinks, nil
+}
+
+// newSink returns the sink that writes the output in format to path, which
+// is standard output if path is "-", an object in an object store if path
+// is an object URL, a file on a server if path is an SFTP URL, or a file or
+// directory otherwise.
+func (r *runner) newSink(
+	format string,
+	path string,
+	opts output.Options,
+) output.Sink {
+	switch {
+	case path == "-":
+		return output.WriterSink{
+			Format:  format,
+			W:       lockedWriter{mu: &r.stdout, w: os.Stdout},
+			Options: opts,
+		}
+	case output.IsObjectURL(path):
+		return output.ObjectSink{
+			Format:    format,
+			URL:       path,
+			Client:    r.uploads,
+			Options:   opts,
+			Gzip:      r.cfg.Gzip,
+			Checksums: r.cfg.Checksums,
+			Signer:    r.signer,
+		}
+	case output.IsSFTPURL(path):
+		return output.SFTPSink{
+			Format:    format,
+			URL:       path,
+			Options:   opts,
+			Gzip:      r.cfg.Gzip,
+			Checksums: r.cfg.Checksums,
+			Signer:    r.signer,
+		}
+	default:
+		return output.FileSink{
+			Format:        format,
+			Path:          path,
+			Options:       opts,
+			SkipUnchanged: r.cfg.SkipUnchanged,
+			Gzip:          r.cfg.Gzip,
+			Checksums:     r.cfg.Checksums,
+			Signer:        r.signer,
+		}
+	}
+}
+
+// isFilePath reports whether the output that is written to path is written
+// to files, and not to standard output, to an object store, or to an SFTP
+// server. The uploaded output is not read, so output that is uploaded is
+// assumed to still exist when it has not changed.
+func isFilePath(path string) bool {
+	return path != "-" && !isRemoteURL(path)
+}
+
+// isRemoteURL reports whether the output that is written to path is
+// uploaded to an object store or to an SFTP server.
+func isRemoteURL(path string) bool {
+	return output.IsObjectURL(path) || output.IsSFTPURL(path)
+}
+
+// sinkMissing reports whether the output of any of the sinks that are
+// written to a path does not exist.
+func sinkMissing(sinks []sinkConfig) bool {
+	for _, s := range sinks {
+		if _, err := os.Stat(s.Path); isFilePath(s.Path) && err != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sinksExist reports whether the output files of all of the sinks that
+// are written to a path exist.
+func sinksExist(sinks []renderedSink) bool {
+	for _, s := range sinks {
+		if isFilePath(s.Path) && !s.files.Exists(s.Path) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sinksHash returns a hash of the output files of a feed and of its sinks
+// that can be used to determine whether any of the output has changed. The
+// hash of a feed that does not have any sinks is the hash of its files.
+func sinksHash(files output.Files, sinks []renderedSink) string {
+	if len(sinks) == 0 {
+		return files.Hash()
+	}
+
+	h := sha256.New()
+	_, _ = io.WriteString(h, files.Hash())
+	for _, s := range sinks {
+		_, _ = fmt.Fprintf(h, "\x00%s\x00%s", s.Path, s.files.Hash())
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lockedWriter serializes the writes to w using mu so that the output of
+// feeds that are processed concurrently is not interleaved.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+)
+
+// stateFile stores information about the feeds that were transformed by a
+// previous run. The state is used to send conditional requests when the
+// feeds are downloaded again and to skip rewriting output that has not
+// changed. The state for each feed is keyed by the output path of the feed.
+type stateFile struct {
+	Feeds map[string]feedState `json:"feeds"`
+
+	mu   sync.Mutex
+	name string
+}
+
+type feedState struct {
+	URL          string `json:"url,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Hash         string `json:"hash,omitempty"`
+
+	// Newest is the time that the newest post of each account was added
+	// to its author feed, keyed by the actor of the feed. It is the
+	// high-water mark of an incremental sync.
+	Newest map[string]time.Time `json:"newest,omitempty"`
+}
+
+// newest returns the high-water mark for actor. The zero time is returned
+// if the posts of actor have not been synced yet.
+func (s feedState) newest(actor string) time.Time {
+	return s.Newest[actor]
+}
+
+// setNewest moves the high-water mark for actor to the newest post in
+// items. The high-water mark does not change if items does not contain a
+// newer post.
+func (s *feedState) setNewest(actor string, items []feed.Item) {
+	newest := s.Newest[actor]
+	for _, item := range items {
+		if item.Post != nil && item.Post.IndexedAt().After(newest) {
+			newest = item.Post.IndexedAt()
+		}
+	}
+
+	if newest.IsZero() {
+		return
+	}
+
+	if s.Newest == nil {
+		s.Newest = make(map[string]time.Time)
+	}
+
+	s.Newest[actor] = newest
+}
+
+// loadState reads the state file name. An empty state is returned if the
+// file does not exist yet.
+func loadState(name string) (*stateFile, error) {
+	state := &stateFile{
+		Feeds: make(map[string]feedState),
+		name:  name,
+	}
+
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the state file: %w", err)
+	}
+
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse the state file: %w", err)
+	}
+
+	if state.Feeds == nil {
+		state.Feeds = make(map[string]feedState)
+	}
+
+	return state, nil
+}
+
+// get returns the state for the feed that is written to path. A nil state
+// file returns an empty state so that callers do not need to check whether
+// the state file is enabled.
+func (s *stateFile) get(path string) feedState {
+	if s == nil {
+		return feedState{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Feeds[path]
+}
+
+func (s *stateFile) set(path string, state feedState) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Feeds[path] = state
+}
+
+// save writes the state back to the state file. A state that does not have
+// a file name is only kept in memory and is not saved.
+func (s *stateFile) save() error {
+	if s == nil || s.name == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return output.WriteFile(s.name, append(data, '\n'))
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
+)
+
+// streamBufferSize is the size of the buffer that the output of a streamed
+// feed is written through. The XML encoder flushes its own small buffer
+// every few items, so the larger buffer reduces the number of writes to the
+// output file for large feeds.
+const streamBufferSize = 64 << 10
+
+// streamFeed downloads the RSS feed of fc and rewrites the dates of the
+// posts while the feed is downloaded, without reading the whole feed into
+// memory. The feed is written to a temporary file next to the output file,
+// which replaces the output file once the whole feed has been written.
+func (r *runner) streamFeed(ctx context.Context, fc feedConfig) error {
+	prev := r.state.get(fc.Path)
+	if prev.URL != fc.URL {
+		prev = feedState{URL: fc.URL}
+	}
+
+	start := time.Now()
+	body, validators, err := r.fetcher.OpenRSS(ctx, fc.URL, feed.Validators{
+		ETag:         prev.ETag,
+		LastModified: prev.LastModified,
+	})
+	r.metrics.observeFetch(
+		fc.Source,
+		prev.ETag != "" || prev.LastModified != "",
+		time.Since(start),
+		err,
+	)
+	if errors.Is(err, feed.ErrNotModified) {
+		slog.Info("The feed has not been modified.", "path", fc.Path)
+		r.report.add(feedReport{Path: fc.Path, Hash: prev.Hash})
+		return nil
+	}
+
+	if err != nil {
+		return fetchError(
+			fmt.Errorf("failed to download the RSS feed: %w", err),
+		)
+	}
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	tmp, err := os.CreateTemp(
+		filepath.Dir(fc.Path),
+		"."+filepath.Base(fc.Path)+".*",
+	)
+	if err != nil {
+		return withExitCode(
+			exitWrite,
+			fmt.Errorf("failed to create the output file: %w", err),
+		)
+	}
+
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	h := sha256.New()
+	buf := bufio.NewWriterSize(tmp, streamBufferSize)
+	items, itemErrors, err := transform.StreamDates(
+		io.MultiWriter(buf, h),
+		body,
+		r.cfg.DateLayouts,
+		r.cfg.DateFormat,
+		r.cfg.Location,
+		r.cfg.OnError,
+	)
+	if err != nil {
+		return fetchError(err)
+	}
+
+	if err = buf.Flush(); err != nil {
+		return withExitCode(
+			exitWrite,
+			fmt.Errorf("failed to write the output file: %w", err),
+		)
+	}
+
+	r.logItemErrors(slog.With("path", fc.Path), itemErrors)
+	r.metrics.itemsEmitted(len(items))
+	next := prev
+	next.ETag = validators.ETag
+	next.LastModified = validators.LastModified
+	next.Hash = hex.EncodeToString(h.Sum(nil))
+	_, statErr := os.Stat(fc.Path)
+	if r.state != nil && next.Hash == prev.Hash && statErr == nil {
+		slog.Info("The output has not changed.", "path", fc.Path)
+		r.outputs.record(items, false)
+		r.report.add(newFeedReport(fc.Path, items, outputChanges{}, next.Hash))
+		r.state.set(fc.Path, next)
+		return nil
+	}
+
+	if r.cfg.DryRun {
+		// The diff of the output needs the whole output, so a dry run
+		// reads the output into memory after all.
+		data, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return withExitCode(
+				exitWrite,
+				fmt.Errorf("failed to read the output: %w", err),
+			)
+		}
+
+		changed, err := r.preview(fc.Path, output.Files{"": data})
+		r.outputs.record(items, changed)
+		return err
+	}
+
+	if err = tmp.Chmod(0o644); err == nil {
+		err = tmp.Close()
+	}
+
+	var changes outputChanges
+	if r.webhook != nil || r.report != nil || r.git != nil {
+		// The streamed items only have their links and dates, so the
+		// items whose content has changed cannot be counted.
+		changes = compareOutput(
+			fc.Format,
+			fc.Path,
+			output.Options{},
+			items,
+			nil,
+		)
+		changes.changed = 0
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), fc.Path)
+	}
+
+	if err != nil {
+		return withExitCode(
+			exitWrite,
+			fmt.Errorf("failed to write %s: %w", fc.Path, err),
+		)
+	}
+
+	r.webhook.add(changes.added)
+	r.git.add(changes.added)
+	r.report.add(newFeedReport(fc.Path, items, changes, next.Hash))
+	r.outputs.record(items, true)
+	slog.Info("Wrote the output.", "path", fc.Path, "files", 1)
+	r.state.set(fc.Path, next)
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/output"
+)
+
+// validate runs the validate command. The validate command checks the
+// output that was written for each of the configured feeds against the
+// structural rules of the RSS 2.0, Atom 1.0, and JSON Feed 1.1
+// specifications and logs each problem that is found. The program exits
+// with a nonzero exit code if a problem is found so that the command can
+// be used in a CI workflow before the site is published. Only the paths and
+// the formats of the feeds are required because the feeds are not
+// downloaded.
+//
+// The dates of the RSS output are validated using the date_format input
+// unless the -strict flag is set, which requires the RFC 822 dates that
+// RSS 2.0 specifies. The output of the other formats is not validated.
+func validate(args []string) {
+	flags := newFlagSet("blueskyrss validate", "blueskyrss validate [flags]")
+	strict := flags.Bool(
+		"strict",
+		false,
+		"require the RFC 822 dates that RSS 2.0 specifies",
+	)
+	cfg := setup(flags, args)
+	if err := cfg.loadOutputs(); err != nil {
+		fatal(exitConfig, "Invalid configuration.", "error", err)
+	}
+
+	opts := output.ValidateOptions{
+		DateFormat:  cfg.DateFormat,
+		DateLayouts: cfg.DateLayouts,
+		Strict:      *strict,
+	}
+	problems := 0
+	for _, fc := range cfg.Feeds {
+		log := slog.With("path", fc.Path)
+		data, err := os.ReadFile(fc.Path)
+		if err != nil {
+			log.Error("Failed to read the output.", "error", err)
+			problems++
+			continue
+		}
+
+		found, err := output.Validate(fc.Format, data, opts)
+		if err != nil {
+			log.Warn("The output cannot be validated.", "error", err)
+			continue
+		}
+
+		for _, problem := range found {
+			log.Error("The output is not valid.", "problem", problem)
+		}
+
+		if len(found) == 0 {
+			log.Info("The output is valid.")
+		}
+
+		problems += len(found)
+	}
+
+	if problems > 0 {
+		fatal(exitFailure, "The validation failed.", "problems", problems)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// webhookFormats are the supported values of the webhook_format input.
+var webhookFormats = []string{"generic", "slack", "discord"}
+
+// maxDiscordContent is the maximum length of the content of a Discord
+// message.
+const maxDiscordContent = 2000
+
+// webhookTimeout is how long the webhook can take to respond to the
+// notification.
+const webhookTimeout = 30 * time.Second
+
+// webhook notifies a webhook URL about the posts that a run added to the
+// output. The posts are collected while the feeds are processed, and the
+// notification is sent after the run. The notification is sent using a
+// client of its own instead of the fetcher, so that the headers of the feed
+// requests are not sent to the webhook, and it is not retried, so that the
+// webhook is never notified twice about the same posts.
+type webhook struct {
+	url    string
+	format string
+	secret string
+	client *http.Client
+
+	mu    sync.Mutex
+	posts []feed.Item
+}
+
+// webhookPayload is the JSON document that is sent using the generic
+// format.
+type webhookPayload struct {
+	Count int           `json:"count"`
+	Posts []webhookPost `json:"posts"`
+}
+
+type webhookPost struct {
+	URL  string `json:"url"`
+	Date string `json:"date"`
+	Text string `json:"text"`
+}
+
+// add adds posts to the posts that the notification is sent for.
+func (w *webhook) add(posts []feed.Item) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.posts = append(w.posts, posts...)
+}
+
+// send sends the notification for the posts that were added since the
+// previous notification. Nothing is sent if no posts were added. The
+// payload is signed using HMAC-SHA256 when the webhook has a secret, and
+// the signature is sent in the X-Hub-Signature-256 header in the same way
+// as the webhooks of GitHub.
+func (w *webhook) send(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	posts := w.posts
+	w.posts = nil
+	w.mu.Unlock()
+	if len(posts) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(w.payload(posts))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		w.url,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", w.url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set(
+			"X-Hub-Signature-256",
+			"sha256="+hex.EncodeToString(mac.Sum(nil)),
+		)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// payload returns the payload of the notification for posts using the
+// format of the webhook. Slack and Discord show a message that lists the
+// links of the posts.
+func (w *webhook) payload(posts []feed.Item) any {
+	switch w.format {
+	case "slack":
+		return map[string]string{"text": webhookMessage(posts, 0)}
+	case "discord":
+		return map[string]string{
+			"content": webhookMessage(posts, maxDiscordContent),
+		}
+	default:
+		payload := webhookPayload{
+			Count: len(posts),
+			Posts: make([]webhookPost, 0, len(posts)),
+		}
+		for _, post := range posts {
+			payload.Posts = append(payload.Posts, webhookPost{
+				URL:  post.Link,
+				Date: post.PubDate,
+				Text: post.PlainText(),
+			})
+		}
+
+		return payload
+	}
+}
+
+// webhookMessage returns a message that lists the links of posts. If limit
+// is not zero, the links that do not fit into limit characters are left
+// out.
+func webhookMessage(posts []feed.Item, limit int) string {
+	var b strings.Builder
+	if len(posts) == 1 {
+		b.WriteString("1 new post:")
+	} else {
+		fmt.Fprintf(&b, "%d new posts:", len(posts))
+	}
+
+	for _, post := range posts {
+		if limit > 0 && b.Len()+1+len(post.Link) > limit {
+			break
+		}
+
+		b.WriteString("\n")
+		b.WriteString(post.Link)
+	}
+
+	return b.String()
+}
@@ -1,3 +1,13 @@
 module github.com/mfcollins3/hugoify-bluesky-rss-feed
 
 go 1.24.0
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/net v0.38.0
+	golang.org/x/text v0.23.0
+)
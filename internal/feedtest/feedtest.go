@@ -0,0 +1,71 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package feedtest builds the feeds that are used by the tests and
+// benchmarks of the other packages.
+package feedtest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// Feed returns a feed with n items that look like the items of the RSS
+// feed that Bluesky publishes. The items are one minute apart, from newest
+// to oldest, and their pubDate fields use the RFC 1123 format of the
+// Bluesky feeds.
+func Feed(n int) feed.RSS {
+	f := feed.RSS{
+		Version: "2.0",
+		Channel: feed.Channel{
+			Title:       "@alice.test - Alice",
+			Link:        "https://bsky.app/profile/alice.test",
+			Description: "Posts by Alice.",
+		},
+	}
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range n {
+		f.Channel.Items = append(f.Channel.Items, feed.Item{
+			Link: fmt.Sprintf(
+				"https://bsky.app/profile/alice.test/post/3k%06d",
+				i,
+			),
+			Description: fmt.Sprintf(
+				"Post %d about #hugo with a <a href=\"https://example.com/\">"+
+					"link</a> &amp; some <b>markup</b>.",
+				i,
+			),
+			PubDate: start.Add(-time.Duration(i) * time.Minute).
+				Format(time.RFC1123Z),
+			GUID: feed.GUID{
+				IsPermaLink: "false",
+				Value: fmt.Sprintf(
+					"at://did:plc:alice/app.bsky.feed.post/3k%06d",
+					i,
+				),
+			},
+		})
+	}
+
+	return f
+}
@@ -0,0 +1,314 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores the posts and the DIDs that were downloaded by a previous
+// run in a directory so that repeated runs only request what has changed.
+// The author feed of each account is stored in the feeds directory, the
+// self-reply threads are stored in the threads directory keyed by the CID
+// of the root post, the DIDs of the handles are stored in dids.json, and
+// the link cards of the links in posts are stored in links.json.
+// The CID of a post changes whenever the record of the post changes, so a
+// post that has the same CID as a cached post has not changed. A Cache can
+// be used by multiple goroutines.
+type Cache struct {
+	// Dir is the directory that the cache is stored in. The directory is
+	// created when the cache is first written.
+	Dir string
+
+	mu sync.Mutex
+}
+
+// cachedDID is the DID of a handle in dids.json.
+type cachedDID struct {
+	DID     string    `json:"did"`
+	Expires time.Time `json:"expires"`
+}
+
+// AuthorFeed returns the cached author feed of actor, ordered from newest
+// to oldest. No posts are returned if the feed has not been cached yet.
+func (c *Cache) AuthorFeed(actor string) ([]FeedViewPost, error) {
+	var posts []FeedViewPost
+	err := c.read(filepath.Join("feeds", cacheFileName(actor)), &posts)
+	return posts, err
+}
+
+// SetAuthorFeed replaces the cached author feed of actor.
+func (c *Cache) SetAuthorFeed(actor string, posts []FeedViewPost) error {
+	return c.write(filepath.Join("feeds", cacheFileName(actor)), posts)
+}
+
+// Thread returns the cached posts of the self-reply thread whose root post
+// has the CID cid. No posts are returned if the thread has not been cached
+// yet.
+func (c *Cache) Thread(cid string) ([]PostView, error) {
+	var posts []PostView
+	err := c.read(filepath.Join("threads", cacheFileName(cid)), &posts)
+	return posts, err
+}
+
+// SetThread replaces the cached posts of the self-reply thread whose root
+// post has the CID cid.
+func (c *Cache) SetThread(cid string, posts []PostView) error {
+	return c.write(filepath.Join("threads", cacheFileName(cid)), posts)
+}
+
+// DID returns the cached DID of handle. The second result is false if the
+// handle is not cached or if the cached DID expired before now.
+func (c *Cache) DID(handle string, now time.Time) (string, bool) {
+	var dids map[string]cachedDID
+	if c.read("dids.json", &dids) != nil {
+		return "", false
+	}
+
+	cached, ok := dids[handle]
+	if !ok || !now.Before(cached.Expires) {
+		return "", false
+	}
+
+	return cached.DID, true
+}
+
+// SetDID caches the DID of handle until expires. The DIDs that have already
+// expired are removed from the cache.
+func (c *Cache) SetDID(handle string, did string, expires time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var dids map[string]cachedDID
+	if err := c.readLocked("dids.json", &dids); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for key, cached := range dids {
+		if !now.Before(cached.Expires) {
+			delete(dids, key)
+		}
+	}
+
+	if dids == nil {
+		dids = make(map[string]cachedDID)
+	}
+
+	dids[handle] = cachedDID{DID: did, Expires: expires}
+	return c.writeLocked("dids.json", dids)
+}
+
+// cachedLinkCard is the link card of a URL in links.json. Card is nil if
+// the page does not have a title, so that the page is not downloaded again
+// until the entry expires.
+type cachedLinkCard struct {
+	Card    *LinkCard `json:"card"`
+	Expires time.Time `json:"expires"`
+}
+
+// LinkCard returns the cached link card of the page at u, which is nil if
+// the page does not have a title. The second result is false if the page is
+// not cached or if the cached link card expired before now.
+func (c *Cache) LinkCard(u string, now time.Time) (*LinkCard, bool) {
+	var links map[string]cachedLinkCard
+	if c.read("links.json", &links) != nil {
+		return nil, false
+	}
+
+	cached, ok := links[u]
+	if !ok || !now.Before(cached.Expires) {
+		return nil, false
+	}
+
+	return cached.Card, true
+}
+
+// SetLinkCard caches the link card of the page at u until expires. The link
+// cards that have already expired are removed from the cache.
+func (c *Cache) SetLinkCard(
+	u string,
+	card *LinkCard,
+	expires time.Time,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var links map[string]cachedLinkCard
+	if err := c.readLocked("links.json", &links); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for key, cached := range links {
+		if !now.Before(cached.Expires) {
+			delete(links, key)
+		}
+	}
+
+	if links == nil {
+		links = make(map[string]cachedLinkCard)
+	}
+
+	links[u] = cachedLinkCard{Card: card, Expires: expires}
+	return c.writeLocked("links.json", links)
+}
+
+// read decodes the cache file name into v. v is not changed if the file
+// does not exist.
+func (c *Cache) read(name string, v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.readLocked(name, v)
+}
+
+func (c *Cache) readLocked(name string, v any) error {
+	data, err := os.ReadFile(filepath.Join(c.Dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read the cache: %w", err)
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse the cache file %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// write encodes v into the cache file name. The file is replaced
+// atomically so that a run that is interrupted does not leave a partial
+// file in the cache.
+func (c *Cache) write(name string, v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.writeLocked(name, v)
+}
+
+func (c *Cache) writeLocked(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(c.Dir, name)
+	if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create the cache directory: %w", err)
+	}
+
+	file, err := os.CreateTemp(
+		filepath.Dir(target),
+		"."+filepath.Base(target)+"-*",
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write the cache: %w", err)
+	}
+
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	if _, err = file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write the cache: %w", err)
+	}
+
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to write the cache: %w", err)
+	}
+
+	return os.Rename(file.Name(), target)
+}
+
+// cacheFileName returns the name of the cache file for key, which is a
+// handle, a DID, or a CID. The characters that are not allowed in file
+// names on every platform, such as the colons in a DID, are replaced.
+func cacheFileName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+			r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, key) + ".json"
+}
+
+// key identifies the post in an author feed. A post that was reposted by
+// the author has a different key than the post itself so that the repost
+// and the post can both be cached.
+func (p *FeedViewPost) key() string {
+	if p.Reason != nil {
+		return p.Post.CID + " " + p.Reason.Type + " " + p.Reason.By.DID
+	}
+
+	return p.Post.CID
+}
+
+// withCachedPosts appends the cached posts that are older than the posts
+// that were downloaded to posts. The cached posts that are newer than the
+// oldest downloaded post but were not downloaded again have been deleted,
+// so they are not included. If none of the downloaded posts are cached,
+// the cached posts are not used because the author feed no longer overlaps
+// with the cache. If limit is not zero, at most limit posts are returned.
+func withCachedPosts(
+	posts []FeedViewPost,
+	cached []FeedViewPost,
+	limit int,
+) []FeedViewPost {
+	downloaded := make(map[string]bool, len(posts))
+	for i := range posts {
+		downloaded[posts[i].key()] = true
+	}
+
+	last := -1
+	for i := range cached {
+		if downloaded[cached[i].key()] {
+			last = i
+		}
+	}
+
+	if last >= 0 {
+		for _, post := range cached[last+1:] {
+			if !downloaded[post.key()] {
+				posts = append(posts, post)
+			}
+		}
+	}
+
+	if limit > 0 && len(posts) > limit {
+		posts = posts[:limit]
+	}
+
+	return posts
+}
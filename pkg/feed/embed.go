@@ -0,0 +1,404 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	embedImagesView          = "app.bsky.embed.images#view"
+	embedExternalView        = "app.bsky.embed.external#view"
+	embedVideoView           = "app.bsky.embed.video#view"
+	embedRecordView          = "app.bsky.embed.record#view"
+	embedRecordWithMediaView = "app.bsky.embed.recordWithMedia#view"
+	embedViewRecord          = "app.bsky.embed.record#viewRecord"
+)
+
+// EmbedView is the hydrated view of the embed of a post. The fields that are
+// populated depend on the type of the embed.
+type EmbedView struct {
+	Type   string           `json:"$type"`
+	Images []ImageView      `json:"images,omitempty"`
+	Media  *EmbedView       `json:"media,omitempty"`
+	Record *EmbedRecordView `json:"record,omitempty"`
+
+	// The external link of an app.bsky.embed.external#view embed.
+	External *ExternalView `json:"external,omitempty"`
+
+	// The fields of an app.bsky.embed.video#view embed.
+	Playlist    string       `json:"playlist,omitempty"`
+	Thumbnail   string       `json:"thumbnail,omitempty"`
+	Alt         string       `json:"alt,omitempty"`
+	AspectRatio *AspectRatio `json:"AspectRatio,omitempty"`
+}
+
+// EmbedRecordView is the view of a record that is embedded in a post, such
+// as a quoted post. The record of an app.bsky.embed.recordWithMedia#view
+// embed is an app.bsky.embed.record#view, whose Record field contains the
+// view of the embedded record.
+type EmbedRecordView struct {
+	Type   string            `json:"$type"`
+	URI    string            `json:"uri,omitempty"`
+	Author *ProfileViewBasic `json:"author,omitempty"`
+	Value  *PostRecord       `json:"value,omitempty"`
+	Record *EmbedRecordView  `json:"record,omitempty"`
+}
+
+type ExternalView struct {
+	URI         string `json:"uri"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Thumb       string `json:"thumb,omitempty"`
+}
+
+type ImageView struct {
+	Thumb       string       `json:"thumb"`
+	Fullsize    string       `json:"fullsize"`
+	Alt         string       `json:"alt"`
+	AspectRatio *AspectRatio `json:"AspectRatio,omitempty"`
+}
+
+type AspectRatio struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Media describes an image or video that is attached to a post. Size is the
+// size of the file in bytes, which is only known for files that have been
+// downloaded.
+type Media struct {
+	Medium    string
+	URL       string
+	MIMEType  string
+	Thumbnail string
+	Alt       string
+	Width     int
+	Height    int
+	Size      int64
+}
+
+// ParseEmbed decodes the embed of a post. A nil embed is returned if the post
+// does not have an embed.
+func ParseEmbed(raw json.RawMessage) (*EmbedView, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var embed EmbedView
+	if err := json.Unmarshal(raw, &embed); err != nil {
+		return nil, fmt.Errorf("failed to parse the embed: %w", err)
+	}
+
+	return &embed, nil
+}
+
+// EmbedMedia returns the images and videos that are attached to a post.
+func EmbedMedia(embed *EmbedView) []Media {
+	if embed == nil {
+		return nil
+	}
+
+	switch embed.Type {
+	case embedImagesView:
+		result := make([]Media, 0, len(embed.Images))
+		for _, image := range embed.Images {
+			m := Media{
+				Medium:    "image",
+				URL:       image.Fullsize,
+				MIMEType:  imageMIMEType(image.Fullsize),
+				Thumbnail: image.Thumb,
+				Alt:       image.Alt,
+			}
+			if image.AspectRatio != nil {
+				m.Width = image.AspectRatio.Width
+				m.Height = image.AspectRatio.Height
+			}
+
+			result = append(result, m)
+		}
+
+		return result
+	case embedVideoView:
+		m := Media{
+			Medium:    "video",
+			URL:       embed.Playlist,
+			MIMEType:  "application/x-mpegURL",
+			Thumbnail: embed.Thumbnail,
+			Alt:       embed.Alt,
+		}
+		if embed.AspectRatio != nil {
+			m.Width = embed.AspectRatio.Width
+			m.Height = embed.AspectRatio.Height
+		}
+
+		return []Media{m}
+	case embedExternalView:
+		if m, ok := gifMedia(embed.External); ok {
+			return []Media{m}
+		}
+	case embedRecordWithMediaView:
+		return EmbedMedia(embed.Media)
+	}
+
+	return nil
+}
+
+// gifMedia returns the animated GIF that is linked by an external embed.
+// Bluesky attaches GIFs from Tenor as external links whose description is
+// the alt text of the GIF prefixed by "Alt: " and whose URL contains the
+// width and height of the GIF as the ww and hh query parameters. The second
+// result is false if the external link is not a GIF.
+func gifMedia(external *ExternalView) (Media, bool) {
+	if external == nil {
+		return Media{}, false
+	}
+
+	u, err := url.Parse(external.URI)
+	if err != nil || !strings.EqualFold(path.Ext(u.Path), ".gif") {
+		return Media{}, false
+	}
+
+	m := Media{
+		Medium:    "image",
+		URL:       external.URI,
+		MIMEType:  "image/gif",
+		Thumbnail: external.Thumb,
+		Alt: cmp.Or(
+			strings.TrimPrefix(external.Description, "Alt: "),
+			external.Title,
+		),
+	}
+	m.Width, _ = strconv.Atoi(u.Query().Get("ww"))
+	m.Height, _ = strconv.Atoi(u.Query().Get("hh"))
+	return m, true
+}
+
+// Quote describes a post that is quoted by another post.
+type Quote struct {
+	URL    string
+	Author ProfileViewBasic
+	Text   string
+	Facets []Facet
+}
+
+// LinkCard is the preview of an external link that is attached to a post,
+// which Bluesky shows as a card with the title, description, and thumbnail
+// image of the linked page.
+type LinkCard struct {
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Thumbnail   string `json:"thumbnail,omitempty"`
+}
+
+// EmbedLinkCard returns the link card of a post, or nil if the post does not
+// have an external link. A link to a GIF is not a link card because the GIF
+// is attached to the post as an image.
+func EmbedLinkCard(embed *EmbedView) *LinkCard {
+	if embed != nil && embed.Type == embedRecordWithMediaView {
+		embed = embed.Media
+	}
+
+	if embed == nil || embed.Type != embedExternalView ||
+		embed.External == nil || embed.External.URI == "" {
+		return nil
+	}
+
+	if _, ok := gifMedia(embed.External); ok {
+		return nil
+	}
+
+	return &LinkCard{
+		URL:         embed.External.URI,
+		Title:       embed.External.Title,
+		Description: embed.External.Description,
+		Thumbnail:   embed.External.Thumb,
+	}
+}
+
+// EmbedQuote returns the post that is quoted by a post, or nil if the post
+// does not quote another post or the quoted post is not available, such as
+// when the quoted post was deleted.
+func EmbedQuote(embed *EmbedView) *Quote {
+	if embed == nil {
+		return nil
+	}
+
+	record := embed.Record
+	if embed.Type == embedRecordWithMediaView && record != nil {
+		record = record.Record
+	} else if embed.Type != embedRecordView {
+		return nil
+	}
+
+	if record == nil || record.Type != embedViewRecord ||
+		record.Author == nil || record.Value == nil {
+		return nil
+	}
+
+	return &Quote{
+		URL:    PostURL(record.Author.Handle, record.URI),
+		Author: *record.Author,
+		Text:   record.Value.Text,
+		Facets: record.Value.Facets,
+	}
+}
+
+// imageMIMEType returns the MIME type of the image at u. The Bluesky CDN URLs
+// end with @ and the format of the image, and other URLs use the file
+// extension. JPEG is assumed if the format is not known.
+func imageMIMEType(u string) string {
+	format := strings.ToLower(u[strings.LastIndexAny(u, "@.")+1:])
+	switch format {
+	case "png", "gif", "webp", "avif":
+		return "image/" + format
+	default:
+		return "image/jpeg"
+	}
+}
+
+// RenderMedia renders the images and videos that are attached to a post as
+// HTML. A video is rendered as a video element that plays the HLS playlist
+// of the video and uses the thumbnail of the video as its poster. Browsers
+// that cannot play the playlist show the thumbnail, which links to the
+// playlist, instead.
+func RenderMedia(items []Media) string {
+	var b strings.Builder
+	for _, m := range items {
+		switch m.Medium {
+		case "image":
+			fmt.Fprintf(
+				&b,
+				`<p><img src="%s" alt="%s"></p>`,
+				html.EscapeString(m.URL),
+				html.EscapeString(m.Alt),
+			)
+		case "video":
+			renderVideo(&b, m)
+		}
+	}
+
+	return b.String()
+}
+
+// renderVideo renders a video that is attached to a post as HTML.
+func renderVideo(b *strings.Builder, m Media) {
+	b.WriteString(`<p><video controls playsinline preload="none"`)
+	if m.Thumbnail != "" {
+		fmt.Fprintf(b, ` poster="%s"`, html.EscapeString(m.Thumbnail))
+	}
+
+	if m.Width > 0 && m.Height > 0 {
+		fmt.Fprintf(b, ` width="%d" height="%d"`, m.Width, m.Height)
+	}
+
+	fmt.Fprintf(
+		b,
+		`><source src="%s" type="%s"><a href="%s">`,
+		html.EscapeString(m.URL),
+		html.EscapeString(m.MIMEType),
+		html.EscapeString(m.URL),
+	)
+	if m.Thumbnail != "" {
+		fmt.Fprintf(
+			b,
+			`<img src="%s" alt="%s">`,
+			html.EscapeString(m.Thumbnail),
+			html.EscapeString(m.Alt),
+		)
+	} else {
+		b.WriteString(html.EscapeString(cmp.Or(m.Alt, m.URL)))
+	}
+
+	b.WriteString("</a></video></p>")
+}
+
+// RenderLinkCard renders a link card as a paragraph that links to the page
+// and contains the thumbnail image, title, and description of the page.
+// The paragraph has the bluesky-card class so that it can be styled by the
+// theme of the site. An empty string is returned if c is nil.
+func RenderLinkCard(c *LinkCard) string {
+	if c == nil {
+		return ""
+	}
+
+	title := c.Title
+	if title == "" {
+		title = c.URL
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(
+		&b,
+		`<p class="bluesky-card"><a href="%s">`,
+		html.EscapeString(c.URL),
+	)
+	if c.Thumbnail != "" {
+		fmt.Fprintf(
+			&b,
+			`<img src="%s" alt="%s"><br>`,
+			html.EscapeString(c.Thumbnail),
+			html.EscapeString(title),
+		)
+	}
+
+	fmt.Fprintf(&b, "<strong>%s</strong></a>", html.EscapeString(title))
+	if c.Description != "" {
+		b.WriteString("<br>")
+		b.WriteString(TextToHTML(c.Description))
+	}
+
+	b.WriteString("</p>")
+	return b.String()
+}
+
+// RenderQuote renders a quoted post as a blockquote element that contains
+// the author, the text, and a link to the quoted post. The blockquote has
+// the bluesky-quote class so that it can be styled by the theme of the
+// site. An empty string is returned if q is nil.
+func RenderQuote(q *Quote) string {
+	if q == nil {
+		return ""
+	}
+
+	author := "@" + q.Author.Handle
+	if q.Author.DisplayName != "" {
+		author = q.Author.DisplayName + " (" + author + ")"
+	}
+
+	return fmt.Sprintf(
+		`<blockquote class="bluesky-quote" cite="%[1]s">`+
+			`<p><a href="%[2]s">%[3]s</a></p><p>%[4]s</p>`+
+			`<p><a href="%[1]s">%[1]s</a></p></blockquote>`,
+		html.EscapeString(q.URL),
+		html.EscapeString(ProfileURL(q.Author.Handle)),
+		html.EscapeString(author),
+		RenderRichText(q.Text, q.Facets),
+	)
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptEncoding is the Accept-Encoding header of the requests. Brotli is
+// not requested because the standard library cannot decode it.
+const acceptEncoding = "gzip, deflate"
+
+// decodeBody replaces the body of resp with a reader that decodes the
+// content encoding of the response, so that the callers always read the
+// uncompressed body. The net/http package only decodes gzip responses
+// transparently when it sets the Accept-Encoding header of the request
+// itself, which it does not do when the header was set by the Fetcher or by
+// the caller. An error is returned if the content encoding of the response
+// is not supported.
+func decodeBody(resp *http.Response) error {
+	var open func(io.Reader) (io.Reader, error)
+	encoding := strings.ToLower(
+		strings.TrimSpace(resp.Header.Get("Content-Encoding")),
+	)
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		open = func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		}
+	case "deflate":
+		open = openDeflate
+	default:
+		return fmt.Errorf("the %s content encoding is not supported", encoding)
+	}
+
+	resp.Body = &decodedBody{body: resp.Body, open: open}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// openDeflate returns a reader that decodes the deflate content encoding.
+// The encoding is the zlib format, but some servers send raw deflate data
+// instead, so the format is detected using the zlib header.
+func openDeflate(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// A zlib header uses the deflate method, and its two bytes are a
+	// multiple of 31 when they are read as a big-endian integer.
+	if header[0]&0x0f == 8 && binary.BigEndian.Uint16(header)%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+
+	return flate.NewReader(buffered), nil
+}
+
+// decodedBody is the body of a response whose content encoding is decoded.
+// The decoder is created when the body is first read, so the empty bodies
+// of responses such as 304 Not Modified are not an error.
+type decodedBody struct {
+	body    io.ReadCloser
+	open    func(io.Reader) (io.Reader, error)
+	decoder io.Reader
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.decoder == nil {
+		decoder, err := b.open(b.body)
+		if err != nil {
+			return 0, err
+		}
+
+		b.decoder = decoder
+	}
+
+	return b.decoder.Read(p)
+}
+
+func (b *decodedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"context"
+	"net/url"
+)
+
+// getPostsLimit is the maximum number of posts that can be requested from
+// the app.bsky.feed.getPosts endpoint at once.
+const getPostsLimit = 25
+
+// Engagement contains the number of replies, reposts, likes, and quotes of
+// a post at the time that the post was fetched.
+type Engagement struct {
+	Replies int `json:"replies" yaml:"replies" toml:"replies"`
+	Reposts int `json:"reposts" yaml:"reposts" toml:"reposts"`
+	Likes   int `json:"likes" yaml:"likes" toml:"likes"`
+	Quotes  int `json:"quotes" yaml:"quotes" toml:"quotes"`
+}
+
+// engagement returns the engagement counts of the post.
+func (p *PostView) engagement() *Engagement {
+	return &Engagement{
+		Replies: p.ReplyCount,
+		Reposts: p.RepostCount,
+		Likes:   p.LikeCount,
+		Quotes:  p.QuoteCount,
+	}
+}
+
+// RefreshEngagement downloads the current engagement counts of the items
+// that have an AT URI from the app.bsky.feed.getPosts endpoint and updates
+// the Engagement of the items. This includes the items that were read from
+// the cache or from the previous output, whose counts are out of date, and
+// the items of the Bluesky RSS feed, which does not include the counts. The
+// items of posts that no longer exist keep their counts.
+func (f *Fetcher) RefreshEngagement(ctx context.Context, items []Item) error {
+	indexes := make(map[string][]int, len(items))
+	var uris []string
+	for i, item := range items {
+		uri := item.URI()
+		if uri == "" {
+			continue
+		}
+
+		if _, ok := indexes[uri]; !ok {
+			uris = append(uris, uri)
+		}
+
+		indexes[uri] = append(indexes[uri], i)
+	}
+
+	posts, err := f.FetchPosts(ctx, uris)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		post := &posts[i]
+		for _, index := range indexes[post.URI] {
+			items[index].Engagement = post.engagement()
+		}
+	}
+
+	return nil
+}
+
+// FetchPosts downloads the posts with the AT URIs from the
+// app.bsky.feed.getPosts endpoint. The posts are requested in batches of up
+// to 25 posts. Posts that do not exist, such as posts that were deleted,
+// are not returned.
+func (f *Fetcher) FetchPosts(
+	ctx context.Context,
+	uris []string,
+) ([]PostView, error) {
+	var posts []PostView
+	for start := 0; start < len(uris); start += getPostsLimit {
+		batch := uris[start:min(start+getPostsLimit, len(uris))]
+		var result struct {
+			Posts []PostView `json:"posts"`
+		}
+		if err := f.xrpcQuery(
+			ctx,
+			"app.bsky.feed.getPosts",
+			url.Values{"uris": batch},
+			&result,
+		); err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, result.Posts...)
+	}
+
+	return posts, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// ExportAuthorFeed downloads every page of the author feed of actor, which
+// can be either a handle or a DID, and writes each post to w as JSON Lines.
+// Each line is the app.bsky.feed.defs#feedViewPost object exactly as the
+// app.bsky.feed.getAuthorFeed endpoint returned it, including the raw post
+// record, so the archive can be processed again without losing any fields
+// that the Fetcher does not know about. The posts are written as each page
+// is downloaded and the cache is not used. The number of posts that were
+// written is returned.
+func (f *Fetcher) ExportAuthorFeed(
+	ctx context.Context,
+	actor string,
+	w io.Writer,
+) (int, error) {
+	var line bytes.Buffer
+	count := 0
+	cursor := ""
+	for {
+		params := url.Values{
+			"actor": {actor},
+			"limit": {fmt.Sprint(authorFeedLimit)},
+		}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		var page struct {
+			Cursor string            `json:"cursor"`
+			Feed   []json.RawMessage `json:"feed"`
+		}
+		err := f.xrpcQuery(ctx, "app.bsky.feed.getAuthorFeed", params, &page)
+		if err != nil {
+			return count, err
+		}
+
+		for _, post := range page.Feed {
+			line.Reset()
+			if err = json.Compact(&line, post); err != nil {
+				return count, err
+			}
+
+			line.WriteByte('\n')
+			if _, err = w.Write(line.Bytes()); err != nil {
+				return count, err
+			}
+
+			count++
+		}
+
+		if page.Cursor == "" || len(page.Feed) == 0 {
+			return count, nil
+		}
+
+		cursor = page.Cursor
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"encoding/xml"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// xmlNamespace is the namespace that is bound to the xml prefix.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// Extension is an element of an RSS document that is not otherwise
+// recognized, such as an element of an RSS extension namespace like
+// atom:link or dc:creator. Extension elements are kept when a feed is
+// decoded so that they are written back out unchanged.
+type Extension struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	InnerXML string     `xml:",innerxml"`
+
+	// Namespace is the namespace of the element when the prefix of the
+	// element is declared by the rss element of the document. XMLName then
+	// contains the prefixed name of the element.
+	Namespace string `xml:"-"`
+}
+
+// UnmarshalXML decodes an RSS document. The encoding/xml package replaces
+// the prefixes of the names of elements and attributes with the namespaces
+// that the prefixes are bound to. The names of the extension elements and
+// their attributes are changed back to the prefixes that they were written
+// with so that the elements are written back out using the same prefixes.
+func (r *RSS) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type document RSS
+	var doc document
+	if err := d.DecodeElement(&doc, &start); err != nil {
+		return err
+	}
+
+	*r = RSS(doc)
+	prefixes := map[string]string{xmlNamespace: "xml"}
+	attrs := make([]xml.Attr, 0, len(r.Attrs))
+	for _, attr := range r.Attrs {
+		if attr.Name.Space == "xmlns" {
+			prefixes[attr.Value] = attr.Name.Local
+			if attr.Name.Local == "media" && attr.Value == MediaRSSNamespace {
+				r.XMLNSMedia = attr.Value
+				continue
+			}
+
+			if attr.Name.Local == "atom" && attr.Value == AtomNamespace {
+				r.XMLNSAtom = attr.Value
+				continue
+			}
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	r.Attrs = prefixAttrs(attrs, prefixes)
+	for i := range r.Channel.Extensions {
+		r.Channel.Extensions[i].usePrefixes(prefixes)
+	}
+
+	for i := range r.Channel.Items {
+		for j := range r.Channel.Items[i].Extensions {
+			r.Channel.Items[i].Extensions[j].usePrefixes(prefixes)
+		}
+	}
+
+	return nil
+}
+
+// MarshalXML encodes an RSS document. The prefixes of the extension
+// elements are declared on the rss element if they are not declared
+// already, which happens when items are merged from a document that
+// declared different namespaces.
+func (r RSS) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type document RSS
+	doc := document(r)
+	declared := map[string]bool{
+		"media": r.XMLNSMedia != "",
+		"atom":  r.XMLNSAtom != "",
+	}
+	for _, attr := range r.Attrs {
+		if prefix, ok := strings.CutPrefix(attr.Name.Local, "xmlns:"); ok {
+			declared[prefix] = true
+		}
+	}
+
+	declare := func(extensions []Extension) {
+		for _, ext := range extensions {
+			prefix, _, ok := strings.Cut(ext.XMLName.Local, ":")
+			if !ok || ext.Namespace == "" || declared[prefix] {
+				continue
+			}
+
+			declared[prefix] = true
+			doc.Attrs = append(slices.Clip(doc.Attrs), xml.Attr{
+				Name:  xml.Name{Local: "xmlns:" + prefix},
+				Value: ext.Namespace,
+			})
+		}
+	}
+	declare(r.Channel.Extensions)
+	for _, item := range r.Channel.Items {
+		declare(item.Extensions)
+	}
+
+	start.Name = xml.Name{Local: "rss"}
+	return e.EncodeElement(doc, start)
+}
+
+// MarshalXML encodes an item of an RSS document. Descriptions that contain
+// HTML markup are written as CDATA sections so that the markup does not
+// need to be escaped.
+func (i Item) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type item Item
+	return e.EncodeElement(struct {
+		Title       string      `xml:"title,omitempty"`
+		Link        string      `xml:"link"`
+		Description description `xml:"description"`
+		item
+	}{i.Title, i.Link, description(i.Description), item(i)}, start)
+}
+
+// description is the description of an item that is written as a CDATA
+// section if it contains HTML markup.
+type description string
+
+func (s description) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !strings.Contains(string(s), "<") {
+		return e.EncodeElement(string(s), start)
+	}
+
+	return e.EncodeElement(struct {
+		Value string `xml:",cdata"`
+	}{string(s)}, start)
+}
+
+// UnmarshalXML decodes the channel of an RSS document. The elements of the
+// channel are matched by their names without a namespace, so that extension
+// elements such as atom:link do not replace the link of the channel.
+func (c *Channel) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return decodeChildren(d, &c.Extensions, func(name string) any {
+		switch name {
+		case "description":
+			return &c.Description
+		case "link":
+			return &c.Link
+		case "title":
+			return &c.Title
+		case "language":
+			return &c.Language
+		case "lastBuildDate":
+			return &c.LastBuildDate
+		case "generator":
+			return &c.Generator
+		case "item":
+			c.Items = append(c.Items, Item{})
+			return &c.Items[len(c.Items)-1]
+		}
+
+		return nil
+	})
+}
+
+// UnmarshalXML decodes an item of an RSS document. The elements of the item
+// are matched by their names without a namespace, like the elements of the
+// channel.
+func (i *Item) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return decodeChildren(d, &i.Extensions, func(name string) any {
+		switch name {
+		case "title":
+			return &i.Title
+		case "link":
+			return &i.Link
+		case "description":
+			return &i.Description
+		case "pubDate":
+			return &i.PubDate
+		case "guid":
+			return &i.GUID
+		case "enclosure":
+			i.Enclosure = &Enclosure{}
+			return i.Enclosure
+		}
+
+		return nil
+	})
+}
+
+// decodeChildren decodes the child elements of the element that is being
+// decoded by d. field returns the value that a child element that does not
+// have a namespace is decoded into, or nil if the element is not
+// recognized. The elements that are not recognized are decoded as
+// extensions.
+func decodeChildren(
+	d *xml.Decoder,
+	extensions *[]Extension,
+	field func(name string) any,
+) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			var v any
+			if t.Name.Space == "" {
+				v = field(t.Name.Local)
+			}
+
+			if v == nil {
+				*extensions = append(*extensions, Extension{})
+				v = &(*extensions)[len(*extensions)-1]
+			}
+
+			if err = d.DecodeElement(v, &t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// usePrefixes changes the names of the element and its attributes to use
+// the prefixes that are declared by the rss element or by the element
+// itself. prefixes maps the namespaces that are declared by the rss
+// element to their prefixes.
+func (e *Extension) usePrefixes(prefixes map[string]string) {
+	local := prefixes
+	for _, attr := range e.Attrs {
+		if attr.Name.Space == "xmlns" {
+			local = maps.Clone(local)
+			local[attr.Value] = attr.Name.Local
+		}
+	}
+
+	if space := e.XMLName.Space; space != "" {
+		if prefix, ok := local[space]; ok {
+			if prefixes[space] == prefix {
+				e.Namespace = space
+			}
+
+			e.XMLName = xml.Name{Local: prefix + ":" + e.XMLName.Local}
+		} else if slices.Contains(e.Attrs, xml.Attr{
+			Name:  xml.Name{Local: "xmlns"},
+			Value: space,
+		}) {
+			// The element declares its namespace as the default
+			// namespace, and the declaration is written back out with
+			// the other attributes.
+			e.XMLName.Space = ""
+		}
+	}
+
+	e.Attrs = prefixAttrs(e.Attrs, local)
+}
+
+// prefixAttrs returns a copy of attrs whose names use the prefixes that
+// prefixes maps the namespaces of the attributes to. Attributes that
+// declare a prefix are named xmlns:<prefix>.
+func prefixAttrs(attrs []xml.Attr, prefixes map[string]string) []xml.Attr {
+	result := make([]xml.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		switch space := attr.Name.Space; {
+		case space == "xmlns":
+			attr.Name = xml.Name{Local: "xmlns:" + attr.Name.Local}
+		case space != "":
+			if prefix, ok := prefixes[space]; ok {
+				attr.Name = xml.Name{Local: prefix + ":" + attr.Name.Local}
+			}
+		}
+
+		result = append(result, attr)
+	}
+
+	return result
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package feed implements reading Bluesky feeds. A feed is either downloaded
+// from the RSS feed that Bluesky publishes for an account, or it is
+// synthesized from the post records that are returned by the AT Protocol
+// app.bsky.feed.getAuthorFeed endpoint.
+//
+// Both sources produce the same RSS document structure. The items of a feed
+// that was synthesized from post records additionally keep a reference to
+// the records so that the richer data of the posts, such as facets and
+// embedded media, can be used when the feed is transformed and written.
+package feed
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MediaRSSNamespace is the XML namespace of the Media RSS elements.
+const MediaRSSNamespace = "http://search.yahoo.com/mrss/"
+
+// AtomNamespace is the XML namespace of the Atom elements.
+const AtomNamespace = "http://www.w3.org/2005/Atom"
+
+// DublinCoreNamespace is the XML namespace of the Dublin Core elements.
+const DublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+
+// ContentNamespace is the XML namespace of the RSS content module, which
+// defines the content:encoded element.
+const ContentNamespace = "http://purl.org/rss/1.0/modules/content/"
+
+// BlueskyNamespace is the XML namespace of the elements that are written for
+// the data of Bluesky posts that RSS does not have an element for, such as
+// the engagement counts of the posts.
+const BlueskyNamespace = "https://github.com/mfcollins3/hugoify-bluesky-rss-feed/ns/bluesky"
+
+// RSS is an RSS 2.0 document.
+type RSS struct {
+	XMLName    xml.Name `xml:"rss"`
+	Version    string   `xml:"version,attr"`
+	XMLNSMedia string   `xml:"xmlns:media,attr,omitempty"`
+	XMLNSAtom  string   `xml:"xmlns:atom,attr,omitempty"`
+	Channel    Channel  `xml:"channel"`
+
+	// Attrs are the other attributes of the rss element, such as the
+	// declarations of the namespaces of extension elements.
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+type Channel struct {
+	Description   string    `xml:"description"`
+	Link          string    `xml:"link"`
+	Title         string    `xml:"title"`
+	Language      string    `xml:"language,omitempty"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Generator     string    `xml:"generator,omitempty"`
+	SelfLink      *AtomLink `xml:"atom:link,omitempty"`
+	Items         []Item    `xml:"item"`
+
+	// Pinned is the AT URI of the post that the account pinned to its
+	// profile, or an empty string if the account did not pin a post or the
+	// pinned post is not known.
+	Pinned string `xml:"-"`
+
+	// Extensions are the child elements of the channel that are not
+	// otherwise recognized.
+	Extensions []Extension `xml:",any"`
+}
+
+// AtomLink is an atom:link element. RSS feeds use an atom:link element with
+// the self relation to identify the location that the feed is published
+// at.
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// Item is a post in the feed. The fields that are not part of the RSS
+// document are populated when the feed is downloaded and transformed.
+type Item struct {
+	Title       string `xml:"title,omitempty"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	GUID        GUID   `xml:"guid"`
+
+	Enclosure    *Enclosure     `xml:"enclosure,omitempty"`
+	MediaContent []MediaContent `xml:"media:content,omitempty"`
+
+	// Extensions are the child elements of the item that are not otherwise
+	// recognized.
+	Extensions []Extension `xml:",any"`
+
+	// Published is the parsed value of PubDate.
+	Published time.Time `xml:"-"`
+
+	// Text is the text of the item without any HTML markup. It is only set
+	// when IsHTML is true.
+	Text string `xml:"-"`
+
+	// Markdown is the text of the item converted into Markdown, or an empty
+	// string if the item was not fetched from the AT Protocol API.
+	Markdown string `xml:"-"`
+
+	// Summary is a shortened version of the text of the item that is used
+	// as an excerpt, or an empty string if the item does not have one.
+	Summary string `xml:"-"`
+
+	// IsHTML reports whether Description is an HTML fragment.
+	IsHTML bool `xml:"-"`
+
+	// Media are the images and videos that are attached to the post.
+	Media []Media `xml:"-"`
+
+	// Author is the account that wrote the post, or nil if the author is
+	// not known. The author of an item that is read from an RSS feed only
+	// has a handle and a display name.
+	Author *ProfileViewBasic `xml:"-"`
+
+	// Languages are the BCP 47 language tags of the post, such as en or
+	// pt-BR. The languages are only known for the items that are
+	// synthesized from post records.
+	Languages []string `xml:"-"`
+
+	// Labels are the values of the labels of the post, such as nudity or
+	// graphic-media. The labels are only known for the items that are
+	// synthesized from post records.
+	Labels []string `xml:"-"`
+
+	// Quote is the post that is quoted by the post, or nil if the post does
+	// not quote another post.
+	Quote *Quote `xml:"-"`
+
+	// LinkCard is the preview of the external link that is attached to the
+	// post, or nil if the post does not have an external link.
+	LinkCard *LinkCard `xml:"-"`
+
+	// Engagement contains the reply, repost, like, and quote counts of the
+	// post, or nil if the counts are not known. The counts are only known
+	// for the items that are synthesized from post records, unless they
+	// are downloaded using RefreshEngagement.
+	Engagement *Engagement `xml:"-"`
+
+	// Pinned reports whether the post is the post that the author pinned to
+	// their profile.
+	Pinned bool `xml:"-"`
+
+	// Deleted reports whether the post was deleted on Bluesky after it was
+	// written to the previous output.
+	Deleted bool `xml:"-"`
+
+	// Draft reports whether the post is a draft that Hugo does not publish
+	// yet, such as a post whose date is in the future.
+	Draft bool `xml:"-"`
+
+	// Post is the post record that the item was synthesized from, or nil if
+	// the item was read from an RSS feed.
+	Post *FeedViewPost `xml:"-"`
+}
+
+// PlainText returns the text of the item without any HTML markup.
+func (i Item) PlainText() string {
+	if i.IsHTML {
+		return i.Text
+	}
+
+	return i.Description
+}
+
+// MarkdownText returns the text of the item as Markdown. The facets of
+// posts that were fetched from the AT Protocol API are converted into
+// links, and the text of other posts is escaped.
+func (i Item) MarkdownText() string {
+	if i.Markdown != "" {
+		return i.Markdown
+	}
+
+	return EscapeMarkdown(i.PlainText())
+}
+
+// HTML returns the description of the item as an HTML fragment.
+func (i Item) HTML() string {
+	if i.IsHTML {
+		return i.Description
+	}
+
+	return TextToHTML(i.Description)
+}
+
+// IsReply reports whether the item is a reply to another post. Replies can
+// only be detected for posts that were fetched from the AT Protocol API.
+func (i Item) IsReply() bool {
+	return i.Post != nil && len(i.Post.Post.Record.Reply) > 0
+}
+
+// URI returns the AT URI of the post of the item. The AT URI is read from
+// the post that was fetched from the AT Protocol API or from the GUID of
+// the item. An empty string is returned if the AT URI is not known.
+func (i Item) URI() string {
+	if i.Post != nil {
+		return i.Post.Post.URI
+	}
+
+	if strings.HasPrefix(i.GUID.Value, "at://") {
+		return i.GUID.Value
+	}
+
+	return ""
+}
+
+// IsRepost reports whether the item is a post by another account that was
+// reposted by the author of the feed.
+func (i Item) IsRepost() bool {
+	return i.Post != nil &&
+		i.Post.Reason != nil &&
+		i.Post.Reason.Type == reasonRepost
+}
+
+// hashtagPattern matches the hashtags in the text of posts that do not have
+// facets, such as the posts in the Bluesky RSS feed.
+var hashtagPattern = regexp.MustCompile(`(?:^|[\s(])#([\pL\pN_]+)`)
+
+// Hashtags returns the lowercase hashtags of the item without the leading #.
+// The hashtags are read from the facets of posts that were fetched from the
+// AT Protocol API and are parsed from the text of other posts.
+func (i Item) Hashtags() []string {
+	var tags []string
+	if i.Post != nil {
+		for _, f := range i.Post.Post.Record.Facets {
+			for _, feature := range f.Features {
+				if feature.Type == facetTag {
+					tags = append(tags, strings.ToLower(feature.Tag))
+				}
+			}
+		}
+
+		return tags
+	}
+
+	for _, match := range hashtagPattern.FindAllStringSubmatch(i.PlainText(), -1) {
+		tags = append(tags, strings.ToLower(match[1]))
+	}
+
+	return tags
+}
+
+type GUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// MediaContent is a Media RSS media:content element. encoding/xml does not
+// support writing namespace prefixes, so the prefix is part of the element
+// name and the namespace is declared on the rss element.
+type MediaContent struct {
+	URL       string          `xml:"url,attr"`
+	Type      string          `xml:"type,attr,omitempty"`
+	Medium    string          `xml:"medium,attr,omitempty"`
+	Width     int             `xml:"width,attr,omitempty"`
+	Height    int             `xml:"height,attr,omitempty"`
+	FileSize  int64           `xml:"fileSize,attr,omitempty"`
+	Thumbnail *MediaThumbnail `xml:"media:thumbnail,omitempty"`
+
+	// Description is the alternative text of the image or video.
+	Description *MediaDescription `xml:"media:description,omitempty"`
+}
+
+type MediaThumbnail struct {
+	URL string `xml:"url,attr"`
+}
+
+// MediaDescription is a Media RSS media:description element. Type is plain
+// or html.
+type MediaDescription struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
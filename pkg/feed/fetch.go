@@ -0,0 +1,667 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultRetries       = 3
+	DefaultRetryDelay    = time.Second
+	DefaultRetryMaxDelay = 30 * time.Second
+	DefaultTimeout       = 30 * time.Second
+)
+
+// DefaultMaxResponseSize is the default maximum size of the body of a
+// response in bytes.
+const DefaultMaxResponseSize = 32 << 20
+
+// DefaultUserAgent is the User-Agent header that identifies the requests
+// that are sent by a Fetcher that is returned by NewFetcher.
+const DefaultUserAgent = "hugoify-bluesky-rss-feed " +
+	"(+https://github.com/mfcollins3/hugoify-bluesky-rss-feed)"
+
+// ErrNotModified is returned by FetchRSS when the server reports that the
+// feed has not been modified since it was last downloaded.
+var ErrNotModified = errors.New("the feed has not been modified")
+
+// ParseError is returned when a feed or the response of an XRPC endpoint
+// was downloaded but cannot be parsed, so that callers can tell a feed that
+// is not valid from a feed that cannot be downloaded.
+type ParseError struct {
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ResponseTooLargeError is returned when the body of a response is larger
+// than the MaxResponseSize of the Fetcher.
+type ResponseTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf(
+		"the response of %s is larger than the maximum response size of "+
+			"%d bytes",
+		e.URL,
+		e.Limit,
+	)
+}
+
+// Fetcher sends the HTTP requests that are used to download the feeds.
+// Requests that fail because of a temporary network error, because the
+// server is rate limiting the client, or because of a server error are
+// retried using exponential backoff with jitter. The Fetcher also honors
+// the RateLimit-* headers of the responses and holds back the requests to a
+// host whose rate limit is exhausted until the limit resets.
+type Fetcher struct {
+	// Client is the HTTP client that sends the requests. If Client is nil,
+	// http.DefaultClient is used. NewClient returns a client that can
+	// trust additional certificate authorities.
+	Client *http.Client
+
+	// Timeout limits how long each attempt of a request can take, including
+	// reading the body of the response. If Timeout is zero, the requests
+	// only end when their context is canceled.
+	Timeout time.Duration
+
+	// MaxResponseSize is the maximum size of the body of a response in
+	// bytes, after the body has been decoded, which protects the process
+	// from oversized responses and from compressed responses that expand
+	// into more data than expected. Reading a larger body fails with a
+	// ResponseTooLargeError. If MaxResponseSize is zero, the size of the
+	// responses is not limited.
+	MaxResponseSize int64
+
+	// UserAgent is the value of the User-Agent header of the requests. If
+	// UserAgent is empty, the default User-Agent of net/http is sent.
+	UserAgent string
+
+	// Header contains additional headers that are sent with every request.
+	// A header that is already set on a request is not replaced.
+	Header http.Header
+
+	// Retries is the number of times that a failed request is retried.
+	Retries int
+
+	// RetryDelay is the delay before the first retry. The delay doubles
+	// with each retry.
+	RetryDelay time.Duration
+
+	// RetryMaxDelay is the maximum delay between retries.
+	RetryMaxDelay time.Duration
+
+	// RateLimitWait is how long a request waits for an exhausted rate
+	// limit to reset. A request that would have to wait longer fails
+	// instead. If RateLimitWait is zero, requests wait until the rate
+	// limit resets.
+	RateLimitWait time.Duration
+
+	// Session authenticates the AT Protocol XRPC requests. If Session is
+	// nil, the requests are sent to the public AppView service without
+	// authentication.
+	Session *Session
+
+	// AppViewURL is the base URL of the AppView service that the XRPC
+	// queries are sent to when the Fetcher does not have a session. If
+	// AppViewURL is empty, the public Bluesky AppView is used.
+	AppViewURL string
+
+	// PDSURL is the base URL of the service that CreateSession signs in to.
+	// If PDSURL is empty, the Bluesky entryway is used, which signs in to
+	// the accounts that are hosted by Bluesky.
+	PDSURL string
+
+	// Cache stores the author feeds that are downloaded by FetchAuthorFeed
+	// so that only the pages with new posts are downloaded again. If Cache
+	// is nil, every page is downloaded.
+	Cache *Cache
+
+	// Logger logs the requests that are retried, that are redirected, or
+	// that wait for a rate limit to reset. If Logger is nil,
+	// slog.Default() is used.
+	Logger *slog.Logger
+
+	limits rateLimits
+}
+
+// NewFetcher returns a Fetcher that uses the default retry policy.
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		Client:          http.DefaultClient,
+		Retries:         DefaultRetries,
+		RetryDelay:      DefaultRetryDelay,
+		RetryMaxDelay:   DefaultRetryMaxDelay,
+		RateLimitWait:   DefaultRateLimitWait,
+		Timeout:         DefaultTimeout,
+		MaxResponseSize: DefaultMaxResponseSize,
+		UserAgent:       DefaultUserAgent,
+	}
+}
+
+// Validators are the cache validators that the server returned for a feed.
+// They are used to make a conditional request when the feed is downloaded
+// again.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// FetchRSS downloads and parses the RSS feed at url. The validators in prev
+// are used to make a conditional request, and the validators returned by
+// the server are returned with the feed.
+func (f *Fetcher) FetchRSS(
+	ctx context.Context,
+	url string,
+	prev Validators,
+) (RSS, Validators, error) {
+	body, validators, err := f.OpenRSS(ctx, url, prev)
+	if err != nil {
+		return RSS{}, prev, err
+	}
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var feed RSS
+	decoder := xml.NewDecoder(body)
+	if err = decoder.Decode(&feed); err != nil {
+		// A feed that is too large is not a feed that is not valid.
+		var tooLarge *ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			return RSS{}, prev, tooLarge
+		}
+
+		return RSS{}, prev, &ParseError{
+			Err: fmt.Errorf("failed to parse the RSS feed: %w", err),
+		}
+	}
+
+	for i := range feed.Channel.Items {
+		item := &feed.Channel.Items[i]
+		item.Author = LinkAuthor(item.Link, feed.Channel)
+		if item.Author != nil {
+			item.Author.DID = AuthorityDID(item.GUID.Value)
+		}
+	}
+
+	return feed, validators, nil
+}
+
+// OpenRSS downloads the RSS feed at url like FetchRSS, but returns the body
+// of the response without parsing it so that the feed can be read as a
+// stream. An *HTMLPageError is returned if the server responds with an
+// HTML page instead of the feed. Local feeds, as described by IsLocalURL,
+// are read instead of downloaded. The caller is responsible for closing
+// the body.
+func (f *Fetcher) OpenRSS(
+	ctx context.Context,
+	url string,
+	prev Validators,
+) (io.ReadCloser, Validators, error) {
+	if IsLocalURL(url) {
+		return openLocal(url, prev)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, prev, err
+	}
+
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+
+	resp, err := f.Do(req)
+	if err != nil {
+		return nil, prev, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		_ = resp.Body.Close()
+		return nil, prev, ErrNotModified
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		// Bot protection services return their challenge pages with an
+		// error status code, which alone does not explain the failure.
+		var page *HTMLPageError
+		err = checkHTMLPage(resp, "a feed")
+		_ = resp.Body.Close()
+		if errors.As(err, &page) && page.Challenge {
+			return nil, prev, page
+		}
+
+		return nil, prev, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	if err = checkHTMLPage(resp, "a feed"); err != nil {
+		_ = resp.Body.Close()
+		return nil, prev, err
+	}
+
+	return resp.Body, Validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
+
+// Do adds the User-Agent and the additional headers of the Fetcher to req,
+// sends req, and returns the response with its body decoded if the body
+// was compressed using gzip or deflate. Each attempt to send the request
+// is limited by the timeout of the Fetcher, and the request is not retried
+// after the context of req is canceled. A *RateLimitError is returned if
+// the server still responds with status code 429 after the request was
+// retried. The caller is responsible for closing the body of the response.
+func (f *Fetcher) Do(req *http.Request) (*http.Response, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	for name, values := range f.Header {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = values
+		}
+	}
+
+	if f.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req.Body = body
+		}
+
+		if err := f.waitForRateLimit(req); err != nil {
+			return nil, err
+		}
+
+		attemptReq, cancel := f.withTimeout(req)
+		resp, err := client.Do(attemptReq)
+		if err == nil {
+			f.limits.observe(req.URL.Host, resp, time.Now())
+		}
+
+		if attempt >= f.Retries || req.Context().Err() != nil ||
+			!shouldRetry(resp, err) {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+
+			if resp.StatusCode == http.StatusTooManyRequests {
+				_, _ = io.Copy(io.Discard, resp.Body)
+				_ = resp.Body.Close()
+				cancel()
+				return nil, newRateLimitError(resp, time.Now())
+			}
+
+			// A feed URL that silently redirects to an HTML page, such as
+			// the profile of a handle that does not exist, would otherwise
+			// only be noticed when the response cannot be parsed.
+			if final := resp.Request.URL; final.String() != req.URL.String() {
+				f.logger().Info(
+					"The request was redirected.",
+					"url", req.URL.Redacted(),
+					"location", final.Redacted(),
+				)
+			}
+
+			resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			if err = decodeBody(resp); err != nil {
+				_ = resp.Body.Close()
+				return nil, err
+			}
+
+			if err = f.limitBody(req, resp); err != nil {
+				_ = resp.Body.Close()
+				return nil, err
+			}
+
+			return resp, nil
+		}
+
+		delay := f.backoff(attempt, resp)
+		logger := f.logger()
+		if err != nil {
+			logger.Warn(
+				"The request failed. Retrying.",
+				"url", req.URL.Redacted(),
+				"error", err,
+				"delay", delay,
+			)
+		} else {
+			logger.Warn(
+				"The request failed. Retrying.",
+				"url", req.URL.Redacted(),
+				"status", resp.StatusCode,
+				"delay", delay,
+			)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		cancel()
+		if err = sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitForRateLimit waits until req can be sent without exceeding the rate
+// limit of its host. A *RateLimitError is returned if the rate limit does
+// not reset within the RateLimitWait of the Fetcher, and an error is
+// returned if the context of req is canceled.
+func (f *Fetcher) waitForRateLimit(req *http.Request) error {
+	now := time.Now()
+	delay := f.limits.reserve(req.URL.Host, now)
+	if delay <= 0 {
+		return nil
+	}
+
+	if f.RateLimitWait > 0 && delay > f.RateLimitWait {
+		return &RateLimitError{Host: req.URL.Host, Reset: now.Add(delay)}
+	}
+
+	f.logger().Warn(
+		"The rate limit has been reached. Waiting for it to reset.",
+		"host", req.URL.Host,
+		"delay", delay,
+	)
+	return sleep(req.Context(), delay)
+}
+
+// logger returns the Logger of the Fetcher or slog.Default().
+func (f *Fetcher) logger() *slog.Logger {
+	if f.Logger == nil {
+		return slog.Default()
+	}
+
+	return f.Logger
+}
+
+// withTimeout returns a copy of req whose context expires after the timeout
+// of the Fetcher and the function that releases the context.
+func (f *Fetcher) withTimeout(
+	req *http.Request,
+) (*http.Request, context.CancelFunc) {
+	if f.Timeout <= 0 {
+		return req, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), f.Timeout)
+	return req.WithContext(ctx), cancel
+}
+
+// limitBody limits the body of resp to the MaxResponseSize of the Fetcher.
+// An error is returned right away if the Content-Length header of the
+// response is larger than the limit.
+func (f *Fetcher) limitBody(req *http.Request, resp *http.Response) error {
+	if f.MaxResponseSize <= 0 {
+		return nil
+	}
+
+	tooLarge := &ResponseTooLargeError{
+		URL:   req.URL.Redacted(),
+		Limit: f.MaxResponseSize,
+	}
+	if resp.ContentLength > f.MaxResponseSize {
+		return tooLarge
+	}
+
+	resp.Body = &limitedBody{
+		Reader: io.LimitReader(resp.Body, f.MaxResponseSize+1),
+		body:   resp.Body,
+		err:    tooLarge,
+	}
+	return nil
+}
+
+// limitedBody is the body of a response that fails with err when more than
+// the limit of err is read. Reader is limited to one more byte than the
+// limit so that a body that is exactly as large as the limit can be read.
+type limitedBody struct {
+	io.Reader
+	body io.ReadCloser
+	err  *ResponseTooLargeError
+	read int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += int64(n)
+	if b.read > b.err.Limit {
+		return n - int(b.read-b.err.Limit), b.err
+	}
+
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// cancelBody is the body of a response that releases the context of the
+// request when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// sleep waits for d to elapse. An error is returned if ctx is canceled
+// before then.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// shouldRetry reports whether a request that returned resp and err should be
+// retried. Only the requests that failed because of a temporary network
+// problem, that were rate limited, or that returned a server error are
+// retried. The other errors, such as an unsupported URL scheme, a malformed
+// URL, or a certificate that cannot be verified, would fail again.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return isTemporary(err)
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= http.StatusInternalServerError
+}
+
+// isTemporary reports whether err is a network error that can succeed when
+// the request is retried: a timeout, a connection that was refused or
+// reset, a connection that was closed before the response was received,
+// or a temporary DNS failure.
+func isTemporary(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// backoff returns how long to wait before retrying a failed request. The
+// delay doubles with each attempt up to the maximum delay, and a random
+// jitter of up to half of the delay is subtracted so that clients do not
+// retry in lockstep. If the server sent a Retry-After header, the delay that
+// the server requested is used instead, limited to the maximum delay.
+func (f *Fetcher) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := retryAfter(resp); ok {
+			return min(delay, f.RetryMaxDelay)
+		}
+	}
+
+	delay := f.RetryDelay << attempt
+	if delay <= 0 || delay > f.RetryMaxDelay {
+		delay = f.RetryMaxDelay
+	}
+
+	if half := int64(delay / 2); half > 0 {
+		delay -= time.Duration(rand.Int64N(half))
+	}
+
+	return delay
+}
+
+// retryAfter parses the Retry-After header of resp, which is either a number
+// of seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
+
+// Get sends a GET request for url.
+func (f *Fetcher) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", url, err)
+	}
+
+	return f.Do(req)
+}
+
+// Download downloads u into the file target if the file does not already
+// exist and returns the size of the file. The file is written to a
+// temporary file first so that a failed download does not leave a partial
+// file behind.
+func (f *Fetcher) Download(
+	ctx context.Context,
+	u string,
+	target string,
+) (int64, error) {
+	if info, err := os.Stat(target); err == nil {
+		return info.Size(), nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+
+	resp, err := f.Get(ctx, u)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(target), ".download-*")
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	size, err := io.Copy(file, resp.Body)
+	if err != nil {
+		_ = file.Close()
+		return 0, err
+	}
+
+	if err = file.Close(); err != nil {
+		return 0, err
+	}
+
+	return size, os.Rename(file.Name(), target)
+}
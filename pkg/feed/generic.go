@@ -0,0 +1,293 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FetchFeed downloads and parses the RSS 2.0, Atom, or JSON Feed feed at
+// url, which can be any feed and is not required to be published by
+// Bluesky. The validators in prev are used to make a conditional request
+// like FetchRSS. The feed is returned as an RSS feed; see ParseFeed.
+func (f *Fetcher) FetchFeed(
+	ctx context.Context,
+	url string,
+	prev Validators,
+) (RSS, Validators, error) {
+	body, validators, err := f.OpenRSS(ctx, url, prev)
+	if err != nil {
+		return RSS{}, prev, err
+	}
+
+	defer func() {
+		_ = body.Close()
+	}()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return RSS{}, prev, fmt.Errorf("failed to read the feed: %w", err)
+	}
+
+	feed, err := ParseFeed(data)
+	if err != nil {
+		return RSS{}, prev, err
+	}
+
+	return feed, validators, nil
+}
+
+// ParseFeed parses an RSS 2.0, Atom, or JSON Feed feed and normalizes it
+// into an RSS feed whose items are transformed like the items of a Bluesky
+// feed. The
+// descriptions of the items are treated as HTML, which is what most feeds
+// publish, and the full content of an item is preferred over its
+// description: the content:encoded element of an RSS item or the content
+// element of an Atom entry is the description of the item, and the
+// description or summary becomes the summary of the item. The entries of
+// Atom and JSON Feed feeds are dated by when they were published, or by
+// when they were last updated if the publication date is not known.
+func ParseFeed(data []byte) (RSS, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var f jsonFeed
+		err := json.Unmarshal(data, &f)
+		if err == nil &&
+			!strings.HasPrefix(f.Version, "https://jsonfeed.org/version/") {
+			err = errors.New("the document is not a JSON Feed")
+		}
+
+		if err != nil {
+			return RSS{}, &ParseError{
+				Err: fmt.Errorf("failed to parse the feed: %w", err),
+			}
+		}
+
+		return f.rss(), nil
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return RSS{}, &ParseError{
+			Err: fmt.Errorf("failed to parse the feed: %w", err),
+		}
+	}
+
+	var feed RSS
+	switch {
+	case root.Local == "rss":
+		err = xml.Unmarshal(data, &feed)
+		normalizeRSS(&feed)
+	case root.Local == "feed" && root.Space == AtomNamespace:
+		var atom atomFeed
+		err = xml.Unmarshal(data, &atom)
+		feed = atom.rss()
+	default:
+		err = fmt.Errorf("the %s element is not an RSS or Atom feed", root.Local)
+	}
+
+	if err != nil {
+		return RSS{}, &ParseError{
+			Err: fmt.Errorf("failed to parse the feed: %w", err),
+		}
+	}
+
+	return feed, nil
+}
+
+// rootElement returns the name of the root element of the XML document in
+// data.
+func rootElement(data []byte) (xml.Name, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return xml.Name{}, errors.New("the document is empty")
+		}
+
+		if err != nil {
+			return xml.Name{}, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+// normalizeRSS normalizes the items of an RSS feed that was not published
+// by Bluesky.
+func normalizeRSS(f *RSS) {
+	for i := range f.Channel.Items {
+		item := &f.Channel.Items[i]
+		j := slices.IndexFunc(item.Extensions, func(ext Extension) bool {
+			return ext.Namespace == ContentNamespace &&
+				localName(ext.XMLName.Local) == "encoded"
+		})
+		if j >= 0 {
+			var content string
+			wrapped := "<content>" + item.Extensions[j].InnerXML + "</content>"
+			if xml.Unmarshal([]byte(wrapped), &content) == nil {
+				item.Summary = htmlText(item.Description)
+				item.Description = content
+				item.Extensions = slices.Delete(item.Extensions, j, j+1)
+			}
+		}
+
+		item.Text = htmlText(item.Description)
+		item.IsHTML = true
+		if item.GUID.Value == "" {
+			item.GUID = GUID{IsPermaLink: "true", Value: item.Link}
+		}
+
+		if item.Enclosure != nil {
+			size, _ := strconv.ParseInt(item.Enclosure.Length, 10, 64)
+			item.Media = []Media{{
+				Medium:   mediumOf(item.Enclosure.Type),
+				URL:      item.Enclosure.URL,
+				MIMEType: item.Enclosure.Type,
+				Size:     size,
+			}}
+			item.Enclosure = nil
+		}
+	}
+}
+
+// atomFeed is the part of an Atom feed that is normalized into an RSS feed.
+type atomFeed struct {
+	Lang     string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	ID       string      `xml:"id"`
+	Title    atomText    `xml:"title"`
+	Subtitle atomText    `xml:"subtitle"`
+	Updated  string      `xml:"updated"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     atomText   `xml:"title"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []AtomLink `xml:"link"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+}
+
+// atomText is an Atom text construct. The content of an xhtml construct is
+// markup, so it is read as XML instead of as text.
+type atomText struct {
+	Type     string `xml:"type,attr"`
+	Value    string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+// html returns the text construct as an HTML fragment.
+func (t atomText) html() string {
+	switch t.Type {
+	case "html":
+		return strings.TrimSpace(t.Value)
+	case "xhtml":
+		return strings.TrimSpace(t.InnerXML)
+	default:
+		return html.EscapeString(strings.TrimSpace(t.Value))
+	}
+}
+
+// text returns the text of the text construct without any markup.
+func (t atomText) text() string {
+	if t.Type == "html" || t.Type == "xhtml" {
+		return htmlText(t.html())
+	}
+
+	return strings.TrimSpace(t.Value)
+}
+
+// atomLink returns the URL of the link in links that has the relation rel.
+// A link without a relation is an alternate link.
+func atomLink(links []AtomLink, rel string) string {
+	for _, link := range links {
+		if cmp.Or(link.Rel, "alternate") == rel {
+			return link.Href
+		}
+	}
+
+	return ""
+}
+
+// rss converts the Atom feed into an RSS feed.
+func (f atomFeed) rss() RSS {
+	feed := RSS{
+		Version: "2.0",
+		Channel: Channel{
+			Title:         f.Title.text(),
+			Link:          cmp.Or(atomLink(f.Links, "alternate"), f.ID),
+			Description:   f.Subtitle.text(),
+			Language:      f.Lang,
+			LastBuildDate: f.Updated,
+			Items:         make([]Item, 0, len(f.Entries)),
+		},
+	}
+	for _, entry := range f.Entries {
+		link := atomLink(entry.Links, "alternate")
+		item := Item{
+			Title:       entry.Title.text(),
+			Link:        link,
+			Description: entry.Content.html(),
+			PubDate:     cmp.Or(entry.Published, entry.Updated),
+			GUID: GUID{
+				IsPermaLink: "false",
+				Value:       cmp.Or(entry.ID, link),
+			},
+			IsHTML: true,
+		}
+		if item.Description == "" {
+			item.Description = entry.Summary.html()
+		} else {
+			item.Summary = entry.Summary.text()
+		}
+
+		item.Text = htmlText(item.Description)
+		for _, l := range entry.Links {
+			if l.Rel == "enclosure" {
+				item.Media = append(item.Media, Media{
+					Medium:   mediumOf(l.Type),
+					URL:      l.Href,
+					MIMEType: l.Type,
+				})
+			}
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	return feed
+}
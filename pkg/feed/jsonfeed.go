@@ -0,0 +1,120 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"cmp"
+	"encoding/json"
+	"html"
+	"strings"
+)
+
+// jsonFeed is the part of a JSON Feed document that is normalized into an
+// RSS feed. Version 1.0 of the specification names the author of the feed
+// and of the items using author instead of authors.
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Language    string         `json:"language"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            json.RawMessage      `json:"id"`
+	URL           string               `json:"url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Language      string               `json:"language"`
+	Attachments   []jsonFeedAttachment `json:"attachments"`
+}
+
+type jsonFeedAttachment struct {
+	URL      string `json:"url"`
+	MIMEType string `json:"mime_type"`
+	Size     int64  `json:"size_in_bytes"`
+}
+
+// rss converts the JSON Feed into an RSS feed.
+func (f jsonFeed) rss() RSS {
+	feed := RSS{
+		Version: "2.0",
+		Channel: Channel{
+			Title:       f.Title,
+			Link:        cmp.Or(f.HomePageURL, f.FeedURL),
+			Description: f.Description,
+			Language:    f.Language,
+			Items:       make([]Item, 0, len(f.Items)),
+		},
+	}
+	for _, entry := range f.Items {
+		item := Item{
+			Title:       entry.Title,
+			Link:        entry.URL,
+			Description: entry.ContentHTML,
+			Summary:     entry.Summary,
+			PubDate:     cmp.Or(entry.DatePublished, entry.DateModified),
+			GUID: GUID{
+				IsPermaLink: "false",
+				Value:       cmp.Or(jsonFeedID(entry.ID), entry.URL),
+			},
+			IsHTML: true,
+		}
+		if item.Description == "" {
+			item.Description = html.EscapeString(entry.ContentText)
+		}
+
+		item.Text = htmlText(item.Description)
+		if entry.Language != "" {
+			item.Languages = []string{entry.Language}
+		}
+
+		for _, a := range entry.Attachments {
+			item.Media = append(item.Media, Media{
+				Medium:   mediumOf(a.MIMEType),
+				URL:      a.URL,
+				MIMEType: a.MIMEType,
+				Size:     a.Size,
+			})
+		}
+
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+
+	return feed
+}
+
+// jsonFeedID returns the id of a JSON Feed item. The specification requires
+// the id to be a string, but some feeds use numbers.
+func jsonFeedID(raw json.RawMessage) string {
+	var id string
+	if json.Unmarshal(raw, &id) == nil {
+		return id
+	}
+
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,77 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import "slices"
+
+// Label is a label that is applied to a post by the author of the post or
+// by a labeling service, such as a moderation service. A negated label
+// removes a label that was applied before.
+type Label struct {
+	Src string `json:"src"`
+	URI string `json:"uri"`
+	Val string `json:"val"`
+	Neg bool   `json:"neg,omitempty"`
+	Cts string `json:"cts"`
+}
+
+// SelfLabels are the labels that the author of a post applied to the post
+// record. Self-labels are used for content warnings.
+type SelfLabels struct {
+	Values []SelfLabel `json:"values"`
+}
+
+type SelfLabel struct {
+	Val string `json:"val"`
+}
+
+// PostLabels returns the values of the labels of the post, which include
+// the self-labels of the post record and the labels that were applied by
+// labeling services. Negated labels are not included.
+func PostLabels(post PostView) []string {
+	var values []string
+	add := func(value string) {
+		if value != "" && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	if post.Record.Labels != nil {
+		for _, label := range post.Record.Labels.Values {
+			add(label.Val)
+		}
+	}
+
+	negated := map[string]bool{}
+	for _, label := range post.Labels {
+		if label.Neg {
+			negated[label.Val] = true
+		}
+	}
+
+	for _, label := range post.Labels {
+		if !label.Neg && !negated[label.Val] {
+			add(label.Val)
+		}
+	}
+
+	return values
+}
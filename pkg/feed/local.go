@@ -0,0 +1,113 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// StdinURL is the URL of a feed that is read from standard input.
+const StdinURL = "-"
+
+// IsLocalURL reports whether the feed at rawURL is read from standard input
+// or from a file instead of being downloaded. Local feeds use StdinURL or a
+// file URL, such as file:///srv/feed.xml, or file:feed.xml for a path that
+// is relative to the working directory.
+func IsLocalURL(rawURL string) bool {
+	return rawURL == StdinURL ||
+		strings.HasPrefix(strings.ToLower(rawURL), "file:")
+}
+
+// openLocal opens the local feed at rawURL like OpenRSS. The SHA-256
+// checksum of the content of a file is its ETag validator, so
+// ErrNotModified is returned if the file has not changed since prev. The
+// modification time is not used because a file that is rewritten within
+// the resolution of the modification time would not be read again.
+// Standard input and other files that are not regular files do not have
+// any validators.
+func openLocal(rawURL string, prev Validators) (
+	io.ReadCloser,
+	Validators,
+	error,
+) {
+	if rawURL == StdinURL {
+		return io.NopCloser(os.Stdin), Validators{}, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, prev, err
+	}
+
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, prev, fmt.Errorf(
+			"the file URL %s refers to another host",
+			rawURL,
+		)
+	}
+
+	name := u.Path
+	if u.Opaque != "" {
+		name = u.Opaque
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, prev, err
+	}
+
+	// A named pipe, such as /dev/stdin, can only be read once, so like
+	// standard input it does not have any validators.
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, prev, err
+	}
+
+	if !info.Mode().IsRegular() {
+		return file, Validators{}, nil
+	}
+
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		_ = file.Close()
+		return nil, prev, err
+	}
+
+	etag := `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+	if prev.ETag == etag {
+		_ = file.Close()
+		return nil, prev, ErrNotModified
+	}
+
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, prev, err
+	}
+
+	return file, Validators{ETag: etag}, nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"regexp"
+	"strings"
+)
+
+// markdownEscapes are the characters that are escaped with a backslash
+// wherever they appear in the text, because they start Markdown emphasis,
+// code spans, links, inline HTML, strikethrough, or tables.
+const markdownEscapes = "\\`*_[]<>~|"
+
+// markdownLineEscapes are the characters that are escaped with a backslash
+// when they are the first character of a line, because they start a
+// heading, a list item, a setext heading underline, or a blockquote.
+const markdownLineEscapes = "#+-=>"
+
+// entityPattern matches an HTML character reference, which Markdown
+// replaces with the character that it refers to.
+var entityPattern = regexp.MustCompile(
+	`^&(?:#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`,
+)
+
+// RenderMarkdown converts the text of a post into Markdown. The ranges of
+// the text that are annotated by link, mention, and hashtag facets are
+// converted into links, and URLs in the rest of the text are converted into
+// autolinks. The characters that would otherwise be interpreted as Markdown
+// syntax are escaped. Line breaks are converted into hard line breaks, and
+// blank lines separate paragraphs.
+func RenderMarkdown(text string, facets []Facet) string {
+	w := markdownWriter{lineStart: true}
+	pos := 0
+	for _, f := range validFacets(text, facets) {
+		href := facetURL(f)
+		if href == "" {
+			continue
+		}
+
+		// An exclamation mark before the link would turn the link into an
+		// image.
+		before := text[pos:f.Index.ByteStart]
+		if strings.HasSuffix(before, "!") {
+			w.writeText(strings.TrimSuffix(before, "!"), true)
+			w.flushLines()
+			w.b.WriteString("\\!")
+		} else {
+			w.writeText(before, true)
+			w.flushLines()
+		}
+
+		w.b.WriteString("[")
+		w.lineStart = false
+		w.writeText(text[f.Index.ByteStart:f.Index.ByteEnd], false)
+		w.b.WriteString("](" + markdownURL(href) + ")")
+		pos = f.Index.ByteEnd
+	}
+
+	w.writeText(text[pos:], true)
+	return w.b.String()
+}
+
+// EscapeMarkdown converts plain text into Markdown that renders as the
+// text. It is the same as RenderMarkdown for text without facets.
+func EscapeMarkdown(text string) string {
+	return RenderMarkdown(text, nil)
+}
+
+// markdownWriter writes text as Markdown. Line breaks are buffered until
+// the next character is written so that the line breaks at the beginning
+// and end of the text are removed.
+type markdownWriter struct {
+	b         strings.Builder
+	lineStart bool
+	newlines  int
+}
+
+// writeText writes text with the Markdown syntax escaped. URLs are written
+// as autolinks if autolink is true.
+func (w *markdownWriter) writeText(text string, autolink bool) {
+	for i := 0; i < len(text); {
+		c := text[i]
+		if c == '\n' {
+			w.newlines++
+			w.lineStart = true
+			i++
+			continue
+		}
+
+		if w.lineStart && (c == ' ' || c == '\t' || c == '\r') {
+			i++
+			continue
+		}
+
+		w.flushLines()
+		if autolink {
+			if n := urlLength(text[i:]); n > 0 {
+				w.b.WriteString(markdownURL(text[i : i+n]))
+				w.lineStart = false
+				i += n
+				continue
+			}
+		}
+
+		if w.lineStart {
+			w.lineStart = false
+			if n := listNumberLength(text[i:]); n > 0 {
+				w.b.WriteString(text[i : i+n-1])
+				w.b.WriteString("\\")
+				w.b.WriteByte(text[i+n-1])
+				i += n
+				continue
+			}
+
+			if strings.IndexByte(markdownLineEscapes, c) >= 0 {
+				w.b.WriteString("\\")
+				w.b.WriteByte(c)
+				i++
+				continue
+			}
+		}
+
+		if strings.IndexByte(markdownEscapes, c) >= 0 ||
+			(c == '&' && entityPattern.MatchString(text[i:])) {
+			w.b.WriteString("\\")
+		}
+
+		w.b.WriteByte(c)
+		i++
+	}
+}
+
+// flushLines writes the buffered line breaks. A single line break is
+// written as a hard line break, and multiple line breaks are written as a
+// blank line that starts a new paragraph.
+func (w *markdownWriter) flushLines() {
+	switch {
+	case w.newlines == 0:
+		return
+	case w.b.Len() == 0:
+	case w.newlines == 1:
+		w.b.WriteString("\\\n")
+	default:
+		w.b.WriteString("\n\n")
+	}
+
+	w.newlines = 0
+}
+
+// urlLength returns the length of the http or https URL at the beginning
+// of s, or 0 if s does not start with a URL. Punctuation at the end of the
+// URL is not included, because it usually ends the sentence.
+func urlLength(s string) int {
+	if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
+		return 0
+	}
+
+	n := strings.IndexAny(s, " \t\r\n")
+	if n < 0 {
+		n = len(s)
+	}
+
+	n = len(strings.TrimRight(s[:n], ".,:;!?'\")"))
+	if n <= len("https://") {
+		return 0
+	}
+
+	return n
+}
+
+// listNumberLength returns the length of the ordered list marker, such as
+// 1. or 1), at the beginning of s, or 0 if s does not start with one.
+func listNumberLength(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+
+	if n == 0 || n == len(s) || (s[n] != '.' && s[n] != ')') {
+		return 0
+	}
+
+	return n + 1
+}
+
+// markdownURLReplacer percent-encodes the characters that cannot appear
+// inside of an autolink.
+var markdownURLReplacer = strings.NewReplacer(
+	" ", "%20",
+	"<", "%3C",
+	">", "%3E",
+	"\n", "%0A",
+)
+
+// markdownURL returns u as a Markdown autolink destination.
+func markdownURL(u string) string {
+	return "<" + markdownURLReplacer.Replace(u) + ">"
+}
@@ -0,0 +1,234 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// MastodonFeedURL returns the URL of the RSS feed that Mastodon publishes
+// for the account identified by handle, which has the form user@instance or
+// @user@instance.
+func MastodonFeedURL(handle string) (string, error) {
+	user, instance, ok := strings.Cut(strings.TrimPrefix(handle, "@"), "@")
+	if !ok || user == "" || instance == "" || strings.Contains(instance, "/") {
+		return "", fmt.Errorf(
+			"%q is not a Mastodon handle of the form user@instance",
+			handle,
+		)
+	}
+
+	return MastodonProfileURL(instance, user) + ".rss", nil
+}
+
+// MastodonProfileURL returns the URL of the profile of the Mastodon account
+// user on instance.
+func MastodonProfileURL(instance string, user string) string {
+	return "https://" + instance + "/@" + url.PathEscape(user)
+}
+
+// NormalizeMastodon converts the items of the RSS feed that Mastodon
+// publishes for an account into the form of the items of a Bluesky feed so
+// that they are transformed and written in the same way. The description
+// of a Mastodon post is an HTML fragment, so the text of the post is
+// extracted from the markup. The author of the posts is the account of the
+// feed, and the images and videos that are listed by the media:content and
+// enclosure elements become the media of the posts.
+func NormalizeMastodon(f *RSS) {
+	author := mastodonAuthor(f.Channel)
+	for i := range f.Channel.Items {
+		item := &f.Channel.Items[i]
+		item.Text = htmlText(item.Description)
+		item.IsHTML = true
+		if author != nil {
+			a := *author
+			item.Author = &a
+		}
+
+		if item.GUID.Value == "" {
+			item.GUID = GUID{IsPermaLink: "true", Value: item.Link}
+		}
+
+		item.Media = nil
+		extensions := item.Extensions[:0]
+		for _, ext := range item.Extensions {
+			if ext.Namespace == MediaRSSNamespace &&
+				localName(ext.XMLName.Local) == "content" {
+				item.Media = append(item.Media, mastodonMedia(ext))
+				continue
+			}
+
+			extensions = append(extensions, ext)
+		}
+
+		item.Extensions = extensions
+		if len(item.Media) == 0 && item.Enclosure != nil {
+			size, _ := strconv.ParseInt(item.Enclosure.Length, 10, 64)
+			item.Media = []Media{{
+				Medium:   mediumOf(item.Enclosure.Type),
+				URL:      item.Enclosure.URL,
+				MIMEType: item.Enclosure.Type,
+				Size:     size,
+			}}
+		}
+
+		item.Enclosure = nil
+	}
+}
+
+// mastodonAuthor returns the account that published the Mastodon feed in
+// channel. The handle of the account is read from the link of the channel,
+// which is the URL of the profile of the account, such as
+// https://mastodon.social/@user. nil is returned if the link is not the
+// URL of a profile.
+func mastodonAuthor(channel Channel) *ProfileViewBasic {
+	u, err := url.Parse(channel.Link)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+
+	user, ok := strings.CutPrefix(strings.Trim(u.Path, "/"), "@")
+	if !ok || user == "" || strings.Contains(user, "/") {
+		return nil
+	}
+
+	author := &ProfileViewBasic{
+		Handle:      user + "@" + u.Host,
+		DisplayName: channel.Title,
+		URL:         MastodonProfileURL(u.Host, user),
+	}
+	for _, ext := range channel.Extensions {
+		if ext.XMLName.Local == "image" {
+			var image struct {
+				URL string `xml:"url"`
+			}
+			if xml.Unmarshal(
+				[]byte("<image>"+ext.InnerXML+"</image>"),
+				&image,
+			) == nil {
+				author.Avatar = strings.TrimSpace(image.URL)
+			}
+		}
+	}
+
+	return author
+}
+
+// mastodonMedia converts a media:content element into an attachment. The
+// alternative text of the attachment is the media:description element.
+func mastodonMedia(ext Extension) Media {
+	var m Media
+	for _, attr := range ext.Attrs {
+		switch attr.Name.Local {
+		case "url":
+			m.URL = attr.Value
+		case "type":
+			m.MIMEType = attr.Value
+		case "medium":
+			m.Medium = attr.Value
+		case "fileSize":
+			m.Size, _ = strconv.ParseInt(attr.Value, 10, 64)
+		case "width":
+			m.Width, _ = strconv.Atoi(attr.Value)
+		case "height":
+			m.Height, _ = strconv.Atoi(attr.Value)
+		}
+	}
+
+	if m.Medium == "" {
+		m.Medium = mediumOf(m.MIMEType)
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(ext.InnerXML))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		start, ok := token.(xml.StartElement)
+		if ok && start.Name.Local == "description" {
+			var alt string
+			if decoder.DecodeElement(&alt, &start) == nil {
+				m.Alt = strings.TrimSpace(alt)
+			}
+		}
+	}
+
+	return m
+}
+
+// mediumOf returns the medium of an attachment with the MIME type
+// mimeType, which is the type of the MIME type for images and videos.
+func mediumOf(mimeType string) string {
+	medium, _, _ := strings.Cut(mimeType, "/")
+	if medium == "image" || medium == "video" || medium == "audio" {
+		return medium
+	}
+
+	return ""
+}
+
+// localName returns name without its prefix.
+func localName(name string) string {
+	if _, local, ok := strings.Cut(name, ":"); ok {
+		return local
+	}
+
+	return name
+}
+
+// htmlText returns the text of the HTML fragment s without any markup. Line
+// breaks become newlines, and paragraphs are separated by a blank line.
+func htmlText(s string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if !errors.Is(z.Err(), io.EOF) {
+				b.Write(z.Raw())
+			}
+
+			return strings.TrimSpace(b.String())
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			if string(name) == "br" {
+				b.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if string(name) == "p" {
+				b.WriteString("\n\n")
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxLinkPageSize is the number of bytes of a linked page that are read
+// when looking for the Open Graph metadata of the page. The metadata is in
+// the head of the page, so the rest of a large page is not downloaded.
+const maxLinkPageSize = 1 << 20
+
+// FetchLinkCard downloads the page at u and returns a link card that is
+// built from the Open Graph metadata of the page. The og:title,
+// og:description, and og:image properties are used, falling back to the
+// Twitter card metadata, the title element, and the description meta tag
+// of the page. A nil card is returned if the page is not an HTML page or
+// does not have a title.
+func (f *Fetcher) FetchLinkCard(
+	ctx context.Context,
+	u string,
+) (*LinkCard, error) {
+	resp, err := f.Get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, nil
+	}
+
+	meta, title := pageMetadata(io.LimitReader(resp.Body, maxLinkPageSize))
+	card := &LinkCard{
+		URL: u,
+		Title: strings.TrimSpace(
+			cmp.Or(meta["og:title"], meta["twitter:title"], title),
+		),
+		Description: strings.TrimSpace(cmp.Or(
+			meta["og:description"],
+			meta["twitter:description"],
+			meta["description"],
+		)),
+	}
+	if card.Title == "" {
+		return nil, nil
+	}
+
+	image := strings.TrimSpace(cmp.Or(
+		meta["og:image:secure_url"],
+		meta["og:image"],
+		meta["twitter:image"],
+	))
+	if image == "" {
+		return card, nil
+	}
+
+	// Relative image URLs are resolved against the URL of the page after
+	// any redirects.
+	if ref, err := url.Parse(image); err == nil {
+		resolved := resp.Request.URL.ResolveReference(ref)
+		if resolved.Scheme == "http" || resolved.Scheme == "https" {
+			card.Thumbnail = resolved.String()
+		}
+	}
+
+	return card, nil
+}
+
+// pageMetadata returns the content of the meta elements in the head of the
+// HTML page that is read from r, keyed by their lowercase property or name
+// attributes, and the text of the title element. Only the first value of
+// each property is kept. The page is read until the body of the page
+// starts.
+func pageMetadata(r io.Reader) (map[string]string, string) {
+	meta := make(map[string]string)
+	var title strings.Builder
+	inTitle := false
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return meta, title.String()
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return meta, title.String()
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = title.Len() == 0
+			case "body":
+				return meta, title.String()
+			case "meta":
+				var key, content string
+				for hasAttr {
+					var attr, value []byte
+					attr, value, hasAttr = z.TagAttr()
+					switch string(attr) {
+					case "property", "name":
+						key = cmp.Or(key, strings.ToLower(string(value)))
+					case "content":
+						content = string(value)
+					}
+				}
+
+				if _, ok := meta[key]; key != "" && !ok {
+					meta[key] = content
+				}
+			}
+		}
+	}
+}
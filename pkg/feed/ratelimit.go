@@ -0,0 +1,155 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimitWait is the default for how long a request waits for an
+// exhausted rate limit to reset.
+const DefaultRateLimitWait = 5 * time.Minute
+
+// RateLimitError is returned when a server is rate limiting the requests,
+// either because it responded with status code 429 after the request was
+// retried, or because its rate limit does not reset within the
+// RateLimitWait of the Fetcher.
+type RateLimitError struct {
+	// Host is the host that is rate limiting the requests.
+	Host string
+
+	// Reset is the time at which the server accepts requests again, or the
+	// zero time if the server did not say.
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%s is rate limiting the requests", e.Host)
+	}
+
+	return fmt.Sprintf(
+		"the rate limit of %s does not reset until %s",
+		e.Host,
+		e.Reset.UTC().Format(time.RFC3339),
+	)
+}
+
+// newRateLimitError returns a *RateLimitError for resp, which has status
+// code 429. The time at which the limit resets is read from the
+// Retry-After header, or from the RateLimit-Reset header if the server did
+// not send a Retry-After header.
+func newRateLimitError(resp *http.Response, now time.Time) *RateLimitError {
+	err := &RateLimitError{Host: resp.Request.URL.Host}
+	if delay, ok := retryAfter(resp); ok {
+		err.Reset = now.Add(delay)
+	} else if reset, ok := rateLimitReset(
+		resp.Header.Get("RateLimit-Reset"),
+		now,
+	); ok {
+		err.Reset = reset
+	}
+
+	return err
+}
+
+// rateLimits tracks the rate limits that the servers report using the
+// RateLimit-Remaining and RateLimit-Reset headers, which Bluesky sends with
+// the responses of the AT Protocol APIs. The limits are tracked per host.
+type rateLimits struct {
+	mu    sync.Mutex
+	hosts map[string]*rateLimit
+}
+
+// rateLimit is the rate limit of a host. remaining is the number of
+// requests that can be sent before the limit resets at reset.
+type rateLimit struct {
+	remaining int
+	reset     time.Time
+}
+
+// observe updates the rate limit of host from the headers of resp.
+// Responses without rate limit headers do not change the rate limit.
+func (l *rateLimits) observe(host string, resp *http.Response, now time.Time) {
+	remaining, err := strconv.Atoi(resp.Header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	reset, ok := rateLimitReset(resp.Header.Get("RateLimit-Reset"), now)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.hosts == nil {
+		l.hosts = map[string]*rateLimit{}
+	}
+
+	l.hosts[host] = &rateLimit{remaining: remaining, reset: reset}
+}
+
+// reserve reserves a request to host and returns how long the request has
+// to wait for the rate limit of host to reset. Requests are reserved so
+// that concurrent requests do not exceed the remaining requests before
+// the server reports the new limit.
+func (l *rateLimits) reserve(host string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit, ok := l.hosts[host]
+	if !ok {
+		return 0
+	}
+
+	if !now.Before(limit.reset) {
+		delete(l.hosts, host)
+		return 0
+	}
+
+	if limit.remaining > 0 {
+		limit.remaining--
+		return 0
+	}
+
+	return limit.reset.Sub(now)
+}
+
+// rateLimitReset parses the value of the RateLimit-Reset header. Bluesky
+// sends the Unix time at which the limit resets, while the IETF draft for
+// the header uses the number of seconds until the limit resets, so values
+// that are too large to be a number of seconds are treated as a Unix time.
+func rateLimitReset(value string, now time.Time) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return time.Time{}, false
+	}
+
+	if seconds > 1_000_000_000 {
+		return time.Unix(seconds, 0), true
+	}
+
+	return now.Add(time.Duration(seconds) * time.Second), true
+}
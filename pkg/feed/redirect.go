@@ -0,0 +1,70 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DefaultMaxRedirects is the default number of redirects that a request
+// follows, which is the limit that net/http uses.
+const DefaultMaxRedirects = 10
+
+// RedirectError is returned when a request is redirected more often than
+// the redirect policy of the client allows. Requests that fail because of
+// a RedirectError are not retried.
+type RedirectError struct {
+	// URL is the URL that the request was redirected to.
+	URL string
+
+	// Max is the number of redirects that the request was allowed to
+	// follow.
+	Max int
+}
+
+func (e *RedirectError) Error() string {
+	if e.Max == 0 {
+		return fmt.Sprintf(
+			"the request was redirected to %s, but redirects are disabled",
+			e.URL,
+		)
+	}
+
+	return fmt.Sprintf(
+		"the request was redirected more than %d times, the last time to %s",
+		e.Max,
+		e.URL,
+	)
+}
+
+// RedirectPolicy returns a function for the CheckRedirect field of an
+// http.Client that follows at most limit redirects per request. Redirects
+// are not followed if limit is zero.
+func RedirectPolicy(limit int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > limit {
+			return &RedirectError{URL: req.URL.Redacted(), Max: limit}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultResolverTTL is how long a Resolver that is returned by NewResolver
+// caches the DID of a handle.
+const DefaultResolverTTL = time.Hour
+
+// maxDIDLength limits how much of the response to a .well-known/atproto-did
+// request is read.
+const maxDIDLength = 2048
+
+// maxHandleLength is the maximum length of a handle.
+const maxHandleLength = 253
+
+// handlePattern and didPattern match the syntax of the handles and the DIDs
+// of the AT Protocol. A handle is a domain name whose top-level domain
+// starts with a letter, so IP addresses and ports are not handles.
+var (
+	handlePattern = regexp.MustCompile(
+		`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)+` +
+			`[a-zA-Z]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`,
+	)
+	didPattern = regexp.MustCompile(
+		`^did:[a-z]+:[a-zA-Z0-9._:%-]*[a-zA-Z0-9._-]$`,
+	)
+)
+
+// IsHandle reports whether s has the syntax of the handle of an account.
+func IsHandle(s string) bool {
+	return len(s) <= maxHandleLength && handlePattern.MatchString(s)
+}
+
+// IsDID reports whether s has the syntax of a DID.
+func IsDID(s string) bool {
+	return len(s) <= maxDIDLength && didPattern.MatchString(s)
+}
+
+// Resolver resolves the handles of Bluesky accounts to their DIDs. The
+// handle of an account can change, but its DID does not, so the DID is used
+// to identify the account in the API calls and in the GUIDs of the posts.
+// A handle is resolved using the com.atproto.identity.resolveHandle XRPC
+// method. If the method fails, the _atproto DNS TXT record of the handle
+// and the https://<handle>/.well-known/atproto-did URL are tried. The
+// results are cached, and a Resolver can be used by multiple goroutines.
+type Resolver struct {
+	// Fetcher sends the requests that resolve the handles.
+	Fetcher *Fetcher
+
+	// TTL is how long the DID of a handle is cached.
+	TTL time.Duration
+
+	// Cache stores the DIDs so that they are also cached by the next run.
+	// If Cache is nil, the DIDs are only cached in memory.
+	Cache *Cache
+
+	mu    sync.Mutex
+	cache map[string]resolvedHandle
+}
+
+// resolvedHandle is a DID in the cache of a Resolver.
+type resolvedHandle struct {
+	did     string
+	expires time.Time
+}
+
+// NewResolver returns a Resolver that sends its requests using f and caches
+// the DIDs for DefaultResolverTTL.
+func NewResolver(f *Fetcher) *Resolver {
+	return &Resolver{Fetcher: f, TTL: DefaultResolverTTL}
+}
+
+// Resolve returns the DID of the account identified by handle. A handle
+// that is already a DID is returned without being resolved.
+func (r *Resolver) Resolve(ctx context.Context, handle string) (string, error) {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+	if strings.HasPrefix(handle, "did:") {
+		return handle, nil
+	}
+
+	now := time.Now()
+	r.mu.Lock()
+	cached, ok := r.cache[handle]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.did, nil
+	}
+
+	if r.Cache != nil {
+		if did, ok := r.Cache.DID(handle, now); ok {
+			return did, nil
+		}
+	}
+
+	did, err := r.Fetcher.ResolveHandle(ctx, handle)
+	if err != nil {
+		errs := []error{err}
+		if did, err = resolveDNS(ctx, handle); err != nil {
+			errs = append(errs, err)
+			did, err = r.resolveWellKnown(ctx, handle)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+			return "", fmt.Errorf(
+				"failed to resolve the handle %s: %w",
+				handle,
+				errors.Join(errs...),
+			)
+		}
+	}
+
+	expires := now.Add(r.TTL)
+	if r.Cache != nil {
+		if err := r.Cache.SetDID(handle, did, expires); err != nil {
+			r.Fetcher.logger().Warn(
+				"Failed to cache the DID.",
+				"handle", handle,
+				"error", err,
+			)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cache == nil {
+		r.cache = make(map[string]resolvedHandle)
+	}
+
+	r.cache[handle] = resolvedHandle{did: did, expires: expires}
+	return did, nil
+}
+
+// PostURI returns the AT URI of the post at the bsky.app web URL link. The
+// handle in link is resolved to a DID so that the AT URI does not change
+// when the author changes their handle.
+func (r *Resolver) PostURI(ctx context.Context, link string) (string, error) {
+	rest, ok := strings.CutPrefix(link, ProfileURL(""))
+	handle, rkey, found := strings.Cut(rest, "/post/")
+	if !ok || !found || handle == "" || rkey == "" ||
+		strings.Contains(rkey, "/") {
+		return "", fmt.Errorf("%s is not the URL of a post", link)
+	}
+
+	did, err := r.Resolve(ctx, handle)
+	if err != nil {
+		return "", err
+	}
+
+	return "at://" + did + "/app.bsky.feed.post/" + rkey, nil
+}
+
+// AuthorityDID returns the DID in the authority of the AT URI uri, or an
+// empty string if the authority of uri is not a DID.
+func AuthorityDID(uri string) string {
+	rest, ok := strings.CutPrefix(uri, "at://")
+	authority, _, _ := strings.Cut(rest, "/")
+	if !ok || !strings.HasPrefix(authority, "did:") {
+		return ""
+	}
+
+	return authority
+}
+
+// ResolveHandle resolves handle to a DID using the
+// com.atproto.identity.resolveHandle XRPC method.
+func (f *Fetcher) ResolveHandle(
+	ctx context.Context,
+	handle string,
+) (string, error) {
+	var resp struct {
+		DID string `json:"did"`
+	}
+	if err := f.xrpcQuery(
+		ctx,
+		"com.atproto.identity.resolveHandle",
+		url.Values{"handle": {handle}},
+		&resp,
+	); err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(resp.DID, "did:") {
+		return "", fmt.Errorf("the DID %q is invalid", resp.DID)
+	}
+
+	return resp.DID, nil
+}
+
+// resolveDNS resolves handle using the did= value of the _atproto DNS TXT
+// record of the handle.
+func resolveDNS(ctx context.Context, handle string) (string, error) {
+	records, err := net.DefaultResolver.LookupTXT(ctx, "_atproto."+handle)
+	if err != nil {
+		return "", err
+	}
+
+	for _, record := range records {
+		did, ok := strings.CutPrefix(record, "did=")
+		if ok && strings.HasPrefix(did, "did:") {
+			return did, nil
+		}
+	}
+
+	return "", fmt.Errorf("_atproto.%s does not have a did= record", handle)
+}
+
+// resolveWellKnown resolves handle using the DID that is served at the
+// https://<handle>/.well-known/atproto-did URL.
+func (r *Resolver) resolveWellKnown(
+	ctx context.Context,
+	handle string,
+) (string, error) {
+	resp, err := r.Fetcher.Get(
+		ctx,
+		"https://"+handle+"/.well-known/atproto-did",
+	)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDIDLength))
+	if err != nil {
+		return "", err
+	}
+
+	did := strings.TrimSpace(string(body))
+	if !strings.HasPrefix(did, "did:") {
+		return "", fmt.Errorf("the DID %q is invalid", did)
+	}
+
+	return did, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"html"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	facetLink    = "app.bsky.richtext.facet#link"
+	facetMention = "app.bsky.richtext.facet#mention"
+	facetTag     = "app.bsky.richtext.facet#tag"
+)
+
+// Facet annotates a range of the text of a post. The range is specified
+// using UTF-8 byte offsets into the text.
+type Facet struct {
+	Index    ByteSlice      `json:"index"`
+	Features []FacetFeature `json:"features"`
+}
+
+type ByteSlice struct {
+	ByteStart int `json:"byteStart"`
+	ByteEnd   int `json:"byteEnd"`
+}
+
+type FacetFeature struct {
+	Type string `json:"$type"`
+	URI  string `json:"uri,omitempty"`
+	DID  string `json:"did,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+// facetURL returns the URL that the text annotated by the facet links to,
+// or an empty string if the facet does not contain a supported feature.
+func facetURL(f Facet) string {
+	for _, feature := range f.Features {
+		switch feature.Type {
+		case facetLink:
+			if IsSafeURL(feature.URI) {
+				return feature.URI
+			}
+		case facetMention:
+			return ProfileURL(feature.DID)
+		case facetTag:
+			return "https://bsky.app/hashtag/" + url.PathEscape(feature.Tag)
+		}
+	}
+
+	return ""
+}
+
+// validFacets returns the facets that have a valid range for text and that
+// do not overlap with the facets before them, sorted by their position in
+// the text.
+func validFacets(text string, facets []Facet) []Facet {
+	sorted := slices.Clone(facets)
+	slices.SortStableFunc(sorted, func(a, b Facet) int {
+		return a.Index.ByteStart - b.Index.ByteStart
+	})
+
+	var result []Facet
+	end := 0
+	for _, f := range sorted {
+		if f.Index.ByteStart < end ||
+			f.Index.ByteEnd <= f.Index.ByteStart ||
+			f.Index.ByteEnd > len(text) {
+			continue
+		}
+
+		result = append(result, f)
+		end = f.Index.ByteEnd
+	}
+
+	return result
+}
+
+// FirstLink returns the URL of the first web page that the text of a post
+// links to using a link facet, or an empty string if the text does not link
+// to a web page.
+func FirstLink(text string, facets []Facet) string {
+	for _, f := range validFacets(text, facets) {
+		for _, feature := range f.Features {
+			u, err := url.Parse(feature.URI)
+			if feature.Type == facetLink && err == nil &&
+				(u.Scheme == "http" || u.Scheme == "https") {
+				return feature.URI
+			}
+		}
+	}
+
+	return ""
+}
+
+// RenderRichText converts the text of a post into an HTML fragment. The
+// ranges of the text that are annotated by link, mention, and hashtag
+// facets are converted into links.
+func RenderRichText(text string, facets []Facet) string {
+	var b strings.Builder
+	pos := 0
+	for _, f := range validFacets(text, facets) {
+		href := facetURL(f)
+		if href == "" {
+			continue
+		}
+
+		b.WriteString(TextToHTML(text[pos:f.Index.ByteStart]))
+		b.WriteString(`<a href="`)
+		b.WriteString(html.EscapeString(href))
+		b.WriteString(`">`)
+		b.WriteString(TextToHTML(text[f.Index.ByteStart:f.Index.ByteEnd]))
+		b.WriteString("</a>")
+		pos = f.Index.ByteEnd
+	}
+
+	b.WriteString(TextToHTML(text[pos:]))
+	return b.String()
+}
+
+// TextToHTML converts plain post text into an HTML fragment by escaping the
+// text and converting line breaks into <br> elements.
+func TextToHTML(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>\n")
+}
+
+// IsSafeURL reports whether u is a relative URL or uses the http, https, or
+// mailto scheme.
+func IsSafeURL(u string) bool {
+	u = strings.ToLower(strings.TrimSpace(u))
+	scheme, _, found := strings.Cut(u, ":")
+	if !found || strings.ContainsAny(scheme, "/?#") {
+		return true
+	}
+
+	return scheme == "http" || scheme == "https" || scheme == "mailto"
+}
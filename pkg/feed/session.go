@@ -0,0 +1,200 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// sessionServiceURL is the base URL of the Bluesky entryway service that
+// creates authenticated sessions for the accounts that are hosted by
+// Bluesky.
+const sessionServiceURL = "https://bsky.social"
+
+// appViewProxy is the service that a PDS forwards the app.bsky.* XRPC
+// requests of an authenticated session to.
+const appViewProxy = "did:web:api.bsky.app#bsky_appview"
+
+// Session is an authenticated AT Protocol session. When the Session field
+// of a Fetcher is set, the XRPC requests are sent to the PDS of the account
+// that signed in instead of the public AppView. This allows the posts of
+// accounts that are only visible to signed-in users to be downloaded, and
+// the requests are rate limited per account instead of per IP address.
+type Session struct {
+	// DID is the DID of the account that signed in.
+	DID string
+
+	// Handle is the handle of the account that signed in.
+	Handle string
+
+	// ServiceURL is the base URL of the PDS of the account.
+	ServiceURL string
+
+	mu         sync.Mutex
+	accessJWT  string
+	refreshJWT string
+}
+
+type sessionResponse struct {
+	AccessJWT  string `json:"accessJwt"`
+	RefreshJWT string `json:"refreshJwt"`
+	Handle     string `json:"handle"`
+	DID        string `json:"did"`
+	DIDDoc     *struct {
+		Service []struct {
+			ID              string `json:"id"`
+			ServiceEndpoint string `json:"serviceEndpoint"`
+		} `json:"service"`
+	} `json:"didDoc,omitempty"`
+}
+
+// CreateSession signs in to Bluesky using identifier, which is the handle,
+// DID, or email address of the account, and an app password for the
+// account. The session is created by the PDSURL service of the Fetcher.
+// The returned session can be assigned to the Session field of the Fetcher
+// to authenticate the XRPC requests.
+func (f *Fetcher) CreateSession(
+	ctx context.Context,
+	identifier string,
+	password string,
+) (*Session, error) {
+	serviceURL := cmp.Or(f.PDSURL, sessionServiceURL)
+	var resp sessionResponse
+	if err := f.xrpcProcedure(
+		ctx,
+		serviceURL,
+		"com.atproto.server.createSession",
+		"",
+		map[string]string{"identifier": identifier, "password": password},
+		&resp,
+	); err != nil {
+		return nil, err
+	}
+
+	s := &Session{ServiceURL: serviceURL}
+	s.update(resp)
+	if resp.DIDDoc != nil {
+		for _, service := range resp.DIDDoc.Service {
+			if service.ID == "#atproto_pds" && service.ServiceEndpoint != "" {
+				s.ServiceURL = service.ServiceEndpoint
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// accessToken returns the current access token of the session.
+func (s *Session) accessToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accessJWT
+}
+
+// refresh replaces the expired access token of the session using the
+// refresh token. The refresh token can only be used once, so the session is
+// not refreshed again if another request has already replaced expired.
+func (s *Session) refresh(
+	ctx context.Context,
+	f *Fetcher,
+	expired string,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.accessJWT != expired {
+		return nil
+	}
+
+	var resp sessionResponse
+	if err := f.xrpcProcedure(
+		ctx,
+		s.ServiceURL,
+		"com.atproto.server.refreshSession",
+		s.refreshJWT,
+		nil,
+		&resp,
+	); err != nil {
+		return err
+	}
+
+	if resp.AccessJWT == "" {
+		return errors.New("the refreshed session does not have a token")
+	}
+
+	s.update(resp)
+	return nil
+}
+
+// update stores the identity and the tokens of a session response. The
+// caller must hold s.mu if the session is shared.
+func (s *Session) update(resp sessionResponse) {
+	s.DID = resp.DID
+	s.Handle = resp.Handle
+	s.accessJWT = resp.AccessJWT
+	s.refreshJWT = resp.RefreshJWT
+}
+
+// xrpcProcedure calls the XRPC procedure method nsid on the service at
+// serviceURL. The input is sent as the JSON body of the request unless it
+// is nil, and the JSON response is decoded into v. If token is not empty,
+// it is sent as the bearer token of the request.
+func (f *Fetcher) xrpcProcedure(
+	ctx context.Context,
+	serviceURL string,
+	nsid string,
+	token string,
+	input any,
+	v any,
+) error {
+	var body []byte
+	if input != nil {
+		var err error
+		if body, err = json.Marshal(input); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		serviceURL+"/xrpc/"+nsid,
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+
+	if input != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return f.xrpcCall(nsid, req, v)
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// sniffLength is the number of bytes at the start of a response body that
+// are used to detect an HTML page. It is larger than the 512 bytes that
+// http.DetectContentType considers so that the title of the page can
+// usually be read too.
+const sniffLength = 4096
+
+// HTMLPageError is returned when a server responds with an HTML page instead
+// of the feed or the XRPC response that was requested. This usually means
+// that the URL is the URL of a web page rather than of a feed, that the
+// server returned an error page with a 200 status code, or that a bot
+// protection service such as Cloudflare returned a challenge page.
+type HTMLPageError struct {
+	// URL is the URL of the page after any redirects were followed.
+	URL string
+
+	// Expected describes the response that was requested, such as "a
+	// feed".
+	Expected string
+
+	// ContentType is the value of the Content-Type header of the response.
+	ContentType string
+
+	// Title is the title of the page, or empty if the title is unknown.
+	Title string
+
+	// Challenge reports whether the page is the challenge page of a bot
+	// protection service.
+	Challenge bool
+}
+
+func (e *HTMLPageError) Error() string {
+	if e.Challenge {
+		return fmt.Sprintf(
+			"%s returned a bot protection challenge instead of %s; the "+
+				"server blocks automated requests, so ask its operator to "+
+				"allow the requests of the action or use another URL",
+			e.URL,
+			e.Expected,
+		)
+	}
+
+	page := "an HTML page"
+	if e.Title != "" {
+		page = fmt.Sprintf("an HTML page titled %q", e.Title)
+	}
+
+	if e.ContentType != "" && !strings.HasPrefix(e.ContentType, "text/html") {
+		page += " served as " + e.ContentType
+	}
+
+	return fmt.Sprintf(
+		"%s returned %s instead of %s; check that the URL is correct and "+
+			"that the server is not returning an error page",
+		e.URL,
+		page,
+		e.Expected,
+	)
+}
+
+// checkHTMLPage returns an *HTMLPageError if the body of resp is an HTML
+// page. The start of the body is sniffed instead of trusting the
+// Content-Type header, because many servers serve feeds as text/html and
+// error pages as anything. The body of resp is replaced by a reader that
+// still returns the sniffed bytes.
+func checkHTMLPage(resp *http.Response, expected string) error {
+	buffered := bufio.NewReaderSize(resp.Body, sniffLength)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{buffered, resp.Body}
+
+	// Errors are returned again when the body is read, where they are
+	// reported the same way as if the body had not been sniffed.
+	head, _ := buffered.Peek(sniffLength)
+	if !strings.HasPrefix(http.DetectContentType(head), "text/html") {
+		return nil
+	}
+
+	_, title := pageMetadata(bytes.NewReader(head))
+	title = strings.Join(strings.Fields(title), " ")
+	return &HTMLPageError{
+		URL:         resp.Request.URL.String(),
+		Expected:    expected,
+		ContentType: resp.Header.Get("Content-Type"),
+		Title:       title,
+		Challenge:   isChallenge(resp, title),
+	}
+}
+
+// isChallenge reports whether resp, whose page has the title title, is the
+// challenge page of a bot protection service. Cloudflare sets the
+// Cf-Mitigated header on its challenge pages, and older challenge pages
+// only have a recognizable title.
+func isChallenge(resp *http.Response, title string) bool {
+	return strings.EqualFold(resp.Header.Get("Cf-Mitigated"), "challenge") ||
+		title == "Just a moment..." ||
+		strings.HasPrefix(title, "Attention Required! | Cloudflare")
+}
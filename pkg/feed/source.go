@@ -0,0 +1,122 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Source is a source of posts, such as the RSS feed of a Bluesky account or
+// the posts of an account that are fetched using the AT Protocol API. The
+// posts are returned as the items of an RSS feed, which is the model that
+// the posts are transformed and written from, so that every source is
+// processed in the same way.
+//
+// The validators in prev are the validators that were returned by the
+// previous fetch of the source. Sources that support conditional requests
+// use them to return ErrNotModified if the posts have not changed, and
+// return the validators for the next fetch with the posts. Other sources
+// ignore prev and return empty validators.
+//
+// Fetch returns an RSS feed and validators instead of only the list of
+// posts, as a Fetch(ctx) ([]Post, error) method would, so that the channel
+// of the feed, which the output formats write, and the conditional
+// requests of the RSS sources are kept.
+type Source interface {
+	Fetch(ctx context.Context, prev Validators) (RSS, Validators, error)
+}
+
+// BlueskyRSSSource is the RSS feed that Bluesky publishes for an account.
+type BlueskyRSSSource struct {
+	Fetcher *Fetcher
+	URL     string
+}
+
+func (s BlueskyRSSSource) Fetch(
+	ctx context.Context,
+	prev Validators,
+) (RSS, Validators, error) {
+	return s.Fetcher.FetchRSS(ctx, s.URL, prev)
+}
+
+// XRPCSource is the author feed of a Bluesky account that is fetched using
+// the app.bsky.feed.getAuthorFeed endpoint of the AT Protocol API. Actor
+// is the DID or the handle of the account. MaxPages limits the number of
+// pages that are fetched, and when Since is not zero, only the posts that
+// are newer than Since are fetched.
+type XRPCSource struct {
+	Fetcher  *Fetcher
+	Actor    string
+	MaxPages int
+	Since    time.Time
+}
+
+func (s XRPCSource) Fetch(
+	ctx context.Context,
+	_ Validators,
+) (RSS, Validators, error) {
+	rss, err := s.Fetcher.FetchAuthorFeed(ctx, s.Actor, s.MaxPages, s.Since)
+	return rss, Validators{}, err
+}
+
+// MastodonSource is the RSS feed that Mastodon publishes for an account.
+// The posts are normalized using NormalizeMastodon.
+type MastodonSource struct {
+	Fetcher *Fetcher
+	URL     string
+}
+
+func (s MastodonSource) Fetch(
+	ctx context.Context,
+	prev Validators,
+) (RSS, Validators, error) {
+	rss, validators, err := s.Fetcher.FetchRSS(ctx, s.URL, prev)
+	if err == nil {
+		NormalizeMastodon(&rss)
+	}
+
+	return rss, validators, err
+}
+
+// GenericSource is any RSS 2.0, Atom, or JSON Feed feed. The feed is
+// normalized using ParseFeed. Micro.blog accounts are read using a
+// GenericSource for the JSON Feed that MicroblogFeedURL returns, because
+// Micro.blog publishes standard JSON Feeds.
+type GenericSource struct {
+	Fetcher *Fetcher
+	URL     string
+}
+
+func (s GenericSource) Fetch(
+	ctx context.Context,
+	prev Validators,
+) (RSS, Validators, error) {
+	return s.Fetcher.FetchFeed(ctx, s.URL, prev)
+}
+
+// MicroblogFeedURL returns the URL of the JSON Feed of the posts of the
+// Micro.blog account with the username. The feed.json feed of a
+// Micro.blog-hosted blog can be used instead.
+func MicroblogFeedURL(username string) string {
+	return "https://micro.blog/posts/" + url.PathEscape(username)
+}
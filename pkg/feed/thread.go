@@ -0,0 +1,140 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// threadDepth is the number of levels of replies that are requested from
+// the app.bsky.feed.getPostThread endpoint.
+const threadDepth = 100
+
+// ThreadViewPost is a post in a thread that was downloaded from the
+// app.bsky.feed.getPostThread endpoint together with its replies. Replies
+// that were deleted or that are blocked for the viewer do not have a post.
+type ThreadViewPost struct {
+	Type    string           `json:"$type"`
+	Post    *PostView        `json:"post,omitempty"`
+	Replies []ThreadViewPost `json:"replies,omitempty"`
+}
+
+type postThread struct {
+	Thread ThreadViewPost `json:"thread"`
+}
+
+// ReplyRef identifies the post that a reply responds to and the post at the
+// root of the thread.
+type ReplyRef struct {
+	Root   StrongRef `json:"root"`
+	Parent StrongRef `json:"parent"`
+}
+
+// StrongRef references a specific version of a record by its AT URI and
+// CID.
+type StrongRef struct {
+	URI string `json:"uri"`
+	CID string `json:"cid"`
+}
+
+// ReplyRef returns the reply reference of the post record. The second
+// result is false if the post is not a reply.
+func (r PostRecord) ReplyRef() (ReplyRef, bool) {
+	var ref ReplyRef
+	if len(r.Reply) == 0 || json.Unmarshal(r.Reply, &ref) != nil {
+		return ReplyRef{}, false
+	}
+
+	return ref, true
+}
+
+// FetchThread downloads the thread of replies to the post identified by the
+// AT URI uri from the app.bsky.feed.getPostThread endpoint.
+func (f *Fetcher) FetchThread(
+	ctx context.Context,
+	uri string,
+) (ThreadViewPost, error) {
+	var thread postThread
+	if err := f.xrpcQuery(
+		ctx,
+		"app.bsky.feed.getPostThread",
+		url.Values{
+			"uri":          {uri},
+			"depth":        {fmt.Sprint(threadDepth)},
+			"parentHeight": {"0"},
+		},
+		&thread,
+	); err != nil {
+		return ThreadViewPost{}, err
+	}
+
+	return thread.Thread, nil
+}
+
+// SelfThread returns the posts of the self-reply chain that starts at the
+// root of thread. The chain follows the replies of the author of the root
+// post to their own posts. When the author replied to a post more than once,
+// the earliest reply continues the chain.
+func SelfThread(thread ThreadViewPost) []PostView {
+	if thread.Post == nil {
+		return nil
+	}
+
+	author := thread.Post.Author.DID
+	posts := []PostView{*thread.Post}
+	for {
+		var next *ThreadViewPost
+		for i := range thread.Replies {
+			reply := &thread.Replies[i]
+			if reply.Post == nil || reply.Post.Author.DID != author {
+				continue
+			}
+
+			if next == nil || createdBefore(reply.Post, next.Post) {
+				next = reply
+			}
+		}
+
+		if next == nil {
+			return posts
+		}
+
+		posts = append(posts, *next.Post)
+		thread = *next
+	}
+}
+
+// createdBefore reports whether the post a was created before the post b.
+// The createdAt values are compared as strings if they are not valid RFC
+// 3339 timestamps.
+func createdBefore(a *PostView, b *PostView) bool {
+	ta, errA := time.Parse(time.RFC3339, a.Record.CreatedAt)
+	tb, errB := time.Parse(time.RFC3339, b.Record.CreatedAt)
+	if errA != nil || errB != nil {
+		return a.Record.CreatedAt < b.Record.CreatedAt
+	}
+
+	return ta.Before(tb)
+}
@@ -0,0 +1,476 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package feed
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// xrpcServiceURL is the base URL of the public Bluesky AppView service that
+// serves the unauthenticated app.bsky.* XRPC endpoints.
+const xrpcServiceURL = "https://public.api.bsky.app"
+
+// authorFeedLimit is the number of posts that are requested from the
+// app.bsky.feed.getAuthorFeed endpoint.
+const authorFeedLimit = 50
+
+const reasonRepost = "app.bsky.feed.defs#reasonRepost"
+
+type authorFeed struct {
+	Cursor string         `json:"cursor"`
+	Feed   []FeedViewPost `json:"feed"`
+}
+
+type FeedViewPost struct {
+	Post   PostView        `json:"post"`
+	Reply  json.RawMessage `json:"reply,omitempty"`
+	Reason *FeedReason     `json:"reason,omitempty"`
+}
+
+// FeedReason explains why a post that was not created by the author appears
+// in the feed of the author, such as when the author reposted the post.
+type FeedReason struct {
+	Type      string           `json:"$type"`
+	By        ProfileViewBasic `json:"by"`
+	IndexedAt string           `json:"indexedAt,omitempty"`
+}
+
+// IndexedAt returns the time that the post was added to the author feed,
+// which is the time that the author reposted the post for a repost. The
+// author feed is ordered by this time. The zero time is returned if the
+// time is not a valid RFC 3339 timestamp.
+func (p *FeedViewPost) IndexedAt() time.Time {
+	indexedAt := p.Post.IndexedAt
+	if p.Reason != nil && p.Reason.IndexedAt != "" {
+		indexedAt = p.Reason.IndexedAt
+	}
+
+	t, err := time.Parse(time.RFC3339, indexedAt)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+type PostView struct {
+	URI       string           `json:"uri"`
+	CID       string           `json:"cid"`
+	Author    ProfileViewBasic `json:"author"`
+	Record    PostRecord       `json:"record"`
+	Embed     json.RawMessage  `json:"embed,omitempty"`
+	IndexedAt string           `json:"indexedAt"`
+	Labels    []Label          `json:"labels,omitempty"`
+
+	ReplyCount  int `json:"replyCount,omitempty"`
+	RepostCount int `json:"repostCount,omitempty"`
+	LikeCount   int `json:"likeCount,omitempty"`
+	QuoteCount  int `json:"quoteCount,omitempty"`
+}
+
+type PostRecord struct {
+	Text      string          `json:"text"`
+	CreatedAt string          `json:"createdAt"`
+	Facets    []Facet         `json:"facets,omitempty"`
+	Langs     []string        `json:"langs,omitempty"`
+	Reply     json.RawMessage `json:"reply,omitempty"`
+	Embed     json.RawMessage `json:"embed,omitempty"`
+	Labels    *SelfLabels     `json:"labels,omitempty"`
+}
+
+type ProfileViewBasic struct {
+	DID         string `json:"did"`
+	Handle      string `json:"handle"`
+	DisplayName string `json:"displayName"`
+	Avatar      string `json:"avatar"`
+
+	// URL is the URL of the profile of an account that is not a Bluesky
+	// account, such as the profile of a Mastodon account. It is not part
+	// of the Bluesky API, and the bsky.app profile URL of the handle is
+	// used when it is empty.
+	URL string `json:"url,omitempty"`
+}
+
+type ProfileViewDetailed struct {
+	ProfileViewBasic
+	Description string `json:"description"`
+
+	// PinnedPost references the post that the account pinned to the top of
+	// its profile, or is nil if the account did not pin a post.
+	PinnedPost *StrongRef `json:"pinnedPost,omitempty"`
+}
+
+// xrpcError is the error that is returned when an XRPC method fails. The
+// name and message are decoded from the body of the response when the
+// service returns one.
+type xrpcError struct {
+	Name    string `json:"error"`
+	Message string `json:"message"`
+
+	nsid       string
+	statusCode int
+}
+
+func (e *xrpcError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf(
+			"%s failed with status code %d",
+			e.nsid,
+			e.statusCode,
+		)
+	}
+
+	return fmt.Sprintf(
+		"%s failed with status code %d: %s: %s",
+		e.nsid,
+		e.statusCode,
+		e.Name,
+		e.Message,
+	)
+}
+
+// FetchAuthorFeed downloads the recent posts for actor, which can be either
+// a handle or a DID, from the app.bsky.feed.getAuthorFeed endpoint and
+// synthesizes an RSS feed that is equivalent to the feed that Bluesky
+// publishes for the account. Up to maxPages pages of posts are downloaded
+// by following the cursors returned by the endpoint. If maxPages is zero,
+// every page is downloaded. If since is not zero, only the posts that were
+// added to the author feed after since are returned, and the pages stop
+// being downloaded once a page contains an older post. When the Fetcher has
+// a cache, the pages are only downloaded until a page contains a post that
+// is already cached, and the older posts are read from the cache instead.
+// The pinned post of the account is marked as pinned if it is one of the
+// downloaded posts.
+func (f *Fetcher) FetchAuthorFeed(
+	ctx context.Context,
+	actor string,
+	maxPages int,
+	since time.Time,
+) (RSS, error) {
+	var profile ProfileViewDetailed
+	if err := f.xrpcQuery(
+		ctx,
+		"app.bsky.actor.getProfile",
+		url.Values{"actor": {actor}},
+		&profile,
+	); err != nil {
+		return RSS{}, err
+	}
+
+	var cached []FeedViewPost
+	if f.Cache != nil {
+		var err error
+		if cached, err = f.Cache.AuthorFeed(actor); err != nil {
+			f.logger().Warn(
+				"Failed to read the cached posts. Every page is downloaded.",
+				"actor", actor,
+				"error", err,
+			)
+		}
+	}
+
+	known := make(map[string]bool, len(cached))
+	for i := range cached {
+		known[cached[i].key()] = true
+	}
+
+	var posts []FeedViewPost
+	cursor := ""
+	for page := 0; maxPages == 0 || page < maxPages; page++ {
+		params := url.Values{
+			"actor": {actor},
+			"limit": {fmt.Sprint(authorFeedLimit)},
+		}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		var feed authorFeed
+		err := f.xrpcQuery(ctx, "app.bsky.feed.getAuthorFeed", params, &feed)
+		if err != nil {
+			return RSS{}, err
+		}
+
+		posts = append(posts, feed.Feed...)
+		if feed.Cursor == "" || len(feed.Feed) == 0 {
+			break
+		}
+
+		if slices.ContainsFunc(feed.Feed, func(p FeedViewPost) bool {
+			return known[p.key()] ||
+				(!since.IsZero() && !p.IndexedAt().After(since))
+		}) {
+			break
+		}
+
+		cursor = feed.Cursor
+	}
+
+	if f.Cache != nil {
+		limit := 0
+		if maxPages > 0 {
+			limit = maxPages * authorFeedLimit
+		}
+
+		posts = withCachedPosts(posts, cached, limit)
+		if err := f.Cache.SetAuthorFeed(actor, posts); err != nil {
+			f.logger().Warn(
+				"Failed to cache the posts.",
+				"actor", actor,
+				"error", err,
+			)
+		}
+	}
+
+	if !since.IsZero() {
+		posts = slices.DeleteFunc(posts, func(p FeedViewPost) bool {
+			return !p.IndexedAt().After(since)
+		})
+	}
+
+	result := RSS{
+		Version: "2.0",
+		Channel: Channel{
+			Description: profile.Description,
+			Link:        ProfileURL(profile.Handle),
+			Title:       ChannelTitle(profile.ProfileViewBasic),
+		},
+	}
+	if profile.PinnedPost != nil {
+		result.Channel.Pinned = profile.PinnedPost.URI
+	}
+
+	for i := range posts {
+		item, err := NewPostItem(&posts[i])
+		if err != nil {
+			return RSS{}, err
+		}
+
+		item.Pinned = item.URI() == result.Channel.Pinned && !item.IsRepost()
+		result.Channel.Items = append(result.Channel.Items, item)
+	}
+
+	return result, nil
+}
+
+// NewPostItem creates the RSS item for a post in an author feed. The
+// description of the item is HTML that is rendered from the text and facets
+// of the post, the images and the link card that are attached to the post,
+// and the post that is quoted by the post. The item keeps
+// a reference to the post so that the richer record data can be used when
+// the output is generated. If the createdAt value of the post is not a
+// valid RFC 3339 timestamp, the value is used as the pubDate field as is so
+// that the value can be handled when the dates of the feed are rewritten.
+func NewPostItem(post *FeedViewPost) (Item, error) {
+	pubDate := post.Post.Record.CreatedAt
+	if createdAt, err := time.Parse(time.RFC3339, pubDate); err == nil {
+		pubDate = createdAt.Format(time.RFC1123Z)
+	}
+
+	embed, err := ParseEmbed(post.Post.Embed)
+	if err != nil {
+		return Item{}, fmt.Errorf("the post %s: %w", post.Post.URI, err)
+	}
+
+	attached := EmbedMedia(embed)
+	card := EmbedLinkCard(embed)
+	quote := EmbedQuote(embed)
+	return Item{
+		Link: PostURL(post.Post.Author.Handle, post.Post.URI),
+		Description: RenderRichText(
+			post.Post.Record.Text,
+			post.Post.Record.Facets,
+		) + RenderMedia(attached) + RenderLinkCard(card) + RenderQuote(quote),
+		PubDate: pubDate,
+		GUID: GUID{
+			IsPermaLink: "false",
+			Value:       post.Post.URI,
+		},
+		Text: post.Post.Record.Text,
+		Markdown: RenderMarkdown(
+			post.Post.Record.Text,
+			post.Post.Record.Facets,
+		),
+		IsHTML:     true,
+		Media:      attached,
+		Author:     &post.Post.Author,
+		Languages:  post.Post.Record.Langs,
+		Labels:     PostLabels(post.Post),
+		Quote:      quote,
+		LinkCard:   card,
+		Engagement: post.Post.engagement(),
+		Post:       post,
+	}, nil
+}
+
+// xrpcQuery calls the XRPC query method nsid and decodes the JSON response
+// into v. The query is sent to the AppView service, or to the PDS of the
+// account when the Fetcher has a session. If the access token of the
+// session has expired, the session is refreshed and the query is retried.
+func (f *Fetcher) xrpcQuery(
+	ctx context.Context,
+	nsid string,
+	params url.Values,
+	v any,
+) error {
+	for refreshed := false; ; refreshed = true {
+		serviceURL := cmp.Or(f.AppViewURL, xrpcServiceURL)
+		token := ""
+		if f.Session != nil {
+			serviceURL = f.Session.ServiceURL
+			token = f.Session.accessToken()
+		}
+
+		req, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodGet,
+			serviceURL+"/xrpc/"+nsid+"?"+params.Encode(),
+			nil,
+		)
+		if err != nil {
+			return err
+		}
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Atproto-Proxy", appViewProxy)
+		}
+
+		err = f.xrpcCall(nsid, req, v)
+		var xerr *xrpcError
+		if token == "" || refreshed || !errors.As(err, &xerr) ||
+			xerr.Name != "ExpiredToken" {
+			return err
+		}
+
+		if err = f.Session.refresh(ctx, f, token); err != nil {
+			return fmt.Errorf("failed to refresh the session: %w", err)
+		}
+	}
+}
+
+// xrpcCall sends req, which calls the XRPC method nsid, and decodes the JSON
+// response into v. An *xrpcError is returned if the method fails, and an
+// *HTMLPageError is returned if the server responds with an HTML page.
+func (f *Fetcher) xrpcCall(nsid string, req *http.Request, v any) error {
+	resp, err := f.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", nsid, err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		xerr := &xrpcError{nsid: nsid, statusCode: resp.StatusCode}
+		if json.NewDecoder(resp.Body).Decode(xerr) != nil {
+			xerr.Name = ""
+		}
+
+		return xerr
+	}
+
+	if err = checkHTMLPage(resp, "the "+nsid+" response"); err != nil {
+		return err
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		var tooLarge *ResponseTooLargeError
+		if errors.As(err, &tooLarge) {
+			return tooLarge
+		}
+
+		return &ParseError{
+			Err: fmt.Errorf("failed to parse the %s response: %w", nsid, err),
+		}
+	}
+
+	return nil
+}
+
+// ChannelTitle returns the title that Bluesky uses for the RSS feed of
+// author.
+func ChannelTitle(author ProfileViewBasic) string {
+	if author.DisplayName == "" {
+		return "@" + author.Handle
+	}
+
+	return "@" + author.Handle + " - " + author.DisplayName
+}
+
+// LinkAuthor returns the author of the post at the bsky.app web URL link.
+// The display name of the author is read from the title of the channel
+// when the channel is the feed of the author. nil is returned if link is
+// not the URL of a post.
+func LinkAuthor(link string, channel Channel) *ProfileViewBasic {
+	rest, ok := strings.CutPrefix(link, ProfileURL(""))
+	if !ok {
+		return nil
+	}
+
+	handle, _, ok := strings.Cut(rest, "/post/")
+	if !ok || handle == "" {
+		return nil
+	}
+
+	author := &ProfileViewBasic{Handle: handle}
+	title, ok := strings.CutPrefix(channel.Title, "@"+handle+" - ")
+	if ok {
+		author.DisplayName = title
+	}
+
+	return author
+}
+
+// ProfileURL returns the bsky.app web URL for the profile of the account
+// identified by handle, which can also be a DID.
+func ProfileURL(handle string) string {
+	return "https://bsky.app/profile/" + handle
+}
+
+// FeedURL returns the URL of the RSS feed that Bluesky publishes for the
+// account identified by handle, which can also be a DID. Bluesky handles
+// never contain an @, so handles such as the handles of Mastodon accounts
+// are rejected so that the feed is always downloaded from bsky.app.
+func FeedURL(handle string) (string, error) {
+	if handle == "" || strings.Contains(handle, "@") {
+		return "", fmt.Errorf("%q is not a Bluesky handle", handle)
+	}
+
+	return ProfileURL(url.PathEscape(handle)) + "/rss", nil
+}
+
+// PostURL returns the bsky.app web URL for the post identified by the AT
+// URI uri. The record key of the post is the last segment of the AT URI.
+func PostURL(handle string, uri string) string {
+	return ProfileURL(handle) + "/post/" + path.Base(uri)
+}
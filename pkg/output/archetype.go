@@ -0,0 +1,66 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// ContentPage is the data that the archetype of the content pages is
+// executed with. It contains the fields of the TemplatePost of the post
+// together with the slug, the front matter, and the Markdown body of the
+// page that is written when no archetype is used, so that an archetype can
+// add the custom parameters, layout, or cascade of the site to the page
+// while keeping the content that is generated for the post.
+type ContentPage struct {
+	TemplatePost
+
+	// Slug is the slug of the content page.
+	Slug string
+
+	// FrontMatter is the front matter that is written for the post when no
+	// archetype is used.
+	FrontMatter FrontMatter
+
+	// Body is the Markdown body of the page, which contains the text of
+	// the post followed by the link card and the quoted post.
+	Body string
+}
+
+// writeArchetype executes the archetype with the data for a content page
+// and writes the result to buf. A newline is added if the page does not end
+// with one.
+func writeArchetype(
+	buf *bytes.Buffer,
+	archetype *template.Template,
+	page ContentPage,
+) error {
+	if err := archetype.Execute(buf, page); err != nil {
+		return err
+	}
+
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"cmp"
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    AtomLink    `xml:"link"`
+	Author  AtomAuthor  `xml:"author"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type AtomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type AtomEntry struct {
+	Lang    string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    AtomLink    `xml:"link"`
+	Author  *AtomAuthor `xml:"author,omitempty"`
+	Summary string      `xml:"summary,omitempty"`
+	Content AtomText    `xml:"content"`
+}
+
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// NewAtomFeed converts the RSS feed into an Atom 1.0 feed. Atom requires
+// that every entry has a title and an updated timestamp, so the title is
+// derived from the post text and the timestamp is the publication date of
+// the post. The updated timestamp of the feed is the date of the newest
+// post.
+func NewAtomFeed(f feed.RSS) AtomFeed {
+	var updated time.Time
+	for _, item := range f.Channel.Items {
+		if item.Published.After(updated) {
+			updated = item.Published
+		}
+	}
+
+	result := AtomFeed{
+		Xmlns:   feed.AtomNamespace,
+		ID:      f.Channel.Link,
+		Title:   f.Channel.Title,
+		Updated: updated.Format(time.RFC3339),
+		Link:    AtomLink{Rel: "alternate", Href: f.Channel.Link},
+		Author: AtomAuthor{
+			Name: f.Channel.Title,
+			URI:  f.Channel.Link,
+		},
+		Entries: make([]AtomEntry, 0, len(f.Channel.Items)),
+	}
+	for _, item := range f.Channel.Items {
+		entry := AtomEntry{
+			Lang:    primaryLanguage(item),
+			ID:      item.GUID.Value,
+			Title:   cmp.Or(item.Title, PostTitle(item.PlainText())),
+			Updated: item.Published.Format(time.RFC3339),
+			Link:    AtomLink{Rel: "alternate", Href: item.Link},
+			Summary: item.Summary,
+			Content: newAtomContent(item),
+		}
+		if author := item.Author; author != nil {
+			entry.Author = &AtomAuthor{
+				Name: authorName(*author),
+				URI:  authorURL(*author),
+			}
+		}
+
+		result.Entries = append(result.Entries, entry)
+	}
+
+	return result
+}
+
+func newAtomContent(item feed.Item) AtomText {
+	if item.IsHTML {
+		return AtomText{Type: "html", Value: item.Description}
+	}
+
+	return AtomText{Type: "text", Value: item.Description}
+}
+
+func writeAtom(w io.Writer, f feed.RSS) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(NewAtomFeed(f))
+}
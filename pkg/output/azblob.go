@@ -0,0 +1,179 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// azureVersion is the version of the Azure Blob Storage REST API that the
+// requests use.
+const azureVersion = "2021-08-06"
+
+// azureStore stores objects as block blobs in an Azure Blob Storage
+// container. The requests are signed using the account key, or are
+// authorized by a shared access signature.
+type azureStore struct {
+	client    Doer
+	account   string
+	container string
+	key       []byte
+	sas       string
+}
+
+func newAzureStore(client Doer, container string) (*azureStore, error) {
+	s := &azureStore{
+		client:    client,
+		account:   os.Getenv("AZURE_STORAGE_ACCOUNT"),
+		container: container,
+		sas: strings.TrimPrefix(
+			os.Getenv("AZURE_STORAGE_SAS_TOKEN"),
+			"?",
+		),
+	}
+	if s.account == "" {
+		return nil, errors.New(
+			"the AZURE_STORAGE_ACCOUNT environment variable is required for " +
+				"azblob URLs",
+		)
+	}
+
+	if value := os.Getenv("AZURE_STORAGE_KEY"); value != "" {
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, errors.New(
+				"the AZURE_STORAGE_KEY environment variable is not a valid key",
+			)
+		}
+
+		s.key = key
+	} else if s.sas == "" {
+		return nil, errors.New(
+			"the AZURE_STORAGE_KEY or AZURE_STORAGE_SAS_TOKEN environment " +
+				"variable is required for azblob URLs",
+		)
+	}
+
+	return s, nil
+}
+
+func (s *azureStore) Put(
+	ctx context.Context,
+	key string,
+	data []byte,
+	contentType string,
+) error {
+	u := url.URL{
+		Scheme: "https",
+		Host:   s.account + ".blob.core.windows.net",
+		Path:   "/" + s.container + "/" + key,
+	}
+	if s.key == nil {
+		u.RawQuery = s.sas
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPut,
+		u.String(),
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	req.Header.Set("X-Ms-Date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("X-Ms-Version", azureVersion)
+	if s.key != nil {
+		s.sign(req, len(data))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusCreated {
+		return objectError(resp)
+	}
+
+	return nil
+}
+
+// sign signs req, whose body has length bytes, using the Shared Key
+// authorization of Azure Storage.
+func (s *azureStore) sign(req *http.Request, length int) {
+	contentLength := ""
+	if length > 0 {
+		contentLength = strconv.Itoa(length)
+	}
+
+	var headers []string
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-ms-") {
+			headers = append(headers, name+":"+strings.Join(values, ","))
+		}
+	}
+
+	slices.Sort(headers)
+	stringToSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		contentLength,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // Date, which is replaced by x-ms-date
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+	}, "\n") + "\n" + strings.Join(headers, "\n") + "\n/" + s.account +
+		req.URL.EscapedPath()
+
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(stringToSign))
+	req.Header.Set(
+		"Authorization",
+		"SharedKey "+s.account+":"+
+			base64.StdEncoding.EncodeToString(h.Sum(nil)),
+	)
+}
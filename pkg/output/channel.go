@@ -0,0 +1,86 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"slices"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// Generator is the value of the generator element of the RSS output.
+const Generator = "hugoify-bluesky-rss-feed"
+
+// withChannelElements returns a copy of the feed with the channel elements
+// that Bluesky does not include in its feeds. The lastBuildDate element is
+// the date of the newest item so that rendering the same feed produces the
+// same output. The language element is only replaced when opts.Language is
+// set, and an atom:link element that refers to opts.SelfURL replaces any
+// self link of the original feed.
+func withChannelElements(f feed.RSS, opts Options) feed.RSS {
+	var updated time.Time
+	for _, item := range f.Channel.Items {
+		if item.Published.After(updated) {
+			updated = item.Published
+		}
+	}
+
+	if !updated.IsZero() {
+		f.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	f.Channel.Generator = Generator
+	if opts.Language != "" {
+		f.Channel.Language = opts.Language
+	}
+
+	if opts.SelfURL != "" {
+		f.XMLNSAtom = feed.AtomNamespace
+		f.Channel.SelfLink = &feed.AtomLink{
+			Href: opts.SelfURL,
+			Rel:  "self",
+			Type: "application/rss+xml",
+		}
+		f.Channel.Extensions = slices.DeleteFunc(
+			slices.Clone(f.Channel.Extensions),
+			isSelfLink,
+		)
+	}
+
+	return f
+}
+
+// isSelfLink reports whether the extension element is an atom:link element
+// with the self relation.
+func isSelfLink(ext feed.Extension) bool {
+	if ext.Namespace != feed.AtomNamespace {
+		return false
+	}
+
+	for _, attr := range ext.Attrs {
+		if attr.Name.Local == "rel" && attr.Value == "self" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"path/filepath"
+	"strings"
+)
+
+// ChecksumsFile is the name of the file in an output directory that
+// contains the checksums of the files in the directory.
+const ChecksumsFile = "SHA256SUMS"
+
+// WithChecksums returns a copy of the output files that are written to
+// path with a file that contains the SHA-256 checksums of the files, in the
+// format that sha256sum writes and verifies. The checksums of the files of
+// an output directory are written to ChecksumsFile in the directory, and
+// the checksums of a single output file and of the files that are written
+// next to it are written next to the file, using the name of the file
+// followed by .sha256. If signer is not nil, the
+// checksum file is signed and the signature is written next to the
+// checksum file with a .sig extension.
+func (o Files) WithChecksums(path string, signer *Signer) Files {
+	var sums bytes.Buffer
+	name := ChecksumsFile
+	_, single := o[""]
+	if single {
+		// The name is relative to path, which is the output file, so the
+		// checksum file is written to the directory of the output file.
+		name = "../" + filepath.Base(path) + ".sha256"
+	}
+
+	for _, file := range o.Names() {
+		switch {
+		case file == "":
+			writeChecksum(&sums, filepath.Base(path), o[file])
+		case single:
+			writeChecksum(&sums, strings.TrimPrefix(file, "../"), o[file])
+		default:
+			writeChecksum(&sums, file, o[file])
+		}
+	}
+
+	result := maps.Clone(o)
+	result[name] = sums.Bytes()
+	if signer != nil {
+		result[name+".sig"] = signer.Sign(sums.Bytes())
+	}
+
+	return result
+}
+
+// writeChecksum writes the line of the checksum file for the file name
+// with the content data to buf.
+func writeChecksum(buf *bytes.Buffer, name string, data []byte) {
+	sum := sha256.Sum256(data)
+	buf.WriteString(hex.EncodeToString(sum[:]) + "  " + name + "\n")
+}
@@ -0,0 +1,285 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+type FrontMatter struct {
+	Title        string `json:"title" yaml:"title" toml:"title"`
+	Summary      string `json:"summary,omitempty" yaml:"summary,omitempty" toml:"summary,omitempty"`
+	Date         string `json:"date" yaml:"date" toml:"date"`
+	Slug         string `json:"slug" yaml:"slug" toml:"slug"`
+	CanonicalURL string `json:"canonicalURL" yaml:"canonicalURL" toml:"canonicalURL"`
+	Handle       string `json:"handle,omitempty" yaml:"handle,omitempty" toml:"handle,omitempty"`
+	Author       string `json:"author,omitempty" yaml:"author,omitempty" toml:"author,omitempty"`
+	Avatar       string `json:"avatar,omitempty" yaml:"avatar,omitempty" toml:"avatar,omitempty"`
+	DID          string `json:"did,omitempty" yaml:"did,omitempty" toml:"did,omitempty"`
+
+	Languages []string `json:"languages,omitempty" yaml:"languages,omitempty" toml:"languages,omitempty"`
+	Labels    []string `json:"labels,omitempty" yaml:"labels,omitempty" toml:"labels,omitempty"`
+
+	Engagement *feed.Engagement `json:"engagement,omitempty" yaml:"engagement,omitempty" toml:"engagement,omitempty"`
+	Pinned     bool             `json:"pinned,omitempty" yaml:"pinned,omitempty" toml:"pinned,omitempty"`
+	Deleted    bool             `json:"deleted,omitempty" yaml:"deleted,omitempty" toml:"deleted,omitempty"`
+	Draft      bool             `json:"draft,omitempty" yaml:"draft,omitempty" toml:"draft,omitempty"`
+
+	Media    []FrontMatterMedia   `json:"media,omitempty" yaml:"media,omitempty" toml:"media,omitempty"`
+	LinkCard *FrontMatterLinkCard `json:"linkCard,omitempty" yaml:"linkCard,omitempty" toml:"linkCard,omitempty"`
+
+	// Taxonomies contains the terms of the Hugo taxonomies that the post
+	// is assigned to, keyed by the name of the taxonomy. The taxonomies are
+	// written as top-level fields of the front matter.
+	Taxonomies map[string][]string `json:"-" yaml:",inline" toml:"-"`
+}
+
+// FrontMatterMedia describes an image or video that is attached to a post,
+// including its alternative text, so that the theme of the site can render
+// accessible images.
+type FrontMatterMedia struct {
+	Type      string `json:"type" yaml:"type" toml:"type"`
+	URL       string `json:"url" yaml:"url" toml:"url"`
+	Alt       string `json:"alt,omitempty" yaml:"alt,omitempty" toml:"alt,omitempty"`
+	Thumbnail string `json:"thumbnail,omitempty" yaml:"thumbnail,omitempty" toml:"thumbnail,omitempty"`
+	Width     int    `json:"width,omitempty" yaml:"width,omitempty" toml:"width,omitempty"`
+	Height    int    `json:"height,omitempty" yaml:"height,omitempty" toml:"height,omitempty"`
+}
+
+// FrontMatterLinkCard contains the link card of a post when the link card is
+// written to the front matter of the content page.
+type FrontMatterLinkCard struct {
+	URL         string `json:"url" yaml:"url" toml:"url"`
+	Title       string `json:"title,omitempty" yaml:"title,omitempty" toml:"title,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Image       string `json:"image,omitempty" yaml:"image,omitempty" toml:"image,omitempty"`
+}
+
+// RenderContent renders one Markdown content page for each item in the feed.
+// Each page contains front matter derived from the Bluesky post, written in
+// the format of opts.FrontMatter, followed by the post text, converted into
+// Markdown, as the body of the page. The post that is quoted by the post is
+// added to the body as a blockquote. The link card of the post is added to
+// the body as well, or to the front matter if opts.LinkCardFrontMatter is
+// true. The hashtags of the post are assigned to Hugo taxonomies using
+// opts.Taxonomies. The pages are returned keyed by their file names, which
+// are given by ContentFileName for the slugs that ItemSlug derives using
+// opts. If opts.Archetype is set, the pages are rendered by executing the
+// archetype with the ContentPage of each post instead. An error is returned
+// if the pages of two items would have the same file name, so that a page
+// does not silently replace the page of another post.
+func RenderContent(f feed.RSS, opts Options) (Files, error) {
+	files := make(Files, len(f.Channel.Items))
+	links := make(map[string]string, len(f.Channel.Items))
+	for _, item := range f.Channel.Items {
+		slug, err := ItemSlug(item, opts)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to create the slug of %s: %w",
+				item.Link,
+				err,
+			)
+		}
+
+		name := ContentFileName(slug, opts.PageBundles)
+		if link, ok := links[name]; ok {
+			return nil, fmt.Errorf(
+				"the slug %q of %s is already used by %s",
+				slug,
+				item.Link,
+				link,
+			)
+		}
+
+		links[name] = item.Link
+
+		matter := FrontMatter{
+			Title:        cmp.Or(item.Title, PostTitle(item.PlainText())),
+			Summary:      item.Summary,
+			Date:         item.PubDate,
+			Slug:         slug,
+			CanonicalURL: item.Link,
+			Languages:    item.Languages,
+			Labels:       item.Labels,
+			Engagement:   item.Engagement,
+			Pinned:       item.Pinned,
+			Deleted:      item.Deleted,
+			Draft:        item.Draft,
+			Taxonomies:   opts.Taxonomies.Assign(item.Hashtags()),
+		}
+		if author := item.Author; author != nil {
+			matter.Handle = author.Handle
+			matter.Author = authorName(*author)
+			matter.Avatar = author.Avatar
+			matter.DID = author.DID
+		}
+
+		for _, m := range item.Media {
+			matter.Media = append(matter.Media, FrontMatterMedia{
+				Type:      m.Medium,
+				URL:       m.URL,
+				Alt:       m.Alt,
+				Thumbnail: m.Thumbnail,
+				Width:     m.Width,
+				Height:    m.Height,
+			})
+		}
+
+		if card := item.LinkCard; card != nil && opts.LinkCardFrontMatter {
+			matter.LinkCard = &FrontMatterLinkCard{
+				URL:         card.URL,
+				Title:       card.Title,
+				Description: card.Description,
+				Image:       card.Thumbnail,
+			}
+		}
+
+		body := contentBody(item, opts)
+		var buf bytes.Buffer
+		if opts.Archetype != nil {
+			err = writeArchetype(&buf, opts.Archetype, ContentPage{
+				TemplatePost: NewTemplatePost(item),
+				Slug:         slug,
+				FrontMatter:  matter,
+				Body:         body,
+			})
+			if err != nil {
+				return nil, fmt.Errorf(
+					"failed to execute the archetype for %s: %w",
+					item.Link,
+					err,
+				)
+			}
+		} else {
+			err = writeFrontMatter(&buf, opts.FrontMatter, matter)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"failed to write the front matter: %w",
+					err,
+				)
+			}
+
+			buf.WriteString(body)
+		}
+
+		files[name] = buf.Bytes()
+	}
+
+	return files, nil
+}
+
+// contentBody returns the Markdown body of the content page of item, which
+// is the text of the post followed by the link card and the quoted post.
+func contentBody(item feed.Item, opts Options) string {
+	var b strings.Builder
+	b.WriteString(item.MarkdownText())
+	b.WriteString("\n")
+	if item.LinkCard != nil && !opts.LinkCardFrontMatter {
+		b.WriteString("\n")
+		b.WriteString(linkCardMarkdown(item.LinkCard))
+	}
+
+	if item.Quote != nil {
+		b.WriteString("\n")
+		b.WriteString(quoteMarkdown(item.Quote))
+	}
+
+	return b.String()
+}
+
+// linkCardMarkdown renders a link card as a Markdown blockquote that
+// contains a link to the page and the description of the page.
+func linkCardMarkdown(c *feed.LinkCard) string {
+	title := c.Title
+	if title == "" {
+		title = c.URL
+	}
+
+	var b strings.Builder
+	b.WriteString(
+		"> [" + feed.EscapeMarkdown(title) + "](<" + c.URL + ">)\n",
+	)
+	if description := feed.EscapeMarkdown(c.Description); description != "" {
+		b.WriteString(">\n")
+		b.WriteString(blockquoteMarkdown(description))
+	}
+
+	return b.String()
+}
+
+// quoteMarkdown renders a quoted post as a Markdown blockquote that contains
+// the author, the text, and a link to the quoted post.
+func quoteMarkdown(q *feed.Quote) string {
+	author := feed.EscapeMarkdown("@" + q.Author.Handle)
+	if q.Author.DisplayName != "" {
+		author = "**" + feed.EscapeMarkdown(q.Author.DisplayName) + "** (" +
+			author + ")"
+	}
+
+	var b strings.Builder
+	b.WriteString("> " + author + "\n>\n")
+	b.WriteString(blockquoteMarkdown(feed.RenderMarkdown(q.Text, q.Facets)))
+	b.WriteString(">\n> <" + q.URL + ">\n")
+	return b.String()
+}
+
+// blockquoteMarkdown prefixes each line of the Markdown text with > so that
+// the text is written as a blockquote.
+func blockquoteMarkdown(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(strings.TrimRight("> "+line, " ") + "\n")
+	}
+
+	return b.String()
+}
+
+// ContentFileName returns the name of the content page of the post with the
+// slug relative to the output path. The page is named after the slug, or is
+// the index.md file of a page bundle that is named after the slug if bundle
+// is true.
+func ContentFileName(slug string, bundle bool) string {
+	if bundle {
+		return slug + "/index.md"
+	}
+
+	return slug + ".md"
+}
+
+// PostSlug returns the record key of the post, which is the last segment of
+// the bsky.app post URL. The record key is unique for an account and is
+// safe to use in a file name and URL.
+func PostSlug(item feed.Item) string {
+	if u, err := url.Parse(item.Link); err == nil {
+		if slug := path.Base(u.Path); slug != "" && slug != "/" && slug != "." {
+			return slug
+		}
+	}
+
+	return path.Base(item.GUID.Value)
+}
@@ -0,0 +1,276 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines that are shown around each
+// change in a unified diff.
+const diffContext = 3
+
+// Diff returns a unified diff of the files that exist at path and the
+// output files. Files that do not exist yet are compared with /dev/null,
+// and files whose content has not changed are not included in the diff. An
+// empty diff is returned if none of the files have changed.
+func (o Files) Diff(path string) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, name := range o.Names() {
+		target := filepath.Join(path, name)
+		oldName := target
+		existing, err := os.ReadFile(target)
+		if errors.Is(err, fs.ErrNotExist) {
+			oldName = "/dev/null"
+		} else if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(existing, o[name]) {
+			continue
+		}
+
+		if isBinary(existing) || isBinary(o[name]) {
+			fmt.Fprintf(
+				&buf,
+				"Binary files %s and %s differ\n",
+				oldName,
+				target,
+			)
+			continue
+		}
+
+		writeUnifiedDiff(&buf, oldName, target, existing, o[name])
+	}
+
+	return buf.Bytes(), nil
+}
+
+// isBinary reports whether data is binary, such as a compressed file,
+// instead of text that can be compared line by line. Like git, data is
+// binary if its first 8000 bytes contain a NUL byte.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+// diffOp is an operation of an edit script that transforms one list of
+// lines into another. The kind of the operation is ' ' for a line that is
+// kept, '-' for a line that is removed, or '+' for a line that is added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// writeUnifiedDiff writes a unified diff of a and b to buf.
+func writeUnifiedDiff(
+	buf *bytes.Buffer,
+	oldName string,
+	newName string,
+	a []byte,
+	b []byte,
+) {
+	ops := diffLines(splitLines(a), splitLines(b))
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", oldName, newName)
+
+	// oldLine and newLine are the line numbers before ops[i].
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// The hunk starts with the context before the change and ends
+		// when there are more than twice the context lines between two
+		// changes.
+		start := max(i-diffContext, 0)
+		for j := start; j < i; j++ {
+			oldLine--
+			newLine--
+		}
+
+		end := i
+		for end < len(ops) {
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+
+			if next == len(ops) || next-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+
+			for next < len(ops) && ops[next].kind != ' ' {
+				next++
+			}
+
+			end = next
+		}
+
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(
+			buf,
+			"@@ -%s +%s @@\n",
+			hunkRange(oldLine, oldCount),
+			hunkRange(newLine, newCount),
+		)
+		for _, op := range ops[start:end] {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		oldLine += oldCount
+		newLine += newCount
+		i = end
+	}
+}
+
+// hunkRange formats the range of lines of a hunk. The line numbers of the
+// hunk start at line+1, unless the hunk does not contain any lines.
+func hunkRange(line int, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", line)
+	case 1:
+		return fmt.Sprint(line + 1)
+	default:
+		return fmt.Sprintf("%d,%d", line+1, count)
+	}
+}
+
+// splitLines splits data into lines. The lines keep their line endings so
+// that a missing newline at the end of the data is detected.
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines computes the shortest edit script that transforms a into b
+// using the algorithm described in "An O(ND) Difference Algorithm and Its
+// Variations" by Eugene W. Myers.
+func diffLines(a []string, b []string) []diffOp {
+	n, m := len(a), len(b)
+
+	// trace[d] contains the furthest reaching x for each diagonal k in
+	// -d..d after d edits, stored at index k+d.
+	var trace [][]int
+	prev := []int{0}
+	found := false
+	for d := 0; d <= n+m && !found; d++ {
+		v := make([]int, 2*d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			switch {
+			case d == 0:
+				x = 0
+			case k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]):
+				x = prev[k+1+d-1]
+			default:
+				x = prev[k-1+d-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[k+d] = x
+			if x >= n && y >= m {
+				found = true
+			}
+		}
+
+		trace = append(trace, v)
+		prev = v
+	}
+
+	// Walk back from the end of both lists to recover the edit script.
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		prevX := 0
+		if d > 0 {
+			v := trace[d-1]
+			prevK := k - 1
+			if k == -d || (k != d && v[k-1+d-1] < v[k+1+d-1]) {
+				prevK = k + 1
+			}
+
+			prevX = v[prevK+d-1]
+			prevY := prevX - prevK
+			for x > prevX && y > prevY {
+				x--
+				y--
+				ops = append(ops, diffOp{kind: ' ', line: a[x]})
+			}
+
+			if x == prevX {
+				y--
+				ops = append(ops, diffOp{kind: '+', line: b[y]})
+			} else {
+				x--
+				ops = append(ops, diffOp{kind: '-', line: a[x]})
+			}
+
+			continue
+		}
+
+		for x > 0 && y > 0 {
+			x--
+			y--
+			ops = append(ops, diffOp{kind: ' ', line: a[x]})
+		}
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+
+	return ops
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/xml"
+	"html"
+	"slices"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// withItemElements returns a copy of the feed with the dc:creator and
+// dc:language elements of the items. The elements are added using the
+// Dublin Core namespace, which is declared by the rss element.
+func withItemElements(f feed.RSS) feed.RSS {
+	return withLanguageElements(withCreatorElements(f))
+}
+
+// withCreatorElements returns a copy of the feed with a dc:creator element
+// that contains the name of the author of each item. The dc:creator element
+// of an item of the source feed names the author better than the author
+// that is derived from the link of the item and the title of the channel,
+// so it is only replaced for the posts that were fetched from the AT
+// Protocol API, whose authors are known. Like the dc:language elements, the
+// dc:creator elements of items whose authors are not known are kept.
+func withCreatorElements(f feed.RSS) feed.RSS {
+	f.Channel.Items = slices.Clone(f.Channel.Items)
+	for i := range f.Channel.Items {
+		item := &f.Channel.Items[i]
+		if item.Author == nil {
+			continue
+		}
+
+		if item.Post == nil &&
+			slices.ContainsFunc(item.Extensions, isDublinCore("creator")) {
+			continue
+		}
+
+		item.Extensions = append(
+			withoutDublinCore(item.Extensions, "creator"),
+			dublinCore("creator", authorName(*item.Author)),
+		)
+	}
+
+	return f
+}
+
+// withLanguageElements returns a copy of the feed with a dc:language element
+// for each language of the items. The dc:language elements of items whose
+// languages are not known, such as items that were merged from previous
+// output, are kept.
+func withLanguageElements(f feed.RSS) feed.RSS {
+	f.Channel.Items = slices.Clone(f.Channel.Items)
+	for i := range f.Channel.Items {
+		item := &f.Channel.Items[i]
+		if len(item.Languages) == 0 {
+			continue
+		}
+
+		item.Extensions = withoutDublinCore(item.Extensions, "language")
+		for _, lang := range item.Languages {
+			item.Extensions = append(
+				item.Extensions,
+				dublinCore("language", lang),
+			)
+		}
+	}
+
+	return f
+}
+
+// dublinCore returns a Dublin Core element named name that contains value.
+func dublinCore(name string, value string) feed.Extension {
+	return feed.Extension{
+		XMLName:   xml.Name{Local: "dc:" + name},
+		InnerXML:  html.EscapeString(value),
+		Namespace: feed.DublinCoreNamespace,
+	}
+}
+
+// withoutDublinCore returns a copy of extensions without the Dublin Core
+// elements named name.
+func withoutDublinCore(
+	extensions []feed.Extension,
+	name string,
+) []feed.Extension {
+	return slices.DeleteFunc(slices.Clone(extensions), isDublinCore(name))
+}
+
+// isDublinCore returns a function that reports whether an extension is a
+// Dublin Core element named name.
+func isDublinCore(name string) func(feed.Extension) bool {
+	return func(ext feed.Extension) bool {
+		return ext.Namespace == feed.DublinCoreNamespace &&
+			ext.XMLName.Local == "dc:"+name
+	}
+}
+
+// primaryLanguage returns the first language of the item, or an empty
+// string if the languages of the item are not known. Formats that only
+// support a single language per item use the first language.
+func primaryLanguage(item feed.Item) string {
+	if len(item.Languages) == 0 {
+		return ""
+	}
+
+	return item.Languages[0]
+}
+
+// languageList returns the language in a list, or nil if lang is empty. It
+// is the inverse of primaryLanguage for the items that are read from
+// previous output.
+func languageList(lang string) []string {
+	if lang == "" {
+		return nil
+	}
+
+	return []string{lang}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/xml"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// engagementElements are the names of the elements of the Bluesky namespace
+// that contain the engagement counts of an item in the RSS output.
+var engagementElements = []string{"replies", "reposts", "likes", "quotes"}
+
+// withEngagementElements returns a copy of the feed with bsky:replies,
+// bsky:reposts, bsky:likes, and bsky:quotes elements that contain the
+// engagement counts of the items. The elements of items whose counts are not
+// known, such as items that were merged from previous output, are kept.
+func withEngagementElements(f feed.RSS) feed.RSS {
+	f.Channel.Items = slices.Clone(f.Channel.Items)
+	for i := range f.Channel.Items {
+		item := &f.Channel.Items[i]
+		e := item.Engagement
+		if e == nil {
+			continue
+		}
+
+		item.Extensions = slices.DeleteFunc(
+			slices.Clone(item.Extensions),
+			isEngagementElement,
+		)
+		for j, count := range []int{e.Replies, e.Reposts, e.Likes, e.Quotes} {
+			item.Extensions = append(item.Extensions, feed.Extension{
+				XMLName:   xml.Name{Local: "bsky:" + engagementElements[j]},
+				InnerXML:  strconv.Itoa(count),
+				Namespace: feed.BlueskyNamespace,
+			})
+		}
+	}
+
+	return f
+}
+
+// itemEngagement returns a copy of an item that was read from RSS output
+// with the engagement counts that were written by withEngagementElements. The
+// elements are removed from the extensions of the item so that they are
+// replaced when the item is written again.
+func itemEngagement(item feed.Item) feed.Item {
+	if !slices.ContainsFunc(item.Extensions, isEngagementElement) {
+		return item
+	}
+
+	var e feed.Engagement
+	counts := []*int{&e.Replies, &e.Reposts, &e.Likes, &e.Quotes}
+	for _, ext := range item.Extensions {
+		_, name, _ := strings.Cut(ext.XMLName.Local, ":")
+		i := slices.Index(engagementElements, name)
+		if ext.Namespace != feed.BlueskyNamespace || i < 0 {
+			continue
+		}
+
+		if n, err := strconv.Atoi(strings.TrimSpace(ext.InnerXML)); err == nil {
+			*counts[i] = n
+		}
+	}
+
+	item.Engagement = &e
+	item.Extensions = slices.DeleteFunc(
+		slices.Clone(item.Extensions),
+		isEngagementElement,
+	)
+	return item
+}
+
+// isEngagementElement reports whether ext is one of the elements that
+// contain the engagement counts of an item.
+func isEngagementElement(ext feed.Extension) bool {
+	if ext.Namespace != feed.BlueskyNamespace {
+		return false
+	}
+
+	_, name, _ := strings.Cut(ext.XMLName.Local, ":")
+	return slices.Contains(engagementElements, name)
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatterFormat is the format that the front matter of the content
+// pages is written in. Hugo detects the format of the front matter of a
+// page using the delimiters of the front matter.
+type FrontMatterFormat string
+
+const (
+	// YAMLFrontMatter writes the front matter as YAML between --- lines.
+	YAMLFrontMatter FrontMatterFormat = "yaml"
+
+	// TOMLFrontMatter writes the front matter as TOML between +++ lines.
+	TOMLFrontMatter FrontMatterFormat = "toml"
+
+	// JSONFrontMatter writes the front matter as a JSON object at the start
+	// of the page.
+	JSONFrontMatter FrontMatterFormat = "json"
+)
+
+// FrontMatterFormats are the supported front matter formats.
+var FrontMatterFormats = []FrontMatterFormat{
+	YAMLFrontMatter, TOMLFrontMatter, JSONFrontMatter,
+}
+
+// writeFrontMatter writes the front matter of a content page to buf using
+// format, including the delimiters of the front matter, followed by the
+// blank line that separates the front matter from the body of the page.
+// YAML is used if format is empty.
+func writeFrontMatter(
+	buf *bytes.Buffer,
+	format FrontMatterFormat,
+	matter FrontMatter,
+) error {
+	switch format {
+	case "", YAMLFrontMatter:
+		buf.WriteString("---\n")
+		encoder := yaml.NewEncoder(buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(matter); err != nil {
+			return err
+		}
+
+		if err := encoder.Close(); err != nil {
+			return err
+		}
+
+		buf.WriteString("---\n\n")
+	case TOMLFrontMatter:
+		// TOML does not allow keys to follow the tables of the front
+		// matter, such as the engagement table, so the taxonomies are
+		// written before the fields of FrontMatter.
+		buf.WriteString("+++\n")
+		encoder := toml.NewEncoder(buf)
+		if len(matter.Taxonomies) > 0 {
+			if err := encoder.Encode(matter.Taxonomies); err != nil {
+				return err
+			}
+		}
+
+		if err := encoder.Encode(matter); err != nil {
+			return err
+		}
+
+		buf.WriteString("+++\n\n")
+	case JSONFrontMatter:
+		// The taxonomies are merged into the object of the front matter
+		// after the fields of FrontMatter, which is where the YAML front
+		// matter has them as well.
+		data, err := marshalJSON(matter)
+		if err != nil {
+			return err
+		}
+
+		if len(matter.Taxonomies) > 0 {
+			terms, err := marshalJSON(matter.Taxonomies)
+			if err != nil {
+				return err
+			}
+
+			data = append(append(data[:len(data)-1], ','), terms[1:]...)
+		}
+
+		if err = json.Indent(buf, data, "", "  "); err != nil {
+			return err
+		}
+
+		buf.WriteString("\n\n")
+	default:
+		return fmt.Errorf("unsupported front matter format %q", format)
+	}
+
+	return nil
+}
+
+// marshalJSON returns the JSON encoding of v without escaping the HTML
+// characters in strings and without a trailing newline.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// gcsStore stores objects in a Google Cloud Storage bucket using the media
+// uploads of the JSON API.
+type gcsStore struct {
+	client   Doer
+	bucket   string
+	endpoint string
+	token    string
+}
+
+func newGCSStore(client Doer, bucket string) (*gcsStore, error) {
+	s := &gcsStore{
+		client:   client,
+		bucket:   bucket,
+		endpoint: "https://storage.googleapis.com",
+		token:    os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+	}
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		s.endpoint = "http://" + host
+	} else if s.token == "" {
+		return nil, errors.New(
+			"the GOOGLE_OAUTH_ACCESS_TOKEN environment variable is required " +
+				"for gs URLs",
+		)
+	}
+
+	return s, nil
+}
+
+func (s *gcsStore) Put(
+	ctx context.Context,
+	key string,
+	data []byte,
+	contentType string,
+) error {
+	query := url.Values{"uploadType": {"media"}, "name": {key}}
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPost,
+		s.endpoint+"/upload/storage/v1/b/"+url.PathEscape(s.bucket)+"/o?"+
+			query.Encode(),
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return objectError(resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"compress/gzip"
+	"maps"
+	"path/filepath"
+)
+
+// WithGzip returns a copy of the output files that are written to path with
+// a gzip-compressed copy of the output file, which is written next to the
+// output file using the name of the file followed by .gz, for web servers
+// that serve precompressed files. The output of a format that writes
+// multiple files is returned unchanged. The compressed copy does not
+// contain a modification time, so it only changes when the output changes.
+func (o Files) WithGzip(path string) Files {
+	data, ok := o[""]
+	if !ok {
+		return o
+	}
+
+	var buf bytes.Buffer
+	w, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+
+	// Writing to a bytes.Buffer cannot fail.
+	_, _ = w.Write(data)
+	_ = w.Close()
+
+	result := maps.Clone(o)
+	result["../"+filepath.Base(path)+".gz"] = buf.Bytes()
+	return result
+}
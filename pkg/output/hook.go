@@ -0,0 +1,123 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
+)
+
+// RewriteFields are the fields of a post that can be rewritten using a
+// template, in the order that the rewrites are applied. The description
+// field is an HTML fragment, while the text field replaces the description
+// with plain text.
+var RewriteFields = []string{"title", "summary", "description", "text"}
+
+// ParseHookTemplate parses the text of a template that is executed with
+// the TemplatePost of each post by the transformers that are returned by
+// FilterTransformer and RewriteTransformer. The template has the same
+// functions as the templates of the template format.
+func ParseHookTemplate(name string, text string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs).Parse(text)
+}
+
+// FilterTransformer returns a transformer that executes tmpl with the
+// TemplatePost of each item and drops the items for which the result is
+// false. The result of the template must be true or false after the
+// surrounding whitespace is removed, so that a mistake in the template does
+// not silently remove every post; any other result is an error.
+func FilterTransformer(tmpl *template.Template) transform.Transformer {
+	return transform.TransformerFunc(func(item feed.Item) (feed.Item, error) {
+		result, err := executeHook(tmpl, item)
+		if err != nil {
+			return item, err
+		}
+
+		keep, err := strconv.ParseBool(result)
+		if err != nil {
+			return item, fmt.Errorf(
+				"the %s template returned %q instead of true or false",
+				tmpl.Name(),
+				result,
+			)
+		}
+
+		if !keep {
+			return item, transform.ErrDrop
+		}
+
+		return item, nil
+	})
+}
+
+// RewriteTransformer returns a transformer that replaces field, which is
+// one of RewriteFields, with the result of executing tmpl with the
+// TemplatePost of each item. The surrounding whitespace of the result is
+// removed.
+func RewriteTransformer(
+	field string,
+	tmpl *template.Template,
+) transform.Transformer {
+	return transform.TransformerFunc(func(item feed.Item) (feed.Item, error) {
+		result, err := executeHook(tmpl, item)
+		if err != nil {
+			return item, err
+		}
+
+		switch field {
+		case "title":
+			item.Title = result
+		case "summary":
+			item.Summary = result
+		case "description":
+			item.Description = result
+			item.Text = transform.HTMLText(result)
+			item.IsHTML = true
+			item.Markdown = ""
+		case "text":
+			item.Description = result
+			item.Text = ""
+			item.IsHTML = false
+			item.Markdown = ""
+		default:
+			return item, fmt.Errorf("the %s field cannot be rewritten", field)
+		}
+
+		return item, nil
+	})
+}
+
+// executeHook executes tmpl with the TemplatePost of item and returns the
+// result without the surrounding whitespace.
+func executeHook(tmpl *template.Template, item feed.Item) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, NewTemplatePost(item)); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
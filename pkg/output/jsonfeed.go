@@ -0,0 +1,106 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+const jsonFeedVersion = "https://jsonfeed.org/version/1.1"
+
+type JSONFeed struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Authors     []JSONFeedAuthor `json:"authors,omitempty"`
+	Items       []JSONFeedItem   `json:"items"`
+}
+
+type JSONFeedAuthor struct {
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+type JSONFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentHTML   string           `json:"content_html"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished string           `json:"date_published,omitempty"`
+	Authors       []JSONFeedAuthor `json:"authors,omitempty"`
+	Language      string           `json:"language,omitempty"`
+
+	// Engagement is the _engagement extension of the item, which contains
+	// the engagement counts of the post.
+	Engagement *feed.Engagement `json:"_engagement,omitempty"`
+}
+
+// NewJSONFeed converts the RSS feed into a JSON Feed 1.1 document.
+func NewJSONFeed(f feed.RSS) JSONFeed {
+	result := JSONFeed{
+		Version:     jsonFeedVersion,
+		Title:       f.Channel.Title,
+		HomePageURL: f.Channel.Link,
+		Description: f.Channel.Description,
+		Authors: []JSONFeedAuthor{
+			{Name: f.Channel.Title, URL: f.Channel.Link},
+		},
+		Items: make([]JSONFeedItem, 0, len(f.Channel.Items)),
+	}
+	for _, item := range f.Channel.Items {
+		entry := JSONFeedItem{
+			ID:            item.GUID.Value,
+			URL:           item.Link,
+			Title:         cmp.Or(item.Title, PostTitle(item.PlainText())),
+			ContentHTML:   item.HTML(),
+			Summary:       item.Summary,
+			DatePublished: item.Published.Format(time.RFC3339),
+			Language:      primaryLanguage(item),
+			Engagement:    item.Engagement,
+		}
+		if author := item.Author; author != nil {
+			entry.Authors = []JSONFeedAuthor{{
+				Name:   authorName(*author),
+				URL:    authorURL(*author),
+				Avatar: author.Avatar,
+			}}
+		}
+
+		result.Items = append(result.Items, entry)
+	}
+
+	return result
+}
+
+func writeJSONFeed(w io.Writer, f feed.RSS) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewJSONFeed(f))
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// withMediaElements returns a copy of the feed with an enclosure element and
+// media:content elements for the images and videos that are attached to the
+// items in the feed. RSS only allows a single enclosure per item, so the
+// enclosure is the first image or video, while every attachment is listed
+// using media:content. The alternative text of an attachment is written as
+// its media:description element.
+func withMediaElements(f feed.RSS) feed.RSS {
+	f.Channel.Items = slices.Clone(f.Channel.Items)
+	for i := range f.Channel.Items {
+		item := &f.Channel.Items[i]
+		if len(item.Media) > 0 {
+			first := item.Media[0]
+			item.Enclosure = &feed.Enclosure{
+				URL:    first.URL,
+				Length: strconv.FormatInt(first.Size, 10),
+				Type:   first.MIMEType,
+			}
+			item.MediaContent = make([]feed.MediaContent, 0, len(item.Media))
+			for _, m := range item.Media {
+				content := feed.MediaContent{
+					URL:      m.URL,
+					Type:     m.MIMEType,
+					Medium:   m.Medium,
+					Width:    m.Width,
+					Height:   m.Height,
+					FileSize: m.Size,
+				}
+				if m.Thumbnail != "" {
+					content.Thumbnail = &feed.MediaThumbnail{URL: m.Thumbnail}
+				}
+
+				if m.Alt != "" {
+					content.Description = &feed.MediaDescription{
+						Type:  "plain",
+						Value: m.Alt,
+					}
+				}
+
+				item.MediaContent = append(item.MediaContent, content)
+			}
+		}
+
+		if len(item.MediaContent) > 0 {
+			f.XMLNSMedia = feed.MediaRSSNamespace
+		}
+	}
+
+	return f
+}
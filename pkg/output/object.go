@@ -0,0 +1,214 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// Doer sends HTTP requests. *http.Client and *feed.Fetcher are Doers.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// ObjectStore stores objects in a bucket or container of an object store,
+// such as Amazon S3, Google Cloud Storage, or Azure Blob Storage.
+type ObjectStore interface {
+	// Put stores data as the object key, replacing the object if it
+	// already exists.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+}
+
+// objectSchemes are the schemes of the URLs of the objects that can be
+// written by an ObjectSink.
+var objectSchemes = []string{"s3", "gs", "azblob"}
+
+// IsObjectURL reports whether path is the URL of an object in an object
+// store, such as s3://bucket/feed.xml, instead of the path of a file.
+func IsObjectURL(path string) bool {
+	scheme, _, ok := strings.Cut(path, "://")
+	if !ok {
+		return false
+	}
+
+	for _, s := range objectSchemes {
+		if strings.EqualFold(scheme, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OpenObjectStore returns the object store of the object URL rawURL and
+// the key of the object. The object stores read their credentials from the
+// environment:
+//
+//   - s3://bucket/key uses AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, the
+//     optional AWS_SESSION_TOKEN, and AWS_REGION or AWS_DEFAULT_REGION.
+//     AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL selects an S3-compatible
+//     service, whose objects are addressed using path-style URLs.
+//   - gs://bucket/key uses the OAuth 2.0 access token in
+//     GOOGLE_OAUTH_ACCESS_TOKEN, or the emulator in STORAGE_EMULATOR_HOST.
+//   - azblob://container/key uses AZURE_STORAGE_ACCOUNT and either the
+//     account key in AZURE_STORAGE_KEY or a shared access signature in
+//     AZURE_STORAGE_SAS_TOKEN.
+//
+// The requests are sent using client.
+func OpenObjectStore(rawURL string, client Doer) (ObjectStore, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := strings.Trim(u.Path, "/")
+	if u.Host == "" || key == "" {
+		return nil, "", fmt.Errorf(
+			"the object URL %s must name a bucket and an object",
+			rawURL,
+		)
+	}
+
+	var store ObjectStore
+	switch strings.ToLower(u.Scheme) {
+	case "s3":
+		store, err = newS3Store(client, u.Host)
+	case "gs":
+		store, err = newGCSStore(client, u.Host)
+	case "azblob":
+		store, err = newAzureStore(client, u.Host)
+	default:
+		err = fmt.Errorf("the %s object store is not supported", u.Scheme)
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return store, key, nil
+}
+
+// ObjectSink writes the feed to the object URL using Format. The objects
+// of formats that write multiple files are stored below the key of the URL,
+// like the files of a FileSink are written below its path, and the
+// compressed copy and the checksums are stored next to the object like
+// they are for a FileSink. Every object is uploaded, because the objects
+// that exist in the store are not compared with the output.
+type ObjectSink struct {
+	Format    string
+	URL       string
+	Client    Doer
+	Options   Options
+	Gzip      bool
+	Checksums bool
+	Signer    *Signer
+}
+
+func (s ObjectSink) Render(f feed.RSS) (Files, error) {
+	return FileSink{
+		Format:    s.Format,
+		Path:      s.URL,
+		Options:   s.Options,
+		Gzip:      s.Gzip,
+		Checksums: s.Checksums,
+		Signer:    s.Signer,
+	}.Render(f)
+}
+
+// Write uploads the files. The uploads are not canceled when the program
+// is stopping, so that an object is never left partially uploaded.
+func (s ObjectSink) Write(files Files) (int, error) {
+	store, key, err := OpenObjectStore(s.URL, s.Client)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for _, name := range files.Names() {
+		objectKey := path.Join(key, name)
+		err = store.Put(
+			context.Background(),
+			objectKey,
+			files[name],
+			objectContentType(objectKey),
+		)
+		if err != nil {
+			return written, fmt.Errorf(
+				"failed to upload %s: %w",
+				objectKey,
+				err,
+			)
+		}
+
+		written++
+	}
+
+	return written, nil
+}
+
+// objectContentType returns the media type of the object key, which is
+// served as the Content-Type of the object.
+func objectContentType(key string) string {
+	switch ext := path.Ext(key); ext {
+	case ".md":
+		return "text/markdown; charset=utf-8"
+	case ".toml":
+		return "application/toml; charset=utf-8"
+	case ".yaml", ".yml":
+		return "application/yaml; charset=utf-8"
+	case ".gz":
+		return "application/gzip"
+	case ".sha256", ".sig":
+		return "text/plain; charset=utf-8"
+	default:
+		if t := mime.TypeByExtension(ext); t != "" {
+			return t
+		}
+	}
+
+	if path.Base(key) == ChecksumsFile {
+		return "text/plain; charset=utf-8"
+	}
+
+	return "application/octet-stream"
+}
+
+// objectError returns the error for a failed request to an object store.
+// The message that the service returned in the body of the response is
+// included if it is short.
+func objectError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := strings.TrimSpace(string(body))
+	if message == "" || len(body) == 4096 {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	return fmt.Errorf("status code: %d: %s", resp.StatusCode, message)
+}
@@ -0,0 +1,399 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package output implements writing a transformed Bluesky feed in the
+// formats that Hugo can use.
+//
+// A feed can be written as an RSS, Atom, or JSON Feed document, as a Hugo
+// data file in JSON, YAML, or TOML format, as one Markdown content page for
+// each post, or as a Markdown fragment that calls a Hugo shortcode for each
+// post. The template format executes a Go template that is supplied by the
+// user, which can be used to write any other format.
+//
+// The output is rendered into memory first so that callers can compare the
+// output with the output of a previous run before any files are written.
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats are the names of the supported output formats.
+var Formats = []string{
+	"rss", "atom", "jsonfeed", "json", "yaml", "toml", "content", "shortcode",
+	"template",
+}
+
+// Files contains the rendered output for a feed. The keys are the names of
+// the files relative to the output path. Formats that write a single file
+// use an empty name for the file, which refers to the output path itself,
+// and names that start with ../ for the files that are written next to it.
+type Files map[string][]byte
+
+// Options control how the output is rendered. The zero value renders the
+// output using the default settings.
+type Options struct {
+	// LinkCardFrontMatter writes the link card of a post to the front
+	// matter of the content page for the post instead of the body of the
+	// page.
+	LinkCardFrontMatter bool
+
+	// Shortcode is the name of the Hugo shortcode that the shortcode format
+	// writes for each post. DefaultShortcode is used if Shortcode is empty.
+	Shortcode string
+
+	// Template is the template that the template format executes with the
+	// feed. It is required by the template format.
+	Template *template.Template
+
+	// Language is the language of the feed that is written to the language
+	// element of the RSS output, such as en-us. The language of the
+	// original feed is kept if Language is empty.
+	Language string
+
+	// SelfURL is the URL that the RSS output is published at. An
+	// atom:link element that refers to SelfURL is added to the channel if
+	// SelfURL is set.
+	SelfURL string
+
+	// TitleStyle determines how the titles of the posts are derived. The
+	// posts do not get titles if TitleStyle is empty or NoTitle.
+	TitleStyle TitleStyle
+
+	// TitleWords is the number of words that the WordsTitle style uses.
+	// DefaultTitleWords is used if TitleWords is zero.
+	TitleWords int
+
+	// TitleTemplate is the template that the TemplateTitle style executes
+	// with the TemplatePost of each post.
+	TitleTemplate *template.Template
+
+	// Taxonomies assigns the hashtags of the posts to the taxonomies that
+	// are written to the front matter of the content pages.
+	Taxonomies Taxonomies
+
+	// SlugStyle determines how the slugs of the content pages are derived.
+	// The record keys of the posts are used if SlugStyle is empty.
+	SlugStyle SlugStyle
+
+	// SlugTemplate is the template that the TemplateSlug style executes
+	// with the TemplatePost of each post.
+	SlugTemplate *template.Template
+
+	// PageBundles writes each content page as the index.md file of a Hugo
+	// leaf page bundle, which is a directory that is named after the slug
+	// of the post, instead of as a Markdown file that is named after the
+	// slug.
+	PageBundles bool
+
+	// FrontMatter is the format of the front matter of the content pages.
+	// YAML front matter is written if FrontMatter is empty.
+	FrontMatter FrontMatterFormat
+
+	// Archetype is the template that the content format executes with the
+	// ContentPage of each post to render the page, instead of writing the
+	// front matter and the body of the page.
+	Archetype *template.Template
+}
+
+// Render renders the transformed feed using the requested output format.
+// The output only depends on the content of the feed and opts, so rendering
+// the same feed always produces byte-identical output, and every file ends
+// with a newline.
+func Render(format string, f feed.RSS, opts Options) (Files, error) {
+	f, err := withTitles(f, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "content" {
+		return RenderContent(f, opts)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, format, f, opts); err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+
+	return Files{"": buf.Bytes()}, nil
+}
+
+// Names returns the sorted names of the output files.
+func (o Files) Names() []string {
+	names := make([]string, 0, len(o))
+	for name := range o {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Hash returns a SHA-256 hash of the names and contents of the output files
+// that can be used to determine whether the output has changed.
+func (o Files) Hash() string {
+	h := sha256.New()
+	for _, name := range o.Names() {
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(o[name]))
+		h.Write(o[name])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Exists reports whether all of the output files exist at path.
+func (o Files) Exists(path string) bool {
+	for name := range o {
+		if _, err := os.Stat(filepath.Join(path, name)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Write writes the output files to path and returns the number of files
+// that were written. When the output contains multiple files, path is a
+// directory that is created if it does not exist, along with the
+// subdirectories of the files. If skipUnchanged is true,
+// files whose existing content is identical to the output are not
+// rewritten. Each file is replaced atomically, so a file either contains
+// the previous output or the new output.
+func (o Files) Write(path string, skipUnchanged bool) (int, error) {
+	if _, ok := o[""]; !ok {
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return 0, fmt.Errorf("failed to create the directory: %w", err)
+		}
+	}
+
+	written := 0
+	for _, name := range o.Names() {
+		target := filepath.Join(path, name)
+		if dir := filepath.Dir(name); dir != "." {
+			err := os.MkdirAll(filepath.Join(path, dir), 0o755)
+			if err != nil {
+				return written, fmt.Errorf(
+					"failed to create the directory: %w",
+					err,
+				)
+			}
+		}
+
+		if skipUnchanged {
+			existing, err := os.ReadFile(target)
+			if err == nil && bytes.Equal(existing, o[name]) {
+				continue
+			}
+		}
+
+		if err := WriteFile(target, o[name]); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+
+		written++
+	}
+
+	return written, nil
+}
+
+// WriteFile atomically replaces the file name with data. The data is
+// written to a temporary file in the same directory, which is renamed to
+// name once all of the data has been written, so the previous content of
+// the file is preserved if writing fails.
+func WriteFile(name string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+"-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	if _, err = file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err = file.Chmod(0o644); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
+
+// DataFeed is the representation of the feed that is written when the feed
+// is output as a Hugo data file. The structure is flattened so that Hugo
+// templates can iterate over the posts using site.Data without having to
+// know about the RSS document structure.
+type DataFeed struct {
+	Title       string     `json:"title" yaml:"title" toml:"title"`
+	Link        string     `json:"link" yaml:"link" toml:"link"`
+	Description string     `json:"description" yaml:"description" toml:"description"`
+	Items       []DataItem `json:"items" yaml:"items" toml:"items"`
+}
+
+type DataItem struct {
+	Title       string `json:"title,omitempty" yaml:"title,omitempty" toml:"title,omitempty"`
+	GUID        string `json:"guid" yaml:"guid" toml:"guid"`
+	Link        string `json:"link" yaml:"link" toml:"link"`
+	Description string `json:"description" yaml:"description" toml:"description"`
+	Summary     string `json:"summary,omitempty" yaml:"summary,omitempty" toml:"summary,omitempty"`
+	Date        string `json:"date" yaml:"date" toml:"date"`
+	Handle      string `json:"handle,omitempty" yaml:"handle,omitempty" toml:"handle,omitempty"`
+	Author      string `json:"author,omitempty" yaml:"author,omitempty" toml:"author,omitempty"`
+	Avatar      string `json:"avatar,omitempty" yaml:"avatar,omitempty" toml:"avatar,omitempty"`
+	DID         string `json:"did,omitempty" yaml:"did,omitempty" toml:"did,omitempty"`
+
+	Languages []string `json:"languages,omitempty" yaml:"languages,omitempty" toml:"languages,omitempty"`
+	Labels    []string `json:"labels,omitempty" yaml:"labels,omitempty" toml:"labels,omitempty"`
+
+	Engagement *feed.Engagement `json:"engagement,omitempty" yaml:"engagement,omitempty" toml:"engagement,omitempty"`
+	Pinned     bool             `json:"pinned,omitempty" yaml:"pinned,omitempty" toml:"pinned,omitempty"`
+	Deleted    bool             `json:"deleted,omitempty" yaml:"deleted,omitempty" toml:"deleted,omitempty"`
+	Draft      bool             `json:"draft,omitempty" yaml:"draft,omitempty" toml:"draft,omitempty"`
+}
+
+// NewDataFeed converts the channel of an RSS feed into a DataFeed.
+func NewDataFeed(channel feed.Channel) DataFeed {
+	result := DataFeed{
+		Title:       channel.Title,
+		Link:        channel.Link,
+		Description: channel.Description,
+		Items:       make([]DataItem, 0, len(channel.Items)),
+	}
+	for _, item := range channel.Items {
+		data := DataItem{
+			Title:       item.Title,
+			GUID:        item.GUID.Value,
+			Link:        item.Link,
+			Description: item.Description,
+			Summary:     item.Summary,
+			Date:        item.PubDate,
+			Languages:   item.Languages,
+			Labels:      item.Labels,
+			Engagement:  item.Engagement,
+			Pinned:      item.Pinned,
+			Deleted:     item.Deleted,
+			Draft:       item.Draft,
+		}
+		if author := item.Author; author != nil {
+			data.Handle = author.Handle
+			data.Author = authorName(*author)
+			data.Avatar = author.Avatar
+			data.DID = author.DID
+		}
+
+		result.Items = append(result.Items, data)
+	}
+
+	return result
+}
+
+// Encode writes the transformed feed to w using the requested output
+// format. The content format is not supported because it writes multiple
+// files; use Render instead.
+func Encode(w io.Writer, format string, f feed.RSS, opts Options) error {
+	switch format {
+	case "rss":
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", "  ")
+		f = withItemElements(withSummaryElements(withMediaElements(f)))
+		f = withStatusElements(withEngagementElements(f))
+		return encoder.Encode(withChannelElements(f, opts))
+	case "atom":
+		return writeAtom(w, f)
+	case "jsonfeed":
+		return writeJSONFeed(w, f)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(NewDataFeed(f.Channel))
+	case "yaml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(NewDataFeed(f.Channel)); err != nil {
+			return err
+		}
+
+		return encoder.Close()
+	case "toml":
+		return toml.NewEncoder(w).Encode(NewDataFeed(f.Channel))
+	case "shortcode":
+		return writeShortcodes(w, f, opts.Shortcode)
+	case "template":
+		return writeTemplate(w, f, opts.Template)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// authorName returns the display name of the author, or the handle of the
+// author if the author does not have a display name.
+func authorName(author feed.ProfileViewBasic) string {
+	if author.DisplayName != "" {
+		return author.DisplayName
+	}
+
+	return "@" + author.Handle
+}
+
+// authorURL returns the URL of the profile of author. The bsky.app profile
+// URL is used unless the author is not a Bluesky account.
+func authorURL(author feed.ProfileViewBasic) string {
+	if author.URL != "" {
+		return author.URL
+	}
+
+	return feed.ProfileURL(author.Handle)
+}
@@ -0,0 +1,255 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/internal/feedtest"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
+)
+
+// benchmarkItems is the number of items in the feeds of the benchmarks.
+const benchmarkItems = 10_000
+
+// benchmarkFormats are the output formats that the benchmarks render. The
+// content format is benchmarked separately because it writes a file for
+// each post.
+var benchmarkFormats = []string{"rss", "atom", "jsonfeed", "json", "yaml"}
+
+// benchmarkFeed returns a transformed feed with n items that look like the
+// items of the RSS feed that Bluesky publishes.
+func benchmarkFeed(b *testing.B, n int) feed.RSS {
+	f := feedtest.Feed(n)
+	items, err := benchmarkTransform(f.Channel.Items)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	f.Channel.Items = items
+	return f
+}
+
+// benchmarkTransform applies the transforms that a run applies to a feed
+// by default.
+func benchmarkTransform(items []feed.Item) ([]feed.Item, error) {
+	items, _, err := transform.Dates(
+		items,
+		nil,
+		transform.DefaultDateFormat,
+		nil,
+		transform.Fail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := transform.TagSet(transform.DefaultAllowedTags)
+	for i := range items {
+		transform.SanitizeItem(&items[i], allowed)
+	}
+
+	return items, nil
+}
+
+func BenchmarkRender(b *testing.B) {
+	f := benchmarkFeed(b, benchmarkItems)
+	for _, format := range benchmarkFormats {
+		b.Run(format, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := Render(format, f, Options{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	b.Run("content", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := Render("content", f, Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkTransformAndWrite measures a complete run of a feed after it
+// was downloaded: the items are transformed, rendered, and written to a
+// file.
+func BenchmarkTransformAndWrite(b *testing.B) {
+	f := benchmarkFeed(b, 0)
+	items := feedtest.Feed(benchmarkItems).Channel.Items
+	for _, format := range benchmarkFormats {
+		b.Run(format, func(b *testing.B) {
+			path := filepath.Join(b.TempDir(), "bluesky."+format)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				result, err := benchmarkTransform(slices.Clone(items))
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				f.Channel.Items = result
+				files, err := Render(format, f, Options{})
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if _, err = files.Write(path, false); err != nil {
+					b.Fatal(err)
+				}
+
+				b.SetBytes(int64(len(files[""])))
+			}
+		})
+	}
+}
+
+func BenchmarkReadItems(b *testing.B) {
+	f := benchmarkFeed(b, benchmarkItems)
+	for _, format := range benchmarkFormats {
+		files, err := Render(format, f, Options{})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(format, func(b *testing.B) {
+			b.SetBytes(int64(len(files[""])))
+			b.ReportAllocs()
+			for b.Loop() {
+				items, err := ReadItems(format, files[""])
+				if err != nil || len(items) != benchmarkItems {
+					b.Fatalf("got %d items: %v", len(items), err)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderContentSlugCollision(t *testing.T) {
+	tmpl, err := ParseSlugTemplate("{{ .Title }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := feed.RSS{Channel: feed.Channel{Items: []feed.Item{
+		{
+			Title: "Weekly notes",
+			Link:  "https://bsky.app/profile/alice.test/post/3kaaa",
+		},
+		{
+			Title: "Weekly notes",
+			Link:  "https://bsky.app/profile/alice.test/post/3kbbb",
+		},
+	}}}
+
+	_, err = RenderContent(f, Options{
+		SlugStyle:    TemplateSlug,
+		SlugTemplate: tmpl,
+	})
+	if err == nil {
+		t.Fatal("expected an error for the duplicate slug")
+	}
+
+	for _, item := range f.Channel.Items {
+		if !strings.Contains(err.Error(), item.Link) {
+			t.Errorf("the error %q does not name %s", err, item.Link)
+		}
+	}
+}
+
+func TestEncodeRSSKeepsCreator(t *testing.T) {
+	f := feed.RSS{
+		Version: "2.0",
+		Channel: feed.Channel{
+			Title: "@alice.test - Alice",
+			Link:  "https://bsky.app/profile/alice.test",
+			Items: []feed.Item{{
+				Link: "https://bsky.app/profile/alice.test/post/3kaaa",
+				Extensions: []feed.Extension{{
+					XMLName:   xml.Name{Local: "dc:creator"},
+					InnerXML:  "Someone",
+					Namespace: feed.DublinCoreNamespace,
+				}},
+			}},
+		},
+	}
+	f.Channel.Items[0].Author = feed.LinkAuthor(
+		f.Channel.Items[0].Link,
+		f.Channel,
+	)
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, "rss", f, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Count(buf.String(), "<dc:creator>"); got != 1 {
+		t.Errorf("got %d dc:creator elements, want 1:\n%s", got, buf.String())
+	}
+
+	if !strings.Contains(buf.String(), "<dc:creator>Someone</dc:creator>") {
+		t.Errorf("the dc:creator of the feed was replaced:\n%s", buf.String())
+	}
+}
+
+func TestValidateRSSItemTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		item    string
+		problem bool
+	}{
+		{"title", "<title>Hello</title>", false},
+		{"description", "<description>Hello</description>", false},
+		{"neither", "<link>https://example.com/post/1</link>", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(`<?xml version="1.0"?>
+<rss version="2.0"><channel>
+<title>Alice</title>
+<link>https://bsky.app/profile/alice.test</link>
+<description>Posts by Alice.</description>
+<item>` + tt.item + `</item>
+</channel></rss>`)
+			problems, err := Validate("rss", data, ValidateOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			found := slices.ContainsFunc(problems, func(p string) bool {
+				return strings.Contains(p, "a title or description")
+			})
+			if found != tt.problem {
+				t.Errorf("got problems %q", problems)
+			}
+		})
+	}
+}
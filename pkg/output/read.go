@@ -0,0 +1,187 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
+	"gopkg.in/yaml.v3"
+)
+
+// ReadItems parses output that was previously written using format and
+// returns the items. The dates of the items are returned as they were
+// written, and Published is not set, so the dates need to be parsed using
+// the format that they were written with. The content format is not supported.
+func ReadItems(format string, data []byte) ([]feed.Item, error) {
+	var err error
+	var items []feed.Item
+	switch format {
+	case "rss":
+		var f feed.RSS
+		if err = xml.Unmarshal(data, &f); err != nil {
+			return nil, err
+		}
+
+		items = f.Channel.Items
+		for i := range items {
+			items[i] = itemEngagement(itemFromRSSItem(items[i]))
+		}
+	case "atom":
+		var f AtomFeed
+		if err = xml.Unmarshal(data, &f); err != nil {
+			return nil, err
+		}
+
+		items = make([]feed.Item, 0, len(f.Entries))
+		for _, entry := range f.Entries {
+			items = append(items, itemFromAtomEntry(entry))
+		}
+	case "jsonfeed":
+		var f JSONFeed
+		if err = json.Unmarshal(data, &f); err != nil {
+			return nil, err
+		}
+
+		items = make([]feed.Item, 0, len(f.Items))
+		for _, entry := range f.Items {
+			items = append(items, itemFromJSONFeedItem(entry))
+		}
+	case "json", "yaml", "toml":
+		var f DataFeed
+		switch format {
+		case "json":
+			err = json.Unmarshal(data, &f)
+		case "yaml":
+			err = yaml.Unmarshal(data, &f)
+		default:
+			_, err = toml.NewDecoder(bytes.NewReader(data)).Decode(&f)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		items = make([]feed.Item, 0, len(f.Items))
+		for _, entry := range f.Items {
+			items = append(items, itemFromDataItem(entry))
+		}
+	default:
+		return nil, fmt.Errorf("reading the %s format is not supported", format)
+	}
+
+	return items, nil
+}
+
+func itemFromAtomEntry(entry AtomEntry) feed.Item {
+	item := feed.Item{
+		Title:       entry.Title,
+		Link:        entry.Link.Href,
+		Description: entry.Content.Value,
+		Summary:     entry.Summary,
+		PubDate:     entry.Updated,
+		GUID:        feed.GUID{IsPermaLink: "false", Value: entry.ID},
+		Languages:   languageList(entry.Lang),
+	}
+	if author := entry.Author; author != nil {
+		item.Author = profileAuthor(author.Name, author.URI, "")
+	}
+	if entry.Content.Type == "html" {
+		item.Text = transform.HTMLText(entry.Content.Value)
+		item.IsHTML = true
+	}
+
+	return item
+}
+
+func itemFromJSONFeedItem(entry JSONFeedItem) feed.Item {
+	item := feed.Item{
+		Title:       entry.Title,
+		Link:        entry.URL,
+		Description: entry.ContentHTML,
+		Summary:     entry.Summary,
+		PubDate:     entry.DatePublished,
+		GUID:        feed.GUID{IsPermaLink: "false", Value: entry.ID},
+		Text:        transform.HTMLText(entry.ContentHTML),
+		IsHTML:      true,
+		Languages:   languageList(entry.Language),
+		Engagement:  entry.Engagement,
+	}
+	if len(entry.Authors) > 0 {
+		author := entry.Authors[0]
+		item.Author = profileAuthor(author.Name, author.URL, author.Avatar)
+	}
+
+	return item
+}
+
+func itemFromDataItem(entry DataItem) feed.Item {
+	item := feed.Item{
+		Title:       entry.Title,
+		Link:        entry.Link,
+		Description: entry.Description,
+		Summary:     entry.Summary,
+		PubDate:     entry.Date,
+		GUID:        feed.GUID{IsPermaLink: "false", Value: entry.GUID},
+		Languages:   entry.Languages,
+		Labels:      entry.Labels,
+		Engagement:  entry.Engagement,
+	}
+	if entry.Handle != "" {
+		item.Author = &feed.ProfileViewBasic{
+			DID:    entry.DID,
+			Handle: entry.Handle,
+			Avatar: entry.Avatar,
+		}
+		if entry.Author != "@"+entry.Handle {
+			item.Author.DisplayName = entry.Author
+		}
+	}
+
+	return item
+}
+
+// profileAuthor returns the author that was written with the name and the
+// bsky.app profile URL of the author by a previous run, or nil if the URL is
+// not the URL of a profile.
+func profileAuthor(
+	name string,
+	profileURL string,
+	avatar string,
+) *feed.ProfileViewBasic {
+	handle, ok := strings.CutPrefix(profileURL, feed.ProfileURL(""))
+	if !ok || handle == "" {
+		return nil
+	}
+
+	author := &feed.ProfileViewBasic{Handle: handle, Avatar: avatar}
+	if name != "@"+handle {
+		author.DisplayName = name
+	}
+
+	return author
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// s3Store stores objects in an Amazon S3 bucket, or in a bucket of a
+// service that implements the S3 API. The requests are signed using AWS
+// Signature Version 4.
+type s3Store struct {
+	client       Doer
+	bucket       string
+	region       string
+	endpoint     *url.URL
+	accessKey    string
+	secretKey    string
+	sessionToken string
+}
+
+func newS3Store(client Doer, bucket string) (*s3Store, error) {
+	s := &s3Store{
+		client: client,
+		bucket: bucket,
+		region: cmp.Or(
+			os.Getenv("AWS_REGION"),
+			os.Getenv("AWS_DEFAULT_REGION"),
+			"us-east-1",
+		),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, errors.New(
+			"the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment " +
+				"variables are required for s3 URLs",
+		)
+	}
+
+	endpoint := cmp.Or(
+		os.Getenv("AWS_ENDPOINT_URL_S3"),
+		os.Getenv("AWS_ENDPOINT_URL"),
+	)
+	if endpoint != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+			return nil, fmt.Errorf("the S3 endpoint %q is not valid", endpoint)
+		}
+
+		s.endpoint = u
+	}
+
+	return s, nil
+}
+
+func (s *s3Store) Put(
+	ctx context.Context,
+	key string,
+	data []byte,
+	contentType string,
+) error {
+	u := url.URL{
+		Scheme: "https",
+		Host:   s.bucket + ".s3." + s.region + ".amazonaws.com",
+		Path:   "/" + key,
+	}
+	if s.endpoint != nil {
+		u = *s.endpoint
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket + "/" + key
+	}
+
+	u.RawPath = awsEscapePath(u.Path)
+	req, err := http.NewRequestWithContext(
+		ctx,
+		http.MethodPut,
+		u.String(),
+		bytes.NewReader(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, data, time.Now())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return objectError(resp)
+	}
+
+	return nil
+}
+
+// sign signs req, whose body is payload, using AWS Signature Version 4.
+// Every header of req is signed, along with the Host header.
+func (s *s3Store) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	signedHeaders := strings.Join(names, ";")
+	var canonical strings.Builder
+	canonical.WriteString(req.Method + "\n")
+	canonical.WriteString(req.URL.EscapedPath() + "\n")
+	canonical.WriteString(awsCanonicalQuery(req.URL.Query()) + "\n")
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.TrimSpace(headers[name]))
+		canonical.WriteString("\n")
+	}
+
+	canonical.WriteString("\n" + signedHeaders + "\n" + payloadHash)
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" +
+		sha256Hex([]byte(canonical.String()))
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range strings.Split(scope, "/") {
+		key = hmacSHA256(key, part)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey,
+		scope,
+		signedHeaders,
+		hex.EncodeToString(hmacSHA256(key, stringToSign)),
+	))
+}
+
+// awsEscapePath escapes the path of a URL the way that AWS Signature
+// Version 4 expects, which escapes every byte except the unreserved
+// characters of RFC 3986 and the slashes that separate the segments.
+func awsEscapePath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '/' || c == '-' || c == '.' || c == '_' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+			('0' <= c && c <= '9') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+// awsCanonicalQuery returns the canonical query string of AWS Signature
+// Version 4, which sorts the parameters by name and escapes them like
+// paths, including the slashes.
+func awsCanonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, strings.ReplaceAll(
+				awsEscapePath(name)+"="+awsEscapePath(value),
+				"/",
+				"%2F",
+			))
+		}
+	}
+
+	slices.Sort(params)
+	return strings.Join(params, "&")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,262 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// IsSFTPURL reports whether path is the URL of a file on a server that the
+// output is uploaded to using SFTP, such as
+// sftp://deploy@example.com/var/www/feed.xml, instead of the path of a
+// file.
+func IsSFTPURL(path string) bool {
+	scheme, _, ok := strings.Cut(path, "://")
+	return ok && strings.EqualFold(scheme, "sftp")
+}
+
+// SFTPServer is a server that files are uploaded to using the sftp command
+// of OpenSSH. Destination is the user and the host that the command
+// connects to, and PrivateKey and KnownHosts are the contents of the
+// private key that the user is authenticated with and of the known_hosts
+// file that the host key is verified with. The configuration of ssh is used
+// if they are empty.
+type SFTPServer struct {
+	Destination string
+	Port        string
+	PrivateKey  string
+	KnownHosts  string
+}
+
+// OpenSFTPServer returns the server of the SFTP URL rawURL and the path of
+// the file on the server. The path is an absolute path, unless it starts
+// with /~/, in which case it is relative to the home directory of the user.
+// The private key and the known hosts are read from the SSH_PRIVATE_KEY and
+// SSH_KNOWN_HOSTS environment variables.
+func OpenSFTPServer(rawURL string) (*SFTPServer, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	remote := u.Path
+	if rest, ok := strings.CutPrefix(remote, "/~/"); ok {
+		remote = rest
+	}
+
+	remote = strings.TrimSuffix(remote, "/")
+	if u.Hostname() == "" || remote == "" {
+		return nil, "", fmt.Errorf(
+			"the SFTP URL %s must name a host and a path",
+			rawURL,
+		)
+	}
+
+	if _, err = exec.LookPath("sftp"); err != nil {
+		return nil, "", errors.New(
+			"the sftp command is required to upload the output to a server",
+		)
+	}
+
+	// IPv6 addresses are enclosed in brackets so that sftp does not treat
+	// the address as a host followed by a path.
+	host := u.Hostname()
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	if u.User != nil {
+		host = u.User.Username() + "@" + host
+	}
+
+	return &SFTPServer{
+		Destination: host,
+		Port:        u.Port(),
+		PrivateKey:  os.Getenv("SSH_PRIVATE_KEY"),
+		KnownHosts:  os.Getenv("SSH_KNOWN_HOSTS"),
+	}, remote, nil
+}
+
+// Upload uploads files to remote, which is the path that the names of the
+// files are relative to like they are for Files.Write. All of the files
+// are uploaded using a single connection. Each file is uploaded to a
+// temporary file that is renamed once the upload has finished, so the
+// previous content of a file is served until the new content is complete.
+// The server must support the posix-rename@openssh.com extension so that
+// the existing files can be replaced.
+func (s *SFTPServer) Upload(
+	ctx context.Context,
+	remote string,
+	files Files,
+) error {
+	dir, err := os.MkdirTemp("", "blueskyrss-sftp-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	args := []string{"-q", "-o", "BatchMode=yes"}
+	if s.Port != "" {
+		args = append(args, "-P", s.Port)
+	}
+
+	if s.PrivateKey != "" {
+		name := filepath.Join(dir, "id")
+		key := strings.TrimSpace(s.PrivateKey) + "\n"
+		if err = os.WriteFile(name, []byte(key), 0o600); err != nil {
+			return err
+		}
+
+		args = append(args, "-i", name, "-o", "IdentitiesOnly=yes")
+	}
+
+	if s.KnownHosts != "" {
+		name := filepath.Join(dir, "known_hosts")
+		err = os.WriteFile(name, []byte(s.KnownHosts+"\n"), 0o600)
+		if err != nil {
+			return err
+		}
+
+		args = append(
+			args,
+			"-o", "UserKnownHostsFile="+name,
+			"-o", "StrictHostKeyChecking=yes",
+		)
+	}
+
+	var batch bytes.Buffer
+	if _, single := files[""]; !single {
+		// A leading hyphen tells sftp to ignore the error when the
+		// directory already exists.
+		for _, d := range sftpDirectories(files) {
+			fmt.Fprintf(&batch, "-mkdir %s\n", sftpQuote(path.Join(remote, d)))
+		}
+	}
+
+	for i, name := range files.Names() {
+		local := filepath.Join(dir, strconv.Itoa(i))
+		if err = os.WriteFile(local, files[name], 0o644); err != nil {
+			return err
+		}
+
+		target := path.Join(remote, name)
+		tmp := path.Join(path.Dir(target), "."+path.Base(target)+".tmp")
+		fmt.Fprintf(&batch, "put %s %s\n", sftpQuote(local), sftpQuote(tmp))
+		fmt.Fprintf(&batch, "rename %s %s\n", sftpQuote(tmp), sftpQuote(target))
+	}
+
+	name := filepath.Join(dir, "batch")
+	if err = os.WriteFile(name, batch.Bytes(), 0o600); err != nil {
+		return err
+	}
+
+	args = append(args, "-b", name, s.Destination)
+	cmd := exec.CommandContext(ctx, "sftp", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("sftp failed: %w: %s", err, msg)
+		}
+
+		return fmt.Errorf("sftp failed: %w", err)
+	}
+
+	return nil
+}
+
+// sftpDirectories returns the directories that the files of a format that
+// writes multiple files are written to, relative to the output path. The
+// parent directories are listed before their subdirectories.
+func sftpDirectories(files Files) []string {
+	dirs := []string{"."}
+	for name := range files {
+		for d := path.Dir(name); d != "."; d = path.Dir(d) {
+			dirs = append(dirs, d)
+		}
+	}
+
+	slices.Sort(dirs)
+	return slices.Compact(dirs)
+}
+
+// sftpQuote quotes an argument of a command of an sftp batch file.
+func sftpQuote(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// SFTPSink writes the feed to the path of the SFTP URL using Format. The
+// files of formats that write multiple files are uploaded to the directory
+// at the path, like the files of a FileSink are written to the directory at
+// its path, and the compressed copy and the checksums are uploaded next to
+// the file like they are for a FileSink. Every file is uploaded, because
+// the files that exist on the server are not compared with the output.
+type SFTPSink struct {
+	Format    string
+	URL       string
+	Options   Options
+	Gzip      bool
+	Checksums bool
+	Signer    *Signer
+}
+
+func (s SFTPSink) Render(f feed.RSS) (Files, error) {
+	return FileSink{
+		Format:    s.Format,
+		Path:      s.URL,
+		Options:   s.Options,
+		Gzip:      s.Gzip,
+		Checksums: s.Checksums,
+		Signer:    s.Signer,
+	}.Render(f)
+}
+
+// Write uploads the files. Like the uploads of an ObjectSink, the upload
+// is not canceled when the program is stopping.
+func (s SFTPSink) Write(files Files) (int, error) {
+	server, remote, err := OpenSFTPServer(s.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	err = server.Upload(context.Background(), remote, files)
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload %s: %w", s.URL, err)
+	}
+
+	return len(files), nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// DefaultShortcode is the name of the Hugo shortcode that is written for
+// each post by the shortcode format when no shortcode is configured.
+const DefaultShortcode = "bluesky-post"
+
+// writeShortcodes writes a Markdown fragment that calls the Hugo shortcode
+// named shortcode once for each item in the feed. The shortcode is given
+// the AT URI, the bsky.app URL, and the date of the post as the uri, url,
+// and date parameters, so that the theme of the site controls how the posts
+// are rendered.
+func writeShortcodes(w io.Writer, f feed.RSS, shortcode string) error {
+	if shortcode == "" {
+		shortcode = DefaultShortcode
+	}
+
+	for i, item := range f.Channel.Items {
+		separator := ""
+		if i > 0 {
+			separator = "\n"
+		}
+
+		if _, err := fmt.Fprintf(
+			w,
+			"%s{{< %s uri=%s url=%s date=%s >}}\n",
+			separator,
+			shortcode,
+			strconv.Quote(item.GUID.Value),
+			strconv.Quote(item.Link),
+			strconv.Quote(item.PubDate),
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// DefaultSignatureNamespace is the namespace that the signatures are
+// created for when no namespace is given. It is the namespace that
+// ssh-keygen -Y sign uses for files.
+const DefaultSignatureNamespace = "file"
+
+// Signer creates SSH signatures of the output files using an Ed25519 key.
+// The signatures use the format of ssh-keygen -Y sign, so they can be
+// verified using ssh-keygen -Y verify and an allowed signers file that
+// contains the public key.
+type Signer struct {
+	key       ed25519.PrivateKey
+	namespace string
+}
+
+// ParseSigner parses an unencrypted Ed25519 private key in the OpenSSH
+// format that ssh-keygen writes and returns a Signer that creates
+// signatures for namespace. DefaultSignatureNamespace is used if namespace
+// is empty.
+func ParseSigner(key []byte, namespace string) (*Signer, error) {
+	block, _ := pem.Decode(key)
+	if block == nil || block.Type != "OPENSSH PRIVATE KEY" {
+		return nil, errors.New("the key is not an OpenSSH private key")
+	}
+
+	r := sshReader{data: block.Bytes}
+	if !bytes.HasPrefix(r.data, []byte("openssh-key-v1\x00")) {
+		return nil, errors.New("the key is not an OpenSSH private key")
+	}
+
+	r.data = r.data[len("openssh-key-v1\x00"):]
+	cipher, kdf, _ := r.string(), r.string(), r.string()
+	if string(cipher) != "none" || string(kdf) != "none" {
+		return nil, errors.New("encrypted private keys are not supported")
+	}
+
+	if r.uint32() != 1 {
+		return nil, errors.New("the key file must contain a single key")
+	}
+
+	_ = r.string()
+	private := sshReader{data: r.string()}
+	if private.uint32() != private.uint32() {
+		return nil, errors.New("the private key is corrupt")
+	}
+
+	keyType := private.string()
+	public, seed := private.string(), private.string()
+	if r.err != nil || private.err != nil {
+		return nil, errors.New("the private key is corrupt")
+	}
+
+	if string(keyType) != "ssh-ed25519" {
+		return nil, fmt.Errorf("%s keys are not supported", keyType)
+	}
+
+	if len(public) != ed25519.PublicKeySize ||
+		len(seed) != ed25519.PrivateKeySize {
+		return nil, errors.New("the private key is corrupt")
+	}
+
+	if namespace == "" {
+		namespace = DefaultSignatureNamespace
+	}
+
+	return &Signer{key: ed25519.PrivateKey(seed), namespace: namespace}, nil
+}
+
+// Sign returns the armored SSH signature of data.
+func (s *Signer) Sign(data []byte) []byte {
+	const hashAlgorithm = "sha512"
+	digest := sha512.Sum512(data)
+	var signed bytes.Buffer
+	signed.WriteString("SSHSIG")
+	writeSSHString(&signed, []byte(s.namespace))
+	writeSSHString(&signed, nil)
+	writeSSHString(&signed, []byte(hashAlgorithm))
+	writeSSHString(&signed, digest[:])
+
+	var public, signature bytes.Buffer
+	writeSSHString(&public, []byte("ssh-ed25519"))
+	writeSSHString(&public, s.key.Public().(ed25519.PublicKey))
+	writeSSHString(&signature, []byte("ssh-ed25519"))
+	writeSSHString(&signature, ed25519.Sign(s.key, signed.Bytes()))
+
+	var blob bytes.Buffer
+	blob.WriteString("SSHSIG")
+	_ = binary.Write(&blob, binary.BigEndian, uint32(1))
+	writeSSHString(&blob, public.Bytes())
+	writeSSHString(&blob, []byte(s.namespace))
+	writeSSHString(&blob, nil)
+	writeSSHString(&blob, []byte(hashAlgorithm))
+	writeSSHString(&blob, signature.Bytes())
+
+	// The base64 encoding is wrapped at 70 characters, which is how
+	// ssh-keygen armors signatures.
+	encoded := base64.StdEncoding.EncodeToString(blob.Bytes())
+	var b bytes.Buffer
+	b.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		b.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+
+	b.WriteString(encoded + "\n")
+	b.WriteString("-----END SSH SIGNATURE-----\n")
+	return b.Bytes()
+}
+
+// writeSSHString writes data to buf as an SSH string, which is the length
+// of the data as a 32-bit big-endian integer followed by the data.
+func writeSSHString(buf *bytes.Buffer, data []byte) {
+	_ = binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	buf.Write(data)
+}
+
+// sshReader reads the integers and strings of the SSH wire format from
+// data. Reading past the end of the data sets err, and the values that are
+// read after an error are zero.
+type sshReader struct {
+	data []byte
+	err  error
+}
+
+func (r *sshReader) uint32() uint32 {
+	if r.err != nil || len(r.data) < 4 {
+		r.err = errors.New("unexpected end of data")
+		return 0
+	}
+
+	value := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return value
+}
+
+func (r *sshReader) string() []byte {
+	n := r.uint32()
+	if r.err != nil || uint64(len(r.data)) < uint64(n) {
+		r.err = errors.New("unexpected end of data")
+		return nil
+	}
+
+	value := r.data[:n]
+	r.data = r.data[n:]
+	return value
+}
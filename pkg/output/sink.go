@@ -0,0 +1,106 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"io"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// Sink is a destination that a transformed feed is written to, such as an
+// RSS document or the Hugo content pages of the posts. Rendering the feed
+// and writing it are separate steps so that the caller can compare the
+// rendered files with the output of a previous run before anything is
+// written.
+type Sink interface {
+	// Render renders the feed into the files that are written by the sink.
+	Render(f feed.RSS) (Files, error)
+
+	// Write writes the files that were returned by Render and returns the
+	// number of files that were written.
+	Write(files Files) (int, error)
+}
+
+// FileSink writes the feed to Path using Format. If SkipUnchanged is true,
+// files whose existing content is identical to the output are not
+// rewritten. If Gzip is true, a compressed copy of the output is written as
+// described by Files.WithGzip. If Checksums is true or Signer is set, the
+// checksums of the files are written as well, as described by
+// Files.WithChecksums.
+type FileSink struct {
+	Format        string
+	Path          string
+	Options       Options
+	SkipUnchanged bool
+	Gzip          bool
+	Checksums     bool
+	Signer        *Signer
+}
+
+func (s FileSink) Render(f feed.RSS) (Files, error) {
+	files, err := Render(s.Format, f, s.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Gzip {
+		files = files.WithGzip(s.Path)
+	}
+
+	if s.Checksums || s.Signer != nil {
+		files = files.WithChecksums(s.Path, s.Signer)
+	}
+
+	return files, nil
+}
+
+func (s FileSink) Write(files Files) (int, error) {
+	return files.Write(s.Path, s.SkipUnchanged)
+}
+
+// WriterSink writes the feed to W using Format, such as to standard output.
+// The content format cannot be written to a WriterSink because it produces
+// a file for each post.
+type WriterSink struct {
+	Format  string
+	W       io.Writer
+	Options Options
+}
+
+func (s WriterSink) Render(f feed.RSS) (Files, error) {
+	if s.Format == "content" {
+		return nil, errors.New(
+			"the content format cannot be written to a single file",
+		)
+	}
+
+	return Render(s.Format, f, s.Options)
+}
+
+func (s WriterSink) Write(files Files) (int, error) {
+	if _, err := s.W.Write(files[""]); err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
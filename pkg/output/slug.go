@@ -0,0 +1,159 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// SlugStyle determines how the slugs of the content pages of the posts are
+// derived. The slug is the file name of the content page and the last
+// segment of the URL of the page on the site, so a slug must not change
+// once a page has been published.
+type SlugStyle string
+
+const (
+	// RKeySlug uses the record key of the post, such as 3kabc.
+	RKeySlug SlugStyle = "rkey"
+
+	// DateSlug uses the date of the post followed by the record key of the
+	// post, such as 2025-03-12-3kabc.
+	DateSlug SlugStyle = "date-rkey"
+
+	// WordsSlug uses the first words of the text of the post followed by
+	// the record key of the post, such as hello-world-3kabc. The record key
+	// keeps the slugs of posts that start with the same words unique.
+	WordsSlug SlugStyle = "words"
+
+	// TemplateSlug executes a template with the TemplatePost of the post.
+	// The template must create a different slug for each post.
+	TemplateSlug SlugStyle = "template"
+)
+
+// SlugStyles are the supported slug styles.
+var SlugStyles = []SlugStyle{RKeySlug, DateSlug, WordsSlug, TemplateSlug}
+
+// slugWords is the number of words of the text of a post that are used by
+// the words slug style.
+const slugWords = 5
+
+// ItemSlug derives the slug of the content page of item using the slug
+// style of opts. The record key of the post is used if the slug style is
+// empty. The slugs that are derived from the text of the post or from a
+// template only contain lowercase letters, digits, and hyphens.
+func ItemSlug(item feed.Item, opts Options) (string, error) {
+	rkey := PostSlug(item)
+	switch opts.SlugStyle {
+	case "", RKeySlug:
+		return rkey, nil
+	case DateSlug:
+		if item.Published.IsZero() {
+			return rkey, nil
+		}
+
+		return item.Published.Format("2006-01-02") + "-" + rkey, nil
+	case WordsSlug:
+		var words []string
+		for _, word := range strings.Fields(item.PlainText()) {
+			if strings.Contains(word, "://") {
+				continue
+			}
+
+			if word = slugify(word); word != "" {
+				words = append(words, word)
+			}
+
+			if len(words) == slugWords {
+				break
+			}
+		}
+
+		return strings.Join(append(words, rkey), "-"), nil
+	case TemplateSlug:
+		if opts.SlugTemplate == nil {
+			return "", errors.New("the template slug style requires a template")
+		}
+
+		// The title of the post is derived the same way as when the page
+		// is rendered so that the slug does not depend on whether the
+		// item already has its title.
+		if item.Title == "" && opts.TitleStyle != "" &&
+			opts.TitleStyle != NoTitle {
+			title, err := itemTitle(item, opts)
+			if err != nil {
+				return "", err
+			}
+
+			item.Title = title
+		}
+
+		var buf bytes.Buffer
+		err := opts.SlugTemplate.Execute(&buf, NewTemplatePost(item))
+		if err != nil {
+			return "", err
+		}
+
+		slug := slugify(buf.String())
+		if slug == "" {
+			return "", errors.New("the slug template returned an empty slug")
+		}
+
+		return slug, nil
+	default:
+		return "", fmt.Errorf("unsupported slug style %q", opts.SlugStyle)
+	}
+}
+
+// ParseSlugTemplate parses the text of a slug template. The template has
+// the same functions as the templates of the template format.
+func ParseSlugTemplate(text string) (*template.Template, error) {
+	return template.New("slug").Funcs(TemplateFuncs).Parse(text)
+}
+
+// slugify converts text into a slug. Letters are converted to lowercase,
+// and every run of characters that are not letters or digits is replaced
+// by a single hyphen.
+func slugify(text string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			hyphen = b.Len() > 0
+			continue
+		}
+
+		if hyphen {
+			b.WriteByte('-')
+			hyphen = false
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/xml"
+	"slices"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// statusElement is an element of the Bluesky namespace that marks the
+// status of an item in the RSS output. has reports whether an item has the
+// status.
+type statusElement struct {
+	name string
+	has  func(feed.Item) bool
+}
+
+// statusElements are the elements that mark the status of an item.
+var statusElements = []statusElement{
+	{"bsky:pinned", func(item feed.Item) bool { return item.Pinned }},
+	{"bsky:deleted", func(item feed.Item) bool { return item.Deleted }},
+	{"bsky:draft", func(item feed.Item) bool { return item.Draft }},
+}
+
+// withStatusElements returns a copy of the feed with bsky:pinned,
+// bsky:deleted, and bsky:draft elements for the items that are pinned, were
+// deleted, or are drafts.
+// Unlike the other elements, the status elements of items that were merged
+// from previous output are removed, because the status of a post is
+// determined again on every run.
+func withStatusElements(f feed.RSS) feed.RSS {
+	f.Channel.Items = slices.Clone(f.Channel.Items)
+	for i := range f.Channel.Items {
+		item := &f.Channel.Items[i]
+		item.Extensions = slices.DeleteFunc(
+			slices.Clone(item.Extensions),
+			isStatusElement,
+		)
+		for _, status := range statusElements {
+			if status.has(*item) {
+				item.Extensions = append(item.Extensions, feed.Extension{
+					XMLName:   xml.Name{Local: status.name},
+					InnerXML:  "true",
+					Namespace: feed.BlueskyNamespace,
+				})
+			}
+		}
+	}
+
+	return f
+}
+
+// isStatusElement reports whether ext is one of the elements that mark the
+// status of an item.
+func isStatusElement(ext feed.Extension) bool {
+	return ext.Namespace == feed.BlueskyNamespace &&
+		slices.ContainsFunc(statusElements, func(s statusElement) bool {
+			return s.name == ext.XMLName.Local
+		})
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/xml"
+	"html"
+	"slices"
+	"strings"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// withSummaryElements returns a copy of the feed in which the description
+// of each item that has a summary is replaced by the summary, and the full
+// description is moved into a content:encoded element. Feed readers show
+// the description as the excerpt of an item and the content:encoded
+// element as its content.
+func withSummaryElements(f feed.RSS) feed.RSS {
+	f.Channel.Items = slices.Clone(f.Channel.Items)
+	for i := range f.Channel.Items {
+		item := &f.Channel.Items[i]
+		if item.Summary == "" {
+			continue
+		}
+
+		item.Extensions = append(
+			slices.DeleteFunc(slices.Clone(item.Extensions), isContentEncoded),
+			feed.Extension{
+				XMLName:   xml.Name{Local: "content:encoded"},
+				InnerXML:  cdata(item.HTML()),
+				Namespace: feed.ContentNamespace,
+			},
+		)
+		item.Description = html.EscapeString(item.Summary)
+	}
+
+	return f
+}
+
+// itemFromRSSItem restores the description of an item that was read from
+// RSS output in which withSummaryElements replaced the description with
+// the summary of the item.
+func itemFromRSSItem(item feed.Item) feed.Item {
+	i := slices.IndexFunc(item.Extensions, isContentEncoded)
+	if i < 0 {
+		return item
+	}
+
+	var content string
+	wrapped := "<content>" + item.Extensions[i].InnerXML + "</content>"
+	if xml.Unmarshal([]byte(wrapped), &content) != nil {
+		return item
+	}
+
+	item.Summary = html.UnescapeString(item.Description)
+	item.Description = content
+	item.Extensions = slices.Delete(slices.Clone(item.Extensions), i, i+1)
+	return item
+}
+
+// isContentEncoded reports whether ext is a content:encoded element.
+func isContentEncoded(ext feed.Extension) bool {
+	return ext.Namespace == feed.ContentNamespace &&
+		strings.HasSuffix(ext.XMLName.Local, ":encoded")
+}
+
+// cdata returns s as a CDATA section. A CDATA section cannot contain ]]>,
+// so the section is split where s contains ]]>.
+func cdata(s string) string {
+	return "<![CDATA[" + strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>") +
+		"]]>"
+}
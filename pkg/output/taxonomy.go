@@ -0,0 +1,137 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Taxonomies assigns the hashtags of posts to the taxonomies of the Hugo
+// site, such as tags and categories, so that the content pages of the posts
+// are listed on the term pages of the site. The taxonomies are written to
+// the front matter of the content pages.
+type Taxonomies struct {
+	// Default is the taxonomy that the hashtags that are not in Terms are
+	// assigned to. The hashtags are not assigned if Default is empty.
+	Default string
+
+	// Terms maps lowercase hashtags without the leading # to the taxonomy
+	// terms that they are assigned to. A hashtag that is mapped to a
+	// TaxonomyTerm without a taxonomy is not assigned to any taxonomy.
+	Terms map[string]TaxonomyTerm
+}
+
+// TaxonomyTerm is a term of a Hugo taxonomy.
+type TaxonomyTerm struct {
+	Taxonomy string
+
+	// Term is the name of the term. The hashtag is used as the term if Term
+	// is empty.
+	Term string
+}
+
+// ParseTaxonomyTerm parses a taxonomy term that is formatted as taxonomy or
+// taxonomy:term. An empty value is a TaxonomyTerm without a taxonomy.
+func ParseTaxonomyTerm(value string) (TaxonomyTerm, error) {
+	taxonomy, term, _ := strings.Cut(value, ":")
+	t := TaxonomyTerm{
+		Taxonomy: strings.TrimSpace(taxonomy),
+		Term:     strings.TrimSpace(term),
+	}
+	if t.Taxonomy == "" {
+		return TaxonomyTerm{}, nil
+	}
+
+	return t, ValidateTaxonomy(t.Taxonomy)
+}
+
+// ValidateTaxonomy returns an error if name cannot be used as the name of a
+// taxonomy in the front matter of the content pages. The name of a taxonomy
+// can only contain letters, digits, hyphens, and underscores, and cannot be
+// the name of one of the other fields of the front matter.
+func ValidateTaxonomy(name string) error {
+	valid := name != "" && strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			r >= '0' && r <= '9' || r == '-' || r == '_')
+	}) < 0
+	if !valid {
+		return fmt.Errorf("the taxonomy name %q is not valid", name)
+	}
+
+	if slices.Contains(frontMatterFields(), name) {
+		return fmt.Errorf(
+			"the taxonomy name %q is already a front matter field",
+			name,
+		)
+	}
+
+	return nil
+}
+
+// frontMatterFields returns the names of the fields of FrontMatter.
+func frontMatterFields() []string {
+	var names []string
+	t := reflect.TypeFor[FrontMatter]()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Assign returns the terms that the hashtags are assigned to, keyed by the
+// name of the taxonomy. The terms of each taxonomy are in the order of the
+// hashtags and do not contain duplicates. Nil is returned if none of the
+// hashtags are assigned to a taxonomy.
+func (t Taxonomies) Assign(hashtags []string) map[string][]string {
+	var result map[string][]string
+	for _, hashtag := range hashtags {
+		term, ok := t.Terms[hashtag]
+		if !ok {
+			term = TaxonomyTerm{Taxonomy: t.Default}
+		}
+
+		if term.Taxonomy == "" {
+			continue
+		}
+
+		name := term.Term
+		if name == "" {
+			name = hashtag
+		}
+
+		if result == nil {
+			result = make(map[string][]string)
+		}
+
+		if !slices.Contains(result[term.Taxonomy], name) {
+			result[term.Taxonomy] = append(result[term.Taxonomy], name)
+		}
+	}
+
+	return result
+}
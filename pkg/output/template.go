@@ -0,0 +1,158 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// TemplateFeed is the data that the template of the template format is
+// executed with.
+type TemplateFeed struct {
+	Title       string
+	Link        string
+	Description string
+	Posts       []TemplatePost
+}
+
+// TemplatePost is the normalized representation of a post that is passed to
+// the template of the template format. The fields that are only known for
+// posts that were fetched from the xrpc source are empty for posts that
+// were read from the RSS feed.
+type TemplatePost struct {
+	Title     string
+	GUID      string
+	URL       string
+	Date      string
+	Published time.Time
+	Text      string
+	HTML      string
+	Markdown  string
+	Summary   string
+	Hashtags  []string
+	Languages []string
+	Labels    []string
+	IsReply   bool
+	IsRepost  bool
+	Author    feed.ProfileViewBasic
+	Media     []feed.Media
+	LinkCard  *feed.LinkCard
+	Quote     *feed.Quote
+
+	// Engagement contains the engagement counts of the post, or nil if the
+	// counts are not known.
+	Engagement *feed.Engagement
+
+	// Pinned reports whether the post is the post that the author pinned to
+	// their profile.
+	Pinned bool
+
+	// Deleted reports whether the post was deleted on Bluesky after it was
+	// written to the previous output.
+	Deleted bool
+
+	// Draft reports whether the post is a draft that is not published yet.
+	Draft bool
+}
+
+// TemplateFuncs are the functions that are available to the templates of
+// the template format in addition to the built-in template functions.
+var TemplateFuncs = template.FuncMap{
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"join":  strings.Join,
+	"quote": strconv.Quote,
+}
+
+// ParseTemplate parses the template file name for the template format.
+func ParseTemplate(name string) (*template.Template, error) {
+	return template.New(filepath.Base(name)).
+		Funcs(TemplateFuncs).
+		ParseFiles(name)
+}
+
+// NewTemplateFeed converts an RSS feed into the data for a template.
+func NewTemplateFeed(f feed.RSS) TemplateFeed {
+	result := TemplateFeed{
+		Title:       f.Channel.Title,
+		Link:        f.Channel.Link,
+		Description: f.Channel.Description,
+		Posts:       make([]TemplatePost, 0, len(f.Channel.Items)),
+	}
+	for _, item := range f.Channel.Items {
+		result.Posts = append(result.Posts, NewTemplatePost(item))
+	}
+
+	return result
+}
+
+// NewTemplatePost converts an item of an RSS feed into the data for a
+// template.
+func NewTemplatePost(item feed.Item) TemplatePost {
+	post := TemplatePost{
+		Title:     item.Title,
+		GUID:      item.GUID.Value,
+		URL:       item.Link,
+		Date:      item.PubDate,
+		Published: item.Published,
+		Text:      item.PlainText(),
+		HTML:      item.HTML(),
+		Markdown:  item.MarkdownText(),
+		Summary:   item.Summary,
+		Hashtags:  item.Hashtags(),
+		Languages: item.Languages,
+		Labels:    item.Labels,
+		IsReply:   item.IsReply(),
+		IsRepost:  item.IsRepost(),
+		Media:     item.Media,
+		LinkCard:  item.LinkCard,
+		Quote:     item.Quote,
+
+		Engagement: item.Engagement,
+		Pinned:     item.Pinned,
+		Deleted:    item.Deleted,
+		Draft:      item.Draft,
+	}
+	if item.Author != nil {
+		post.Author = *item.Author
+	}
+
+	return post
+}
+
+// writeTemplate executes tmpl with the data for the feed and writes the
+// result to w.
+func writeTemplate(w io.Writer, f feed.RSS, tmpl *template.Template) error {
+	if tmpl == nil {
+		return errors.New("the template format requires a template")
+	}
+
+	return tmpl.Execute(w, NewTemplateFeed(f))
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
+)
+
+// TitleStyle determines how the titles of the posts are derived from the
+// text of the posts. Bluesky posts do not have titles, but many Hugo themes
+// and feed readers expect every item to have one.
+type TitleStyle string
+
+const (
+	// NoTitle does not add titles to the posts. The formats that require a
+	// title, such as Atom, use the first line of the text of the post.
+	NoTitle TitleStyle = "none"
+
+	// LineTitle uses the text of the post up to the first line break.
+	LineTitle TitleStyle = "line"
+
+	// SentenceTitle uses the first sentence of the text of the post.
+	SentenceTitle TitleStyle = "sentence"
+
+	// WordsTitle uses the first words of the text of the post.
+	WordsTitle TitleStyle = "words"
+
+	// TemplateTitle executes a template with the TemplatePost of the post.
+	TemplateTitle TitleStyle = "template"
+)
+
+// TitleStyles are the supported title styles.
+var TitleStyles = []TitleStyle{
+	NoTitle, LineTitle, SentenceTitle, WordsTitle, TemplateTitle,
+}
+
+// DefaultTitleWords is the number of words of the text of a post that are
+// used as the title by the words style when no other number is configured.
+const DefaultTitleWords = 10
+
+// maxTitleLength is the maximum number of characters that will be used from
+// the post text when a title is derived from a line or a sentence.
+const maxTitleLength = 60
+
+// withTitles returns a copy of the feed in which every item has a title
+// that is derived using the title style of opts. The items of the feed are
+// not changed if the style is NoTitle or empty, which keeps the titles of
+// items that were read from the existing output.
+func withTitles(f feed.RSS, opts Options) (feed.RSS, error) {
+	if opts.TitleStyle == "" || opts.TitleStyle == NoTitle {
+		return f, nil
+	}
+
+	f.Channel.Items = slices.Clone(f.Channel.Items)
+	for i := range f.Channel.Items {
+		title, err := itemTitle(f.Channel.Items[i], opts)
+		if err != nil {
+			return feed.RSS{}, fmt.Errorf(
+				"failed to create the title of %s: %w",
+				f.Channel.Items[i].Link,
+				err,
+			)
+		}
+
+		f.Channel.Items[i].Title = title
+	}
+
+	return f, nil
+}
+
+// itemTitle derives the title of item using the title style of opts.
+func itemTitle(item feed.Item, opts Options) (string, error) {
+	text := item.PlainText()
+	switch opts.TitleStyle {
+	case LineTitle:
+		return PostTitle(text), nil
+	case SentenceTitle:
+		return shortenTitle(firstSentence(text)), nil
+	case WordsTitle:
+		words := cmp.Or(opts.TitleWords, DefaultTitleWords)
+		return firstWords(text, words), nil
+	case TemplateTitle:
+		if opts.TitleTemplate == nil {
+			return "", errors.New("the template title style requires a template")
+		}
+
+		var buf bytes.Buffer
+		err := opts.TitleTemplate.Execute(&buf, NewTemplatePost(item))
+		if err != nil {
+			return "", err
+		}
+
+		return strings.Join(strings.Fields(buf.String()), " "), nil
+	default:
+		return "", fmt.Errorf("unsupported title style %q", opts.TitleStyle)
+	}
+}
+
+// ParseTitleTemplate parses the text of a title template. The template has
+// the same functions as the templates of the template format.
+func ParseTitleTemplate(text string) (*template.Template, error) {
+	return template.New("title").Funcs(TemplateFuncs).Parse(text)
+}
+
+// PostTitle derives a title for a post from the first line of the post
+// text. Long lines are shortened at a word boundary.
+func PostTitle(text string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return shortenTitle(strings.TrimSpace(title))
+}
+
+// shortenTitle shortens a title that is longer than maxTitleLength
+// characters at a word boundary.
+func shortenTitle(title string) string {
+	if transform.GraphemeCount(title) <= maxTitleLength {
+		return title
+	}
+
+	cut := transform.Truncate(title, maxTitleLength)
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		return cut[:i] + "…"
+	}
+
+	return cut + "…"
+}
+
+// firstSentence returns the first sentence of text. A sentence ends with a
+// period, question mark, or exclamation mark that is followed by a space,
+// or with a line break.
+func firstSentence(text string) string {
+	text = strings.TrimSpace(text)
+	for i, r := range text {
+		switch r {
+		case '\n':
+			return strings.TrimSpace(text[:i])
+		case '.', '?', '!':
+			next, _ := utf8.DecodeRuneInString(text[i+1:])
+			if i+1 == len(text) || unicode.IsSpace(next) {
+				return text[:i+1]
+			}
+		}
+	}
+
+	return text
+}
+
+// firstWords returns the first n words of text. An ellipsis is added if
+// the text has more words.
+func firstWords(text string, n int) string {
+	words := strings.Fields(text)
+	if len(words) <= n {
+		return strings.Join(words, " ")
+	}
+
+	return strings.Join(words[:n], " ") + "…"
+}
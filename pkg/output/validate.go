@@ -0,0 +1,340 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/transform"
+)
+
+// jsonFeedVersion1 is the version of JSON Feed 1.0 documents, which are
+// also accepted by Validate.
+const jsonFeedVersion1 = "https://jsonfeed.org/version/1"
+
+// rfc822Layouts are the layouts of the RFC 822 dates that RSS 2.0 requires.
+// The day of the week is optional and the day of the month can have one or
+// two digits.
+var rfc822Layouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"02 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+}
+
+// ValidateOptions control how the output is validated.
+type ValidateOptions struct {
+	// DateFormat and DateLayouts are the date format and the additional
+	// date layouts that the dates of the RSS output were written with.
+	DateFormat  string
+	DateLayouts []string
+
+	// Strict requires that the dates of the RSS output are RFC 822 dates,
+	// as RSS 2.0 specifies, instead of dates that use DateFormat.
+	Strict bool
+}
+
+// Validate checks that data is a structurally valid RSS 2.0, Atom 1.0, or
+// JSON Feed 1.1 document. The elements that the specifications require must
+// be present, the dates must be valid, and the GUIDs or IDs of the items
+// must be unique. Validate returns a description of each problem that is
+// found, or an error if format cannot be validated.
+func Validate(
+	format string,
+	data []byte,
+	opts ValidateOptions,
+) ([]string, error) {
+	switch format {
+	case "rss":
+		return validateRSS(data, opts), nil
+	case "atom":
+		return validateAtom(data), nil
+	case "jsonfeed":
+		return validateJSONFeed(data), nil
+	default:
+		return nil, fmt.Errorf(
+			"validating the %s format is not supported",
+			format,
+		)
+	}
+}
+
+// validator collects the problems that are found in a document.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// require reports a problem if the value of the element or field is empty.
+func (v *validator) require(where string, name string, value string) {
+	if value == "" {
+		v.addf("%s: the %s is required", where, name)
+	}
+}
+
+// absoluteURL reports a problem if value is not an absolute URL.
+func (v *validator) absoluteURL(where string, name string, value string) {
+	if u, err := url.Parse(value); err != nil || !u.IsAbs() {
+		v.addf("%s: the %s %q is not an absolute URL", where, name, value)
+	}
+}
+
+// unique reports a problem if the value of the ID of an item has already
+// been seen.
+func (v *validator) unique(
+	where string,
+	name string,
+	value string,
+	seen map[string]bool,
+) {
+	if value == "" {
+		return
+	}
+
+	if seen[value] {
+		v.addf("%s: the %s %q is not unique", where, name, value)
+	}
+
+	seen[value] = true
+}
+
+// rfc3339 reports a problem if value is not an RFC 3339 timestamp.
+func (v *validator) rfc3339(where string, name string, value string) {
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		v.addf("%s: the %s %q is not an RFC 3339 date", where, name, value)
+	}
+}
+
+func validateRSS(data []byte, opts ValidateOptions) []string {
+	var v validator
+	var f feed.RSS
+	if err := xml.Unmarshal(data, &f); err != nil {
+		v.addf("the document is not a valid RSS document: %v", err)
+		return v.problems
+	}
+
+	if f.Version != "2.0" {
+		v.addf("rss: the version %q is not 2.0", f.Version)
+	}
+
+	v.require("channel", "title", f.Channel.Title)
+	v.require("channel", "link", f.Channel.Link)
+	v.require("channel", "description", f.Channel.Description)
+	if f.Channel.Link != "" {
+		v.absoluteURL("channel", "link", f.Channel.Link)
+	}
+
+	date := func(where string, name string, value string) {
+		if opts.Strict {
+			if _, err := parseRFC822(value); err != nil {
+				v.addf(
+					"%s: the %s %q is not an RFC 822 date",
+					where,
+					name,
+					value,
+				)
+			}
+
+			return
+		}
+
+		_, err := transform.ParseFormattedDate(
+			value,
+			opts.DateFormat,
+			opts.DateLayouts,
+		)
+		if err != nil {
+			v.addf("%s: the %s %q is not a valid date", where, name, value)
+		}
+	}
+	if value := f.Channel.LastBuildDate; value != "" {
+		if _, err := parseRFC822(value); err != nil {
+			v.addf(
+				"channel: the lastBuildDate %q is not an RFC 822 date",
+				value,
+			)
+		}
+	}
+
+	guids := map[string]bool{}
+	for i, item := range f.Channel.Items {
+		where := fmt.Sprintf("item %d", i+1)
+		if item.Title == "" && item.Description == "" {
+			v.addf("%s: a title or description element is required", where)
+		}
+
+		if item.Link != "" {
+			v.absoluteURL(where, "link", item.Link)
+		}
+
+		if item.PubDate != "" {
+			date(where, "pubDate", item.PubDate)
+		}
+
+		v.unique(where, "guid", item.GUID.Value, guids)
+		if item.GUID.Value != "" && item.GUID.IsPermaLink != "false" {
+			v.absoluteURL(where, "permalink guid", item.GUID.Value)
+		}
+
+		if e := item.Enclosure; e != nil {
+			if e.URL == "" || e.Length == "" || e.Type == "" {
+				v.addf(
+					"%s: the enclosure element requires url, length, "+
+						"and type attributes",
+					where,
+				)
+			}
+		}
+	}
+
+	return v.problems
+}
+
+// parseRFC822 parses an RFC 822 date.
+func parseRFC822(value string) (time.Time, error) {
+	var err error
+	for _, layout := range rfc822Layouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, err
+}
+
+func validateAtom(data []byte) []string {
+	var v validator
+	var f AtomFeed
+	if err := xml.Unmarshal(data, &f); err != nil {
+		v.addf("the document is not a valid Atom document: %v", err)
+		return v.problems
+	}
+
+	if f.XMLName.Space != feed.AtomNamespace {
+		v.addf(
+			"feed: the namespace %q is not the Atom namespace",
+			f.XMLName.Space,
+		)
+	}
+
+	v.require("feed", "id", f.ID)
+	v.require("feed", "title", f.Title)
+	v.require("feed", "updated", f.Updated)
+	if f.Updated != "" {
+		v.rfc3339("feed", "updated", f.Updated)
+	}
+
+	ids := map[string]bool{}
+	anonymous := false
+	for i, entry := range f.Entries {
+		where := fmt.Sprintf("entry %d", i+1)
+		if entry.Author == nil || entry.Author.Name == "" {
+			anonymous = true
+		}
+
+		v.require(where, "id", entry.ID)
+		v.require(where, "title", entry.Title)
+		v.require(where, "updated", entry.Updated)
+		if entry.Updated != "" {
+			v.rfc3339(where, "updated", entry.Updated)
+		}
+
+		v.unique(where, "id", entry.ID, ids)
+	}
+
+	// Atom requires an author for every entry. writeAtom only writes the
+	// authors of the posts that have them, such as the posts of an
+	// aggregated feed, so the other entries need the author of the feed.
+	if anonymous && f.Author.Name == "" {
+		v.addf("feed: the author is required")
+	}
+
+	return v.problems
+}
+
+// jsonFeedDocument contains the parts of a JSON Feed document that are
+// validated. Unlike JSONFeedItem, the items can have plain text content.
+type jsonFeedDocument struct {
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	Items   *[]struct {
+		ID            string `json:"id"`
+		ContentHTML   string `json:"content_html"`
+		ContentText   string `json:"content_text"`
+		DatePublished string `json:"date_published"`
+		DateModified  string `json:"date_modified"`
+	} `json:"items"`
+}
+
+func validateJSONFeed(data []byte) []string {
+	var v validator
+	var f jsonFeedDocument
+	if err := json.Unmarshal(data, &f); err != nil {
+		v.addf("the document is not a valid JSON Feed document: %v", err)
+		return v.problems
+	}
+
+	if f.Version != jsonFeedVersion && f.Version != jsonFeedVersion1 {
+		v.addf("feed: the version %q is not a JSON Feed version", f.Version)
+	}
+
+	v.require("feed", "title", f.Title)
+	if f.Items == nil {
+		v.addf("feed: the items field is required")
+		return v.problems
+	}
+
+	ids := map[string]bool{}
+	for i, item := range *f.Items {
+		where := fmt.Sprintf("item %d", i+1)
+		v.require(where, "id", item.ID)
+		v.unique(where, "id", item.ID, ids)
+		if item.ContentHTML == "" && item.ContentText == "" {
+			v.addf(
+				"%s: a content_html or content_text field is required",
+				where,
+			)
+		}
+
+		if item.DatePublished != "" {
+			v.rfc3339(where, "date_published", item.DatePublished)
+		}
+
+		if item.DateModified != "" {
+			v.rfc3339(where, "date_modified", item.DateModified)
+		}
+	}
+
+	return v.problems
+}
@@ -0,0 +1,187 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDateFormat is the layout that is used to rewrite the pubDate field
+// when a date format is not specified. Hugo is able to parse dates that are
+// formatted using this layout.
+const DefaultDateFormat = "2006-01-02T15:04:05-07:00"
+
+// dateFormatPresets maps the named presets that can be used as a date
+// format to Go time layouts. The unix preset is handled
+// separately by FormatPubDate because it is not a layout.
+var dateFormatPresets = map[string]string{
+	"rfc822":  time.RFC822Z,
+	"rfc1123": time.RFC1123Z,
+	"rfc3339": DefaultDateFormat,
+}
+
+// layoutComponents are the elements of the Go reference time that a date
+// format needs to contain at least one of to be used as a layout. The
+// single-digit elements are not included because they also match numbers
+// in the names of misspelled presets, such as rfc1123z.
+var layoutComponents = []string{
+	"2006", "Jan", "Mon", "01", "02", "_2", "002", "15", "03", "04", "05",
+	"PM", "pm", "MST", "Z07", "-07",
+}
+
+// PubDateLayouts are the layouts that are used to parse the pubDate field of
+// the feed items, in priority order. The first layouts are the formats that
+// Bluesky has used in its RSS feeds. The remaining layouts are the common
+// RFC 822, RFC 1123, and ISO 8601 formats used by other feeds and by feeds
+// that are synthesized from AT Protocol records. RFC 822 allows the day of
+// the month to have a single digit, so the layouts that start with the day
+// are also accepted with a single-digit day.
+var PubDateLayouts = []string{
+	"02 Jan 2006 15:04 -0700",
+	"02 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	time.DateTime,
+	time.DateOnly,
+}
+
+// ParsePubDate parses the value of a pubDate field. The user-supplied
+// layouts in extra are tried first, followed by the built-in layouts in
+// PubDateLayouts. An error is returned only if none of the layouts match
+// the value.
+func ParsePubDate(value string, extra []string) (time.Time, error) {
+	return parsePubDate(value, extra, time.UTC)
+}
+
+// parsePubDate parses the value of a pubDate field like ParsePubDate. Dates
+// that do not include a time zone are parsed as dates in loc.
+func parsePubDate(
+	value string,
+	extra []string,
+	loc *time.Location,
+) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layouts := range [][]string{extra, PubDateLayouts} {
+		for _, layout := range layouts {
+			t, err := time.ParseInLocation(layout, value, loc)
+			if err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf(
+		"%q does not match any of the supported date layouts",
+		value,
+	)
+}
+
+// ParseFormattedDate parses a date that was formatted by FormatPubDate using
+// format. The layouts in extra, the built-in layouts, and Unix timestamps
+// are also accepted so that dates written using a different format by a
+// previous run can be parsed.
+func ParseFormattedDate(
+	value string,
+	format string,
+	extra []string,
+) (time.Time, error) {
+	return parseFormattedDate(value, format, extra, time.UTC)
+}
+
+// parseFormattedDate parses a date like ParseFormattedDate. Dates that do
+// not include a time zone are parsed as dates in loc.
+func parseFormattedDate(
+	value string,
+	format string,
+	extra []string,
+	loc *time.Location,
+) (time.Time, error) {
+	layout, ok := dateFormatPresets[strings.ToLower(format)]
+	if !ok {
+		layout = format
+	}
+
+	t, err := parsePubDate(value, append([]string{layout}, extra...), loc)
+	if err != nil {
+		seconds, parseErr := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if parseErr != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	return t, nil
+}
+
+// ValidateDateFormat returns an error if format cannot be used by
+// FormatPubDate. The format needs to be one of the presets, unix, or a Go
+// time layout that contains at least one element of the reference time, so
+// that a misspelled preset is not used as a layout that formats every date
+// as the name of the preset.
+func ValidateDateFormat(format string) error {
+	name := strings.ToLower(format)
+	if _, ok := dateFormatPresets[name]; ok || name == "unix" {
+		return nil
+	}
+
+	for _, component := range layoutComponents {
+		if strings.Contains(format, component) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"the date format %q is not a preset or a Go time layout",
+		format,
+	)
+}
+
+// FormatPubDate formats t using format, which is either the name of one of
+// the presets in dateFormatPresets, unix to format the timestamp as the
+// number of seconds since the Unix epoch, or a Go time layout.
+func FormatPubDate(t time.Time, format string) string {
+	name := strings.ToLower(format)
+	if name == "unix" {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+
+	if layout, ok := dateFormatPresets[name]; ok {
+		return t.Format(layout)
+	}
+
+	return t.Format(format)
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"slices"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// DeletedPolicy determines how the posts of the previous output that no
+// longer exist on Bluesky are handled when new posts are merged into the
+// output.
+type DeletedPolicy string
+
+const (
+	// KeepDeleted keeps the posts without checking whether they still
+	// exist.
+	KeepDeleted DeletedPolicy = "keep"
+
+	// MarkDeleted keeps the posts and marks them as deleted in the output
+	// formats that support it, such as the front matter of the content
+	// pages.
+	MarkDeleted DeletedPolicy = "mark"
+
+	// DropDeleted removes the posts.
+	DropDeleted DeletedPolicy = "drop"
+)
+
+// Apply returns a copy of the items with the policy applied. deleted
+// contains the AT URIs of the posts that no longer exist. The items for the
+// deleted posts are marked as deleted if the policy is MarkDeleted and are
+// removed if the policy is DropDeleted.
+func (p DeletedPolicy) Apply(
+	items []feed.Item,
+	deleted map[string]bool,
+) []feed.Item {
+	if p == KeepDeleted || len(deleted) == 0 {
+		return items
+	}
+
+	items = slices.Clone(items)
+	if p == DropDeleted {
+		return slices.DeleteFunc(items, func(item feed.Item) bool {
+			return deleted[item.URI()]
+		})
+	}
+
+	for i := range items {
+		if deleted[items[i].URI()] {
+			items[i].Deleted = true
+		}
+	}
+
+	return items
+}
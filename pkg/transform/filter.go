@@ -0,0 +1,210 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// Filter selects the items that are removed from a feed. The zero value
+// does not remove any items.
+type Filter struct {
+	// ExcludeReplies removes the posts that are replies to other posts.
+	ExcludeReplies bool
+
+	// ExcludeReposts removes the posts by other accounts that were reposted
+	// by the author of the feed.
+	ExcludeReposts bool
+
+	// IncludeTags keeps only the posts that have at least one of the
+	// hashtags in the set. The set is created using HashtagSet.
+	IncludeTags map[string]bool
+
+	// ExcludeTags removes the posts that have any of the hashtags in the
+	// set. The set is created using HashtagSet.
+	ExcludeTags map[string]bool
+
+	// IncludePattern keeps only the posts whose text matches the pattern.
+	IncludePattern *regexp.Regexp
+
+	// ExcludePattern removes the posts whose text matches the pattern.
+	ExcludePattern *regexp.Regexp
+
+	// ExcludeLabels removes the posts that have any of the labels in the
+	// set. The set is created using LabelSet.
+	ExcludeLabels map[string]bool
+
+	// Languages keeps only the posts that have at least one of the
+	// languages in the set. The set is created using LanguageSet. Posts
+	// whose languages are not known are removed.
+	Languages map[string]bool
+
+	// Since removes the posts that were published before the time, and
+	// Until removes the posts that were published after the time. The
+	// zero value does not remove any posts. Posts whose publication date
+	// could not be parsed are not removed.
+	Since time.Time
+	Until time.Time
+}
+
+// Exclude reports whether item is removed from the feed by the filter.
+func (f *Filter) Exclude(item feed.Item) bool {
+	if f.ExcludeReplies && item.IsReply() {
+		return true
+	}
+
+	if f.ExcludeReposts && item.IsRepost() {
+		return true
+	}
+
+	if len(f.IncludeTags) > 0 || len(f.ExcludeTags) > 0 {
+		included := len(f.IncludeTags) == 0
+		for _, tag := range item.Hashtags() {
+			if f.ExcludeTags[tag] {
+				return true
+			}
+
+			if f.IncludeTags[tag] {
+				included = true
+			}
+		}
+
+		if !included {
+			return true
+		}
+	}
+
+	if !item.Published.IsZero() {
+		if !f.Since.IsZero() && item.Published.Before(f.Since) {
+			return true
+		}
+
+		if !f.Until.IsZero() && item.Published.After(f.Until) {
+			return true
+		}
+	}
+
+	if len(MatchingLabels(item, f.ExcludeLabels)) > 0 {
+		return true
+	}
+
+	if len(f.Languages) > 0 && !f.hasLanguage(item) {
+		return true
+	}
+
+	text := item.PlainText()
+	if f.IncludePattern != nil && !f.IncludePattern.MatchString(text) {
+		return true
+	}
+
+	if f.ExcludePattern != nil && f.ExcludePattern.MatchString(text) {
+		return true
+	}
+
+	return false
+}
+
+// hasLanguage reports whether one of the languages of the item is in the
+// Languages set of the filter. A language such as en-US also matches the
+// language en.
+func (f *Filter) hasLanguage(item feed.Item) bool {
+	for _, lang := range item.Languages {
+		lang = strings.ToLower(lang)
+		primary, _, _ := strings.Cut(lang, "-")
+		if f.Languages[lang] || f.Languages[primary] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LanguageSet converts a list of BCP 47 language tags into a set. The tags
+// are compared without regard to case.
+func LanguageSet(langs []string) map[string]bool {
+	set := make(map[string]bool, len(langs))
+	for _, lang := range langs {
+		if lang = strings.ToLower(strings.TrimSpace(lang)); lang != "" {
+			set[lang] = true
+		}
+	}
+
+	return set
+}
+
+// ParseDateBound parses the value of a date that limits the items that are
+// kept by a Filter. The value is either a date that uses one of the layouts
+// in PubDateLayouts, such as 2025-03-01, or a duration that is subtracted
+// from now, such as 30d. Durations can use the units that are supported by
+// time.ParseDuration, d for days, and w for weeks.
+func ParseDateBound(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := ParsePubDate(value, nil); err == nil {
+		return t, nil
+	}
+
+	d, err := parseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date or a duration", value)
+	}
+
+	return now.Add(-d), nil
+}
+
+// parseDuration parses a duration that can use days and weeks as units in
+// addition to the units that are supported by time.ParseDuration.
+func parseDuration(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, err
+			}
+
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	return time.ParseDuration(value)
+}
+
+// HashtagSet converts a list of hashtags into a set. The hashtags are
+// compared without regard to case or a leading #.
+func HashtagSet(tags []string) map[string]bool {
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag != "" {
+			set[tag] = true
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"slices"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// FuturePolicy determines how the posts whose dates are in the future are
+// handled. Hugo does not publish future content unless the site is built
+// with --buildFuture, so posts that are scheduled by a cross-posting tool
+// would otherwise be missing from the site until it is rebuilt after the
+// date of the post.
+type FuturePolicy string
+
+const (
+	// KeepFuture keeps the posts and their dates.
+	KeepFuture FuturePolicy = "keep"
+
+	// ClampFuture changes the dates of the posts to the time of the run.
+	ClampFuture FuturePolicy = "clamp"
+
+	// DraftFuture keeps the posts and marks them as drafts.
+	DraftFuture FuturePolicy = "draft"
+
+	// DropFuture removes the posts.
+	DropFuture FuturePolicy = "drop"
+)
+
+// Schedule marks the posts that should not be published yet as drafts,
+// which Hugo does not publish unless the site is built with --buildDrafts.
+type Schedule struct {
+	// Future determines how the posts whose dates are in the future are
+	// handled. The posts are kept if Future is empty.
+	Future FuturePolicy
+
+	// DraftAfter is the date after which the posts are marked as drafts,
+	// such as a day before the time of the run, which gives the author a
+	// day to review the posts before they are published. No posts are
+	// marked as drafts if DraftAfter is zero.
+	DraftAfter time.Time
+
+	// DateFormat is the format that is used to rewrite the dates of the
+	// posts that are clamped.
+	DateFormat string
+}
+
+// Apply returns a copy of the items with the schedule applied at now. The
+// posts are marked as drafts again on every run, so a post stops being a
+// draft once its date is before DraftAfter or before now. Items whose dates
+// were not parsed are not changed.
+func (s Schedule) Apply(items []feed.Item, now time.Time) []feed.Item {
+	items = slices.Clone(items)
+	if s.Future == DropFuture {
+		items = slices.DeleteFunc(items, func(item feed.Item) bool {
+			return item.Published.After(now)
+		})
+	}
+
+	for i := range items {
+		item := &items[i]
+		if item.Published.IsZero() {
+			continue
+		}
+
+		future := item.Published.After(now)
+		if future && s.Future == ClampFuture {
+			item.Published = now.In(item.Published.Location())
+			item.PubDate = FormatPubDate(item.Published, s.DateFormat)
+			future = false
+		}
+
+		item.Draft = (future && s.Future == DraftFuture) ||
+			(!s.DraftAfter.IsZero() && item.Published.After(s.DraftAfter))
+	}
+
+	return items
+}
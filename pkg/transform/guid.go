@@ -0,0 +1,74 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// GUIDPolicy determines the GUIDs of the items in the output.
+type GUIDPolicy string
+
+const (
+	// URIGUID uses the AT URI of the post as the GUID. The AT URI
+	// identifies the author by their DID, so the GUID does not change when
+	// the author changes their handle or when Bluesky changes the format of
+	// its web URLs.
+	URIGUID GUIDPolicy = "uri"
+
+	// HashGUID uses a SHA-256 hash of the DID of the author and the record
+	// key of the post as the GUID. The hash is as stable as the AT URI, but
+	// does not reveal the DID of the author.
+	HashGUID GUIDPolicy = "hash"
+
+	// LinkGUID uses the bsky.app web URL of the post as the GUID and marks
+	// the GUID as a permalink.
+	LinkGUID GUIDPolicy = "link"
+)
+
+// SetGUID replaces the GUID of item using policy. uri is the AT URI of the
+// post of the item. The GUID of an item is not changed if the GUID that the
+// policy requires cannot be determined, such as when the item does not have
+// an AT URI.
+func SetGUID(item *feed.Item, uri string, policy GUIDPolicy) {
+	switch policy {
+	case URIGUID:
+		if uri != "" {
+			item.GUID = feed.GUID{IsPermaLink: "false", Value: uri}
+		}
+	case HashGUID:
+		if did := feed.AuthorityDID(uri); did != "" {
+			sum := sha256.Sum256([]byte(did + "/" + path.Base(uri)))
+			item.GUID = feed.GUID{
+				IsPermaLink: "false",
+				Value:       hex.EncodeToString(sum[:]),
+			}
+		}
+	case LinkGUID:
+		if item.Link != "" {
+			item.GUID = feed.GUID{IsPermaLink: "true", Value: item.Link}
+		}
+	}
+}
@@ -0,0 +1,431 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// ImageMirror downloads the images that are attached to posts, and the
+// thumbnails of the videos that are attached to posts, into a directory of
+// the Hugo site so that the site does not hotlink the Bluesky CDN. The
+// videos themselves are streamed by Bluesky and are not downloaded.
+type ImageMirror struct {
+	// Fetcher downloads the images.
+	Fetcher *feed.Fetcher
+
+	// Dir is the directory that the images are downloaded into.
+	Dir string
+
+	// BaseURL is the URL that the site uses to reference Dir.
+	BaseURL string
+
+	// Bundle returns the directory of the Hugo page bundle of an item
+	// relative to Dir. When Bundle is set, the images of each item are
+	// downloaded into the page bundle of the item and are referenced by
+	// their file names, which Hugo resolves relative to the page, instead of
+	// using BaseURL.
+	Bundle func(feed.Item) string
+
+	// DryRun rewrites the items to reference the local copies of the
+	// images without downloading the images.
+	DryRun bool
+
+	// Concurrency is the number of images that are downloaded at once. A
+	// single image is downloaded at a time if Concurrency is less than 1.
+	Concurrency int
+
+	// HostInterval is the minimum time between the starts of two downloads
+	// from the same host. The downloads are not limited if HostInterval is
+	// zero.
+	HostInterval time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+// DefaultImageConcurrency is the default number of images that are
+// downloaded at once.
+const DefaultImageConcurrency = 4
+
+// imageResult is the result of downloading an image.
+type imageResult struct {
+	size int64
+	err  error
+}
+
+// imageJob is an image that is downloaded to the file name, which is
+// relative to the image directory.
+type imageJob struct {
+	url  string
+	name string
+}
+
+// Mirror downloads the images that are attached to the items into the
+// image directory and rewrites the descriptions and media of the items to
+// reference the local copies. The images are downloaded by a pool of
+// Concurrency workers, and an image that is attached to multiple items is
+// only downloaded once, unless the items are in different page bundles.
+// Failed requests are retried by the Fetcher. Images
+// that have already been downloaded are not downloaded again, unless the
+// file is empty, or the image is a blob that is downloaded using
+// com.atproto.sync.getBlob and the checksum of the file does not match the
+// CID of the blob. The Bluesky CDN re-encodes the images, so the images that
+// are downloaded from the CDN are not checked against their CIDs. If an
+// image cannot be downloaded, the item keeps
+// referencing the original image, the remaining images are still
+// downloaded, and the items are returned with their errors.
+func (m *ImageMirror) Mirror(
+	ctx context.Context,
+	items []feed.Item,
+) []ItemError {
+	var pending []imageJob
+	results := map[string]*imageResult{}
+	for _, item := range items {
+		for _, media := range item.Media {
+			u := mirroredURL(&media)
+			if u == nil {
+				continue
+			}
+
+			name := m.fileName(item, *u)
+			if results[name] != nil {
+				continue
+			}
+
+			pending = append(pending, imageJob{url: *u, name: name})
+			results[name] = &imageResult{}
+		}
+	}
+
+	jobs := make(chan imageJob)
+	var wg sync.WaitGroup
+	for range min(max(m.Concurrency, 1), len(pending)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				result := results[job.name]
+				result.size, result.err = m.download(ctx, job.url, job.name)
+			}
+		}()
+	}
+
+	for _, job := range pending {
+		jobs <- job
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	var itemErrors []ItemError
+	for i := range items {
+		item := &items[i]
+		var errs []error
+		for j := range item.Media {
+			media := &item.Media[j]
+			u := mirroredURL(media)
+			if u == nil {
+				continue
+			}
+
+			name := m.fileName(*item, *u)
+			result := results[name]
+			if result.err != nil {
+				errs = append(errs, fmt.Errorf(
+					"failed to download %s: %w",
+					*u,
+					result.err,
+				))
+				continue
+			}
+
+			local := imageURL(m.BaseURL, name)
+			if m.Bundle != nil {
+				local = imageFileName(*u)
+			}
+
+			item.Description = strings.ReplaceAll(
+				item.Description,
+				html.EscapeString(*u),
+				html.EscapeString(local),
+			)
+			if media.Medium == "image" {
+				media.Size = result.size
+			}
+
+			*u = local
+		}
+
+		if len(errs) > 0 {
+			itemErrors = append(itemErrors, ItemError{
+				Item: *item,
+				Err:  errors.Join(errs...),
+			})
+		}
+	}
+
+	return itemErrors
+}
+
+// mirroredURL returns the field of the attachment that holds the URL of the
+// image that is mirrored, which is the URL of an image or the thumbnail of
+// a video, or nil if nothing is mirrored for the attachment.
+func mirroredURL(media *feed.Media) *string {
+	switch {
+	case media.Medium == "image" && media.URL != "":
+		return &media.URL
+	case media.Medium == "video" && media.Thumbnail != "":
+		return &media.Thumbnail
+	default:
+		return nil
+	}
+}
+
+// fileName returns the name of the local copy of the image at u that is
+// attached to item, relative to the image directory.
+func (m *ImageMirror) fileName(item feed.Item, u string) string {
+	if m.Bundle == nil {
+		return imageFileName(u)
+	}
+
+	return path.Join(m.Bundle(item), imageFileName(u))
+}
+
+// download downloads the image at u into the file name in the image
+// directory and returns the size of the file. In a dry run, only the size
+// of an image that has already been downloaded is returned.
+func (m *ImageMirror) download(
+	ctx context.Context,
+	u string,
+	name string,
+) (int64, error) {
+	target := filepath.Join(m.Dir, filepath.FromSlash(name))
+	if m.DryRun {
+		if info, err := os.Stat(target); err == nil {
+			return info.Size(), nil
+		}
+
+		return 0, nil
+	}
+
+	// An empty file was not downloaded completely, so it is downloaded
+	// again.
+	if info, err := os.Stat(target); err == nil && info.Size() == 0 {
+		if err = os.Remove(target); err != nil {
+			return 0, err
+		}
+	}
+
+	digest, hasDigest := cidDigest(blobCID(u))
+	if hasDigest {
+		// A file whose checksum does not match the CID is incomplete or
+		// corrupt, so it is removed and downloaded again.
+		matches, err := fileMatches(target, digest)
+		if err == nil && !matches {
+			if err = os.Remove(target); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if _, err := os.Stat(target); errors.Is(err, fs.ErrNotExist) {
+		if err = m.wait(ctx, u); err != nil {
+			return 0, err
+		}
+	}
+
+	size, err := m.Fetcher.Download(ctx, u, target)
+	if err != nil || !hasDigest {
+		return size, err
+	}
+
+	matches, err := fileMatches(target, digest)
+	if err != nil {
+		return 0, err
+	}
+
+	if !matches {
+		_ = os.Remove(target)
+		return 0, errors.New("the checksum of the image does not match its CID")
+	}
+
+	return size, nil
+}
+
+// wait blocks until a download from the host of u can start without
+// exceeding HostInterval.
+func (m *ImageMirror) wait(ctx context.Context, u string) error {
+	if m.HostInterval <= 0 {
+		return nil
+	}
+
+	host := u
+	if parsed, err := url.Parse(u); err == nil {
+		host = parsed.Host
+	}
+
+	m.mu.Lock()
+	if m.next == nil {
+		m.next = map[string]time.Time{}
+	}
+
+	now := time.Now()
+	start := m.next[host]
+	if start.Before(now) {
+		start = now
+	}
+
+	m.next[host] = start.Add(m.HostInterval)
+	m.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// blobCID returns the CID of the blob that is downloaded from u, or an
+// empty string if u is not a com.atproto.sync.getBlob URL. Only the bytes of
+// the blob that are returned by getBlob match the CID.
+func blobCID(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || path.Base(parsed.Path) != "com.atproto.sync.getBlob" {
+		return ""
+	}
+
+	return parsed.Query().Get("cid")
+}
+
+// cidDigest returns the SHA-256 digest that is contained in a CID. The
+// second result is false if cid is not a version 1 CID that uses a SHA-256
+// multihash, which is how the image blobs are addressed.
+func cidDigest(cid string) ([]byte, bool) {
+	if len(cid) < 2 || cid[0] != 'b' {
+		return nil, false
+	}
+
+	data, err := base32.StdEncoding.WithPadding(base32.NoPadding).
+		DecodeString(strings.ToUpper(cid[1:]))
+	if err != nil {
+		return nil, false
+	}
+
+	// A CID is the version, the codec of the content, and the multihash,
+	// which is the hash function, the length of the digest, and the
+	// digest. The codecs that are used for blobs fit into a single byte.
+	if len(data) != 4+sha256.Size || data[0] != 1 || data[2] != 0x12 ||
+		data[3] != sha256.Size {
+		return nil, false
+	}
+
+	return data[4:], true
+}
+
+// fileMatches reports whether the SHA-256 checksum of the file name is
+// digest.
+func fileMatches(name string, digest []byte) (bool, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, file); err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(h.Sum(nil), digest), nil
+}
+
+// imageFileName returns the name of the local copy of the image at u. The
+// Bluesky CDN URLs end with the CID of the image blob followed by @ and the
+// image format, which is converted into a file name with an extension.
+// Other URLs that end with a file name are prefixed with the name of the
+// parent directory, which is the CID of the video for video thumbnails, so
+// that files with the same name do not collide. Blobs that are downloaded
+// using com.atproto.sync.getBlob are named by their CIDs. A hash of the URL
+// is used for the remaining URLs.
+func imageFileName(u string) string {
+	if cid := blobCID(u); cid != "" && !strings.ContainsAny(cid, "/\\.") {
+		return cid
+	}
+
+	if parsed, err := url.Parse(u); err == nil {
+		base := path.Base(parsed.Path)
+		if cid, format, ok := strings.Cut(base, "@"); ok && cid != "" {
+			return cid + "." + format
+		}
+
+		if ext := path.Ext(base); ext != "" && len(base) > len(ext) {
+			dir := path.Base(path.Dir(parsed.Path))
+			if dir == "." || dir == "/" {
+				return base
+			}
+
+			return dir + "-" + base
+		}
+	}
+
+	sum := sha256.Sum256([]byte(u))
+	return hex.EncodeToString(sum[:16])
+}
+
+// imageURL returns the URL that the site uses to reference the local copy
+// of an image.
+func imageURL(baseURL string, name string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/" + name
+}
+
+// DefaultImageBaseURL derives the URL of the image directory from its path.
+// Files in the Hugo static directory are published at the root of the site,
+// so the static prefix is removed.
+func DefaultImageBaseURL(dir string) string {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	dir = strings.TrimPrefix(dir, "static/")
+	return "/" + strings.TrimPrefix(dir, "/")
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"html"
+	"strings"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// LabelPolicy determines how the posts that have one of the configured
+// labels are handled.
+type LabelPolicy string
+
+const (
+	// KeepLabeled keeps the posts. The labels of the posts are written to
+	// the output formats that support them, such as the front matter of
+	// the content pages.
+	KeepLabeled LabelPolicy = "keep"
+
+	// WarnLabeled keeps the posts and hides the descriptions of the posts
+	// behind a content warning.
+	WarnLabeled LabelPolicy = "warn"
+
+	// DropLabeled removes the posts.
+	DropLabeled LabelPolicy = "drop"
+)
+
+// DefaultLabels are the labels that the label policy applies to unless a
+// different set of labels is configured. These are the labels that
+// Bluesky uses for adult content and graphic media.
+var DefaultLabels = []string{"porn", "sexual", "nudity", "graphic-media"}
+
+// MatchingLabels returns the labels of item that are in the set. The set
+// is created using LabelSet.
+func MatchingLabels(item feed.Item, set map[string]bool) []string {
+	var matching []string
+	for _, label := range item.Labels {
+		if set[strings.ToLower(label)] {
+			matching = append(matching, label)
+		}
+	}
+
+	return matching
+}
+
+// LabelSet converts a list of label values into a set. The labels are
+// compared without regard to case.
+func LabelSet(labels []string) map[string]bool {
+	return TagSet(labels)
+}
+
+// WarnItem hides the description of item inside a details element whose
+// summary is a content warning that lists the labels. Readers have to
+// open the details element to see the post.
+func WarnItem(item *feed.Item, labels []string) {
+	if !item.IsHTML {
+		item.Text = item.Description
+		item.Description = html.EscapeString(item.Description)
+		item.IsHTML = true
+	}
+
+	item.Description = `<details class="bluesky-labels"><summary>` +
+		html.EscapeString(LabelWarning(labels)) + "</summary>" +
+		item.Description + "</details>"
+}
+
+// LabelWarning returns the content warning that is shown instead of a post
+// that has the labels.
+func LabelWarning(labels []string) string {
+	return "Content warning: " + strings.Join(labels, ", ")
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+const (
+	// DefaultLinkPreviewTimeout is the default time that the download of a
+	// linked page can take.
+	DefaultLinkPreviewTimeout = 5 * time.Second
+
+	// DefaultLinkPreviewTTL is the default time that the link card of a
+	// page is cached.
+	DefaultLinkPreviewTTL = 7 * 24 * time.Hour
+)
+
+// LinkPreviewer builds link cards for the posts that link to a web page in
+// their text without attaching a link card. Bluesky only shows a card when
+// the author attached one while writing the post, so posts that only
+// contain a bare link would otherwise only show the URL.
+type LinkPreviewer struct {
+	// Fetcher downloads the linked pages.
+	Fetcher *feed.Fetcher
+
+	// Cache stores the link cards so that a page is only downloaded again
+	// after TTL has elapsed. If Cache is nil, the link cards are only cached
+	// in memory.
+	Cache *feed.Cache
+
+	// Timeout is how long the download of a linked page can take. The
+	// downloads are not limited if Timeout is zero.
+	Timeout time.Duration
+
+	// TTL is how long the link card of a page is cached.
+	TTL time.Duration
+
+	mu    sync.Mutex
+	cards map[string]cachedLinkCard
+}
+
+// cachedLinkCard is a link card in the memory cache of a LinkPreviewer.
+type cachedLinkCard struct {
+	card    *feed.LinkCard
+	expires time.Time
+}
+
+// Preview adds a link card for the first link in the text of each post that
+// does not have an embed. The link card is stored in the LinkCard field of
+// the item and appended to the description of the item. Pages that do not
+// have a title do not get a link card. Items that do not have a post, such
+// as the items of an RSS feed, are not changed. If a page cannot be
+// downloaded, the item is not changed and is returned with the error. A
+// LinkPreviewer can be used by multiple goroutines.
+func (p *LinkPreviewer) Preview(
+	ctx context.Context,
+	items []feed.Item,
+) []ItemError {
+	var itemErrors []ItemError
+	for i := range items {
+		item := &items[i]
+		if item.Post == nil || item.LinkCard != nil ||
+			len(item.Post.Post.Embed) > 0 {
+			continue
+		}
+
+		record := item.Post.Post.Record
+		u := feed.FirstLink(record.Text, record.Facets)
+		if u == "" {
+			continue
+		}
+
+		card, err := p.linkCard(ctx, u)
+		if err != nil {
+			itemErrors = append(itemErrors, ItemError{
+				Item: *item,
+				Err:  fmt.Errorf("failed to download %s: %w", u, err),
+			})
+			continue
+		}
+
+		if card != nil {
+			item.LinkCard = card
+			item.Description += feed.RenderLinkCard(card)
+		}
+	}
+
+	return itemErrors
+}
+
+// linkCard returns the link card of the page at u from the cache, or
+// downloads the page and caches its link card.
+func (p *LinkPreviewer) linkCard(
+	ctx context.Context,
+	u string,
+) (*feed.LinkCard, error) {
+	now := time.Now()
+	p.mu.Lock()
+	cached, ok := p.cards[u]
+	p.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.card, nil
+	}
+
+	if p.Cache != nil {
+		if card, ok := p.Cache.LinkCard(u, now); ok {
+			return card, nil
+		}
+	}
+
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	card, err := p.Fetcher.FetchLinkCard(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	// The link card is only cached to avoid downloading the page again,
+	// so failing to write the cache does not prevent the card from being
+	// used.
+	expires := now.Add(p.TTL)
+	if p.Cache != nil {
+		_ = p.Cache.SetLinkCard(u, card, expires)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cards == nil {
+		p.cards = make(map[string]cachedLinkCard)
+	}
+
+	p.cards[u] = cachedLinkCard{card: card, expires: expires}
+	return card, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// Merge merges the items of the previous output into the new items and
+// removes the duplicate items. Two items are duplicates if they have the
+// same GUID, AT URI, or CID, or if their links are the same after they are
+// normalized using NormalizeURL, so a post is only kept once if it appears
+// in multiple feeds or its GUID policy has changed. The new version of an
+// item replaces the previous version, and if the same list contains
+// duplicates, the item with the newest date is kept. The merged items are
+// sorted from newest to oldest.
+func Merge(items []feed.Item, existing []feed.Item) []feed.Item {
+	// seen maps the keys of the merged items to their indexes in merged.
+	seen := make(map[string]int, len(items))
+	merged := make([]feed.Item, 0, len(items)+len(existing))
+	for _, items := range [][]feed.Item{items, existing} {
+		// first is the index of the first item in merged from this list.
+		first := len(merged)
+		for _, item := range items {
+			keys := mergeKeys(item)
+			i := slices.IndexFunc(keys, func(key string) bool {
+				_, ok := seen[key]
+				return ok
+			})
+			if i < 0 {
+				for _, key := range keys {
+					seen[key] = len(merged)
+				}
+
+				merged = append(merged, item)
+				continue
+			}
+
+			index := seen[keys[i]]
+			if index >= first && item.Published.After(merged[index].Published) {
+				merged[index] = item
+			}
+
+			// The keys of the duplicate refer to the item that was kept
+			// so that later duplicates of either item are detected.
+			for _, key := range keys {
+				if _, ok := seen[key]; !ok {
+					seen[key] = index
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(merged, func(a, b feed.Item) int {
+		return cmp.Compare(b.Published.UnixNano(), a.Published.UnixNano())
+	})
+	return merged
+}
+
+// mergeKeys returns the keys that identify the item when items are merged.
+// The keys are prefixed by their kind so that keys of different kinds are
+// never equal.
+func mergeKeys(item feed.Item) []string {
+	var keys []string
+	if item.GUID.Value != "" {
+		keys = append(keys, "guid:"+item.GUID.Value)
+	}
+
+	if uri := item.URI(); uri != "" {
+		keys = append(keys, "uri:"+uri)
+	}
+
+	if item.Post != nil && item.Post.Post.CID != "" {
+		keys = append(keys, "cid:"+item.Post.Post.CID)
+	}
+
+	if link := NormalizeURL(item.Link); link != "" {
+		keys = append(keys, "link:"+link)
+	}
+
+	return keys
+}
+
+// NormalizeURL normalizes a URL so that URLs that refer to the same page
+// compare equal. The scheme, the www. prefix of the host, the fragment,
+// the trailing slash of the path, and the utm_* tracking parameters are
+// removed, the host is lowercased, and the query parameters are sorted. An
+// empty string is returned if rawURL is not an absolute URL.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	query := u.Query()
+	for name := range query {
+		if strings.HasPrefix(strings.ToLower(name), "utm_") {
+			query.Del(name)
+		}
+	}
+
+	normalized := host + strings.TrimSuffix(u.EscapedPath(), "/")
+	if len(query) > 0 {
+		normalized += "?" + query.Encode()
+	}
+
+	return normalized
+}
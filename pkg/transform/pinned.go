@@ -0,0 +1,78 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"slices"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// PinnedPolicy determines how the post that an account pinned to its
+// profile is handled.
+type PinnedPolicy string
+
+const (
+	// IgnorePinned writes the pinned post like any other post.
+	IgnorePinned PinnedPolicy = "ignore"
+
+	// FlagPinned marks the pinned post as pinned in the output formats that
+	// support it, such as the front matter of the content pages.
+	FlagPinned PinnedPolicy = "flag"
+
+	// FirstPinned marks the pinned post as pinned and moves it before the
+	// other posts regardless of its date.
+	FirstPinned PinnedPolicy = "first"
+)
+
+// Apply returns a copy of the items with the policy applied. pinned contains
+// the AT URIs of the pinned posts of the accounts. The items for the pinned
+// posts are marked as pinned unless the policy is IgnorePinned, which
+// clears the mark of every item instead, and the pinned items are moved
+// before the other items if the policy is FirstPinned. The order of the
+// other items is kept.
+func (p PinnedPolicy) Apply(
+	items []feed.Item,
+	pinned map[string]bool,
+) []feed.Item {
+	items = slices.Clone(items)
+	for i := range items {
+		item := &items[i]
+		uri := item.URI()
+		item.Pinned = p != IgnorePinned && uri != "" && pinned[uri] &&
+			!item.IsRepost()
+	}
+
+	if p == FirstPinned {
+		slices.SortStableFunc(items, func(a, b feed.Item) int {
+			switch {
+			case a.Pinned == b.Pinned:
+				return 0
+			case a.Pinned:
+				return -1
+			default:
+				return 1
+			}
+		})
+	}
+
+	return items
+}
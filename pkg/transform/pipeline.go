@@ -0,0 +1,133 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"errors"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// ErrDrop is returned by a Transformer to remove an item from the feed.
+// Dropping an item is not a failure, so the item is not handled using the
+// error policy of the pipeline.
+var ErrDrop = errors.New("the item was removed from the feed")
+
+// Transformer is a step of a Pipeline that transforms a single item, such
+// as rewriting its date, sanitizing its description, or removing it using
+// a filter. Transform returns the transformed item, or ErrDrop to remove
+// the item from the feed.
+type Transformer interface {
+	Transform(item feed.Item) (feed.Item, error)
+}
+
+// TransformerFunc is a function that is used as a Transformer.
+type TransformerFunc func(item feed.Item) (feed.Item, error)
+
+func (f TransformerFunc) Transform(item feed.Item) (feed.Item, error) {
+	return f(item)
+}
+
+// Pipeline is a chain of transformers that every item of a feed is passed
+// through in order. The order matters: a filter that removes items using
+// their dates needs to run after the dates have been parsed, and a
+// transformer that limits the number of items only counts the items that
+// were not removed by the transformers before it.
+type Pipeline []Transformer
+
+// Run passes each item through the transformers of the pipeline. An item
+// that is dropped by a transformer is removed from the feed and is not
+// passed to the remaining transformers. An item that a transformer fails
+// to transform is handled using policy: a skipped item is removed, and a
+// passed through item keeps the values that it had before the failed
+// transformer and continues through the pipeline. The items that were
+// skipped or passed through are returned with their errors.
+func (p Pipeline) Run(
+	items []feed.Item,
+	policy ErrorPolicy,
+) ([]feed.Item, []ItemError, error) {
+	var itemErrors []ItemError
+	result := items[:0]
+
+items:
+	for _, item := range items {
+		for _, t := range p {
+			next, err := t.Transform(item)
+			switch {
+			case err == nil:
+				item = next
+			case errors.Is(err, ErrDrop):
+				continue items
+			case policy == SkipItem:
+				itemErrors = append(itemErrors, ItemError{item, err})
+				continue items
+			case policy == Passthrough:
+				itemErrors = append(itemErrors, ItemError{item, err})
+			default:
+				return nil, nil, err
+			}
+		}
+
+		result = append(result, item)
+	}
+
+	return result, itemErrors, nil
+}
+
+// DateTransformer returns a transformer that parses and rewrites the
+// pubDate field of an item like Dates.
+func DateTransformer(
+	extra []string,
+	format string,
+	loc *time.Location,
+) Transformer {
+	return TransformerFunc(func(item feed.Item) (feed.Item, error) {
+		err := rewriteDate(&item, extra, format, loc)
+		return item, err
+	})
+}
+
+// LimitTransformer returns a transformer that keeps the first n items that
+// reach it and drops the rest. Every item is kept if n is zero. The
+// transformer counts the items that it has kept, so a new transformer needs
+// to be created for each run of a pipeline.
+func LimitTransformer(n int) Transformer {
+	kept := 0
+	return TransformerFunc(func(item feed.Item) (feed.Item, error) {
+		if n > 0 && kept >= n {
+			return item, ErrDrop
+		}
+
+		kept++
+		return item, nil
+	})
+}
+
+// Transform drops the items that are excluded by the filter so that the
+// filter can be used as a step of a Pipeline.
+func (f Filter) Transform(item feed.Item) (feed.Item, error) {
+	if f.Exclude(item) {
+		return item, ErrDrop
+	}
+
+	return item, nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+	xhtml "golang.org/x/net/html"
+)
+
+// DefaultAllowedTags are the HTML elements that are kept in descriptions by
+// the sanitizer unless a different set of elements is configured.
+var DefaultAllowedTags = []string{
+	"a", "b", "blockquote", "br", "code", "details", "em", "i", "img", "p",
+	"pre", "source", "strong", "summary", "video",
+}
+
+// allowedAttributes are the attributes that are kept on allowed elements.
+// All other attributes are removed.
+var allowedAttributes = map[string][]string{
+	"a":          {"href", "title"},
+	"blockquote": {"cite", "class"},
+	"details":    {"class"},
+	"img":        {"src", "alt", "title", "width", "height"},
+	"p":          {"class"},
+	"source":     {"src", "type"},
+	"video": {
+		"controls", "playsinline", "preload", "poster", "width", "height",
+	},
+}
+
+// urlAttributes are the attributes that contain URLs. The values of these
+// attributes are only kept if they use a safe scheme.
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"cite":   true,
+	"poster": true,
+}
+
+// droppedElements are elements whose content is removed along with the
+// element itself instead of being kept as text.
+var droppedElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"noscript": true,
+	"object":   true,
+}
+
+// SanitizeHTML removes all elements that are not in allowed from the HTML
+// fragment s and normalizes the character references in the text so that
+// every special character is escaped exactly once. Characters that are not
+// allowed in XML documents are removed.
+func SanitizeHTML(s string, allowed map[string]bool) string {
+	var b strings.Builder
+	tokenizer := xhtml.NewTokenizer(strings.NewReader(s))
+	dropping := ""
+	for {
+		tt := tokenizer.Next()
+		if tt == xhtml.ErrorToken {
+			// Reading from a string can only fail at the end of the input.
+			break
+		}
+
+		token := tokenizer.Token()
+		if dropping != "" {
+			if tt == xhtml.EndTagToken && token.Data == dropping {
+				dropping = ""
+			}
+
+			continue
+		}
+
+		switch tt {
+		case xhtml.TextToken:
+			b.WriteString(html.EscapeString(validXMLText(token.Data)))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if droppedElements[token.Data] && tt == xhtml.StartTagToken {
+				dropping = token.Data
+				continue
+			}
+
+			if allowed[token.Data] {
+				token.Attr = sanitizeAttributes(token.Data, token.Attr)
+				b.WriteString(token.String())
+			}
+		case xhtml.EndTagToken:
+			if allowed[token.Data] {
+				b.WriteString(token.String())
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func sanitizeAttributes(tag string, attrs []xhtml.Attribute) []xhtml.Attribute {
+	var result []xhtml.Attribute
+	for _, attr := range attrs {
+		if attr.Namespace != "" {
+			continue
+		}
+
+		allowed := false
+		for _, name := range allowedAttributes[tag] {
+			if attr.Key == name {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed || (urlAttributes[attr.Key] && !feed.IsSafeURL(attr.Val)) {
+			continue
+		}
+
+		result = append(result, attr)
+	}
+
+	return result
+}
+
+// validXMLText removes characters that are not allowed in XML documents,
+// such as most control characters and invalid UTF-8 sequences.
+func validXMLText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError:
+			return -1
+		case r == '\t' || r == '\n' || r == '\r':
+			return r
+		case r < 0x20, r >= 0xFFFE && r <= 0xFFFF:
+			return -1
+		case r >= 0xD800 && r <= 0xDFFF:
+			return -1
+		}
+
+		return r
+	}, s)
+}
+
+// SanitizeItem sanitizes the description of item. The description is treated
+// as an HTML fragment, and the text of the description without any markup
+// is kept for the formats that need plain text.
+func SanitizeItem(item *feed.Item, allowed map[string]bool) {
+	if !item.IsHTML {
+		item.Text = HTMLText(item.Description)
+	}
+
+	item.Description = SanitizeHTML(item.Description, allowed)
+	item.IsHTML = true
+}
+
+// HTMLText returns the text of the HTML fragment s without any markup.
+func HTMLText(s string) string {
+	return html.UnescapeString(SanitizeHTML(s, nil))
+}
+
+// TagSet converts a list of tag names into a set for SanitizeHTML.
+func TagSet(tags []string) map[string]bool {
+	set := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			set[tag] = true
+		}
+	}
+
+	return set
+}
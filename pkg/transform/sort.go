@@ -0,0 +1,71 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// SortOrder determines the order that the items are written in.
+type SortOrder string
+
+const (
+	// NewestFirst sorts the items from newest to oldest, which is the order
+	// that RSS readers expect.
+	NewestFirst SortOrder = "newest"
+
+	// OldestFirst sorts the items from oldest to newest, which is the order
+	// that some Hugo templates expect.
+	OldestFirst SortOrder = "oldest"
+
+	// AsFetched keeps the order that the items were fetched in. Bluesky
+	// orders the author feed by the time that the posts were added to it,
+	// so a repost is ordered by the time that it was reposted. Merged items
+	// are ordered from newest to oldest.
+	AsFetched SortOrder = "as-fetched"
+)
+
+// Sort returns a copy of the items sorted by their published dates in the
+// order o. Items that were published at the same time are ordered by their
+// GUIDs, so the order does not depend on the order that the items were
+// fetched in. The items are returned in the same order if o is AsFetched.
+func (o SortOrder) Sort(items []feed.Item) []feed.Item {
+	if o == AsFetched {
+		return items
+	}
+
+	items = slices.Clone(items)
+	slices.SortStableFunc(items, func(a, b feed.Item) int {
+		c := cmp.Or(
+			a.Published.Compare(b.Published),
+			cmp.Compare(a.GUID.Value, b.GUID.Value),
+		)
+		if o == NewestFirst {
+			return -c
+		}
+
+		return c
+	})
+	return items
+}
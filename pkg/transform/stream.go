@@ -0,0 +1,255 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// StreamDates copies the RSS feed that is read from r to w and rewrites the
+// pubDate elements of the items like Dates. The feed is transformed one
+// token at a time, so only the item that is being rewritten is kept in
+// memory, and every element other than the pubDate elements of the items,
+// including the elements that are unknown to this package, is copied
+// without changes. Items whose pubDate element cannot be parsed are
+// handled using policy. The items that were written are returned with
+// only their links and dates set, along with the items that were skipped
+// or passed through.
+func StreamDates(
+	w io.Writer,
+	r io.Reader,
+	extra []string,
+	format string,
+	loc *time.Location,
+	policy ErrorPolicy,
+) ([]feed.Item, []ItemError, error) {
+	s := dateStream{
+		decoder: xml.NewDecoder(r),
+		encoder: xml.NewEncoder(w),
+		extra:   extra,
+		format:  format,
+		loc:     loc,
+		policy:  policy,
+	}
+	if err := s.run(); err != nil {
+		return nil, nil, err
+	}
+
+	return s.items, s.itemErrors, nil
+}
+
+// dateStream is the state of StreamDates.
+type dateStream struct {
+	decoder *xml.Decoder
+	encoder *xml.Encoder
+	extra   []string
+	format  string
+	loc     *time.Location
+	policy  ErrorPolicy
+
+	items      []feed.Item
+	itemErrors []ItemError
+
+	// The tokens of the item that is being read are buffered so that the
+	// item can be skipped if its pubDate element cannot be parsed. depth
+	// is the number of elements of the item that are open, and text
+	// collects the text of the current child element of the item.
+	inItem bool
+	tokens []xml.Token
+	depth  int
+	field  string
+	text   strings.Builder
+	item   feed.Item
+	err    error
+}
+
+// run copies the tokens of the feed.
+func (s *dateStream) run() error {
+	for {
+		// RawToken is used instead of Token because Token replaces the
+		// prefixes of the names with their namespace URLs, which the
+		// encoder cannot turn back into the original prefixes.
+		token, err := s.decoder.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var syntaxErr *xml.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return &feed.ParseError{
+				Err: fmt.Errorf("failed to parse the RSS feed: %w", err),
+			}
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read the RSS feed: %w", err)
+		}
+
+		if err = s.copyToken(prefixedNames(token)); err != nil {
+			return err
+		}
+	}
+
+	return s.encoder.Close()
+}
+
+// copyToken writes token to the output, or buffers token if it belongs to
+// an item.
+func (s *dateStream) copyToken(token xml.Token) error {
+	switch t := token.(type) {
+	case xml.StartElement:
+		if !s.inItem && t.Name.Local == "item" {
+			s.inItem = true
+			s.tokens = s.tokens[:0]
+			s.depth = 0
+			s.item = feed.Item{}
+			s.err = nil
+			break
+		}
+
+		if s.inItem {
+			s.depth++
+			if s.depth == 1 {
+				s.field = t.Name.Local
+				s.text.Reset()
+			}
+		}
+	case xml.CharData:
+		if s.inItem && s.depth == 1 {
+			s.text.Write(t)
+			if s.field == "pubDate" {
+				return nil
+			}
+		}
+	case xml.EndElement:
+		if !s.inItem {
+			break
+		}
+
+		if s.depth == 0 {
+			s.tokens = append(s.tokens, t)
+			s.inItem = false
+			return s.endItem()
+		}
+
+		if s.depth == 1 {
+			switch s.field {
+			case "link":
+				s.item.Link = strings.TrimSpace(s.text.String())
+			case "pubDate":
+				s.tokens = append(s.tokens, xml.CharData(s.pubDate()))
+			}
+		}
+
+		s.depth--
+	}
+
+	if s.inItem {
+		s.tokens = append(s.tokens, xml.CopyToken(token))
+		return nil
+	}
+
+	return s.encoder.EncodeToken(token)
+}
+
+// pubDate rewrites the text of the pubDate element of the current item.
+// The original text is returned if the date cannot be parsed.
+func (s *dateStream) pubDate() string {
+	s.item.PubDate = s.text.String()
+	published, err := ParsePubDate(strings.TrimSpace(s.item.PubDate), s.extra)
+	if err != nil {
+		s.err = fmt.Errorf("failed to parse the pubDate field: %w", err)
+		return s.item.PubDate
+	}
+
+	if s.loc != nil {
+		published = published.In(s.loc)
+	}
+
+	s.item.Published = published
+	s.item.PubDate = FormatPubDate(published, s.format)
+	return s.item.PubDate
+}
+
+// endItem writes the buffered tokens of the current item unless the item
+// is skipped because of the error policy.
+func (s *dateStream) endItem() error {
+	if s.err != nil {
+		itemErr := ItemError{Item: s.item, Err: s.err}
+		switch s.policy {
+		case SkipItem:
+			s.itemErrors = append(s.itemErrors, itemErr)
+			return nil
+		case Passthrough:
+			s.itemErrors = append(s.itemErrors, itemErr)
+		default:
+			return &feed.ParseError{Err: s.err}
+		}
+	}
+
+	for _, token := range s.tokens {
+		if err := s.encoder.EncodeToken(token); err != nil {
+			return err
+		}
+	}
+
+	s.items = append(s.items, s.item)
+	return nil
+}
+
+// prefixedNames returns token with the prefixes of the names of elements
+// and attributes moved into the local names. The encoder treats the space
+// of a name as a namespace URL, so the names would otherwise be written
+// with generated namespace declarations instead of their prefixes.
+func prefixedNames(token xml.Token) xml.Token {
+	switch t := token.(type) {
+	case xml.StartElement:
+		t.Name = prefixedName(t.Name)
+		attrs := make([]xml.Attr, len(t.Attr))
+		for i, attr := range t.Attr {
+			attrs[i] = xml.Attr{Name: prefixedName(attr.Name), Value: attr.Value}
+		}
+
+		t.Attr = attrs
+		return t
+	case xml.EndElement:
+		t.Name = prefixedName(t.Name)
+		return t
+	default:
+		return token
+	}
+}
+
+// prefixedName moves the prefix of name into its local name.
+func prefixedName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SummaryUnit is the unit of the length of the summaries of the posts.
+type SummaryUnit string
+
+const (
+	// SummaryCharacters limits the number of characters of a summary.
+	SummaryCharacters SummaryUnit = "characters"
+
+	// SummaryWords limits the number of words of a summary.
+	SummaryWords SummaryUnit = "words"
+)
+
+// Summarize returns a summary of text that is at most length characters or
+// words long, depending on unit. The whitespace of text is collapsed into
+// single spaces so that the summary can be shown on a single line. Text
+// that is longer than length is shortened at a word boundary and an
+// ellipsis is added, which is not counted in the length.
+func Summarize(text string, length int, unit SummaryUnit) string {
+	words := strings.Fields(text)
+	if unit == SummaryWords {
+		if len(words) <= length {
+			return strings.Join(words, " ")
+		}
+
+		return strings.Join(words[:length], " ") + "…"
+	}
+
+	summary := strings.Join(words, " ")
+	if GraphemeCount(summary) <= length {
+		return summary
+	}
+
+	// A word that does not fit is removed unless it is the only word, in
+	// which case the word is cut.
+	cut := Truncate(summary, length)
+	if !strings.HasPrefix(summary[len(cut):], " ") {
+		if i := strings.LastIndex(cut, " "); i > 0 {
+			cut = cut[:i]
+		}
+	}
+
+	return strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	}) + "…"
+}
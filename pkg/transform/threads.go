@@ -0,0 +1,192 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// threadMarker is the emoji that authors use to mark the first post of a
+// thread.
+const threadMarker = "\U0001F9F5"
+
+// ThreadExpander combines the posts of self-reply threads into a single
+// item. Threads are published on Bluesky as a chain of replies by the
+// author to their own posts, which would otherwise appear in the feed as
+// separate posts that are out of context.
+type ThreadExpander struct {
+	// Fetcher downloads the threads from the app.bsky.feed.getPostThread
+	// endpoint.
+	Fetcher *feed.Fetcher
+
+	// Cache stores the threads that were downloaded so that a thread is
+	// only downloaded again when the author continues the thread. If Cache
+	// is nil, every thread is downloaded.
+	Cache *feed.Cache
+}
+
+// Expand replaces each item that is the root of a self-reply thread with an
+// item that contains all of the posts of the thread and removes the items
+// for the other posts of the thread. An item is the root of a thread if
+// another item is a reply by the same author in the thread, or if the text
+// of the item contains the 🧵 emoji. Items that do not have a post, such as
+// the items of an RSS feed, are not changed. If a thread cannot be
+// downloaded, the posts of the thread are kept as separate items and the
+// error is returned with the items.
+func (t *ThreadExpander) Expand(
+	ctx context.Context,
+	items []feed.Item,
+) ([]feed.Item, error) {
+	// roots contains the URIs of the self-replies in the items, keyed by
+	// the URI of the root post of their thread.
+	roots := make(map[string][]string)
+	for _, item := range items {
+		if item.Post == nil || item.IsRepost() {
+			continue
+		}
+
+		post := item.Post.Post
+		if ref, ok := post.Record.ReplyRef(); ok {
+			if strings.HasPrefix(ref.Root.URI, "at://"+post.Author.DID+"/") {
+				roots[ref.Root.URI] = append(roots[ref.Root.URI], post.URI)
+			}
+		} else if strings.Contains(post.Record.Text, threadMarker) {
+			if _, ok := roots[post.URI]; !ok {
+				roots[post.URI] = nil
+			}
+		}
+	}
+
+	var errs []error
+	threaded := make(map[string]bool)
+	result := make([]feed.Item, 0, len(items))
+	for _, item := range items {
+		if item.Post == nil || item.IsRepost() || item.IsReply() {
+			result = append(result, item)
+			continue
+		}
+
+		uri := item.Post.Post.URI
+		replies, ok := roots[uri]
+		if !ok {
+			result = append(result, item)
+			continue
+		}
+
+		posts, err := t.selfThread(ctx, item.Post.Post, replies)
+		if err == nil {
+			var combined feed.Item
+			if len(posts) > 1 {
+				combined, err = threadItem(item, posts[1:])
+			}
+
+			if err == nil && len(posts) > 1 {
+				item = combined
+				for _, post := range posts[1:] {
+					threaded[post.URI] = true
+				}
+			}
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("the thread %s: %w", uri, err))
+		}
+
+		result = append(result, item)
+	}
+
+	result = slices.DeleteFunc(result, func(item feed.Item) bool {
+		return item.Post != nil && !item.IsRepost() &&
+			threaded[item.Post.Post.URI]
+	})
+	return result, errors.Join(errs...)
+}
+
+// selfThread returns the posts of the self-reply thread that starts at
+// root. The cached thread is used if it contains all of the replies, which
+// are the URIs of the self-replies in the feed, because the author
+// continuing the thread adds a new reply to the feed. Otherwise, the thread
+// is downloaded and cached.
+func (t *ThreadExpander) selfThread(
+	ctx context.Context,
+	root feed.PostView,
+	replies []string,
+) ([]feed.PostView, error) {
+	if t.Cache != nil {
+		cached, err := t.Cache.Thread(root.CID)
+		if err == nil && len(cached) > 0 && containsPosts(cached, replies) {
+			return cached, nil
+		}
+	}
+
+	thread, err := t.Fetcher.FetchThread(ctx, root.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	// A thread that cannot be cached is downloaded again by the next run,
+	// so the error does not prevent the thread from being combined.
+	posts := feed.SelfThread(thread)
+	if t.Cache != nil {
+		_ = t.Cache.SetThread(root.CID, posts)
+	}
+
+	return posts, nil
+}
+
+// containsPosts reports whether every URI in uris is the URI of one of
+// posts.
+func containsPosts(posts []feed.PostView, uris []string) bool {
+	for _, uri := range uris {
+		if !slices.ContainsFunc(posts, func(p feed.PostView) bool {
+			return p.URI == uri
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// threadItem appends the text and the attachments of posts, which are the
+// replies that continue the thread, to the item for the root of the thread.
+func threadItem(root feed.Item, posts []feed.PostView) (feed.Item, error) {
+	root.Media = slices.Clone(root.Media)
+	for i := range posts {
+		part, err := feed.NewPostItem(&feed.FeedViewPost{Post: posts[i]})
+		if err != nil {
+			return feed.Item{}, err
+		}
+
+		root.Description += "<br>\n<br>\n" + part.Description
+		root.Text += "\n\n" + part.Text
+		root.Markdown += "\n\n" + part.Markdown
+		root.Media = append(root.Media, part.Media...)
+	}
+
+	return root, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+// Package transform implements the rewriting of the items of a Bluesky feed
+// into a form that Hugo can use.
+//
+// The pubDate fields of the items are parsed and rewritten using a format
+// that Hugo can parse, the descriptions can be sanitized, items can be
+// filtered out using their hashtags and text, the images that are attached
+// to posts can be mirrored into the Hugo site, and new items can be merged
+// into previously generated output. The pubDate fields can also be rewritten
+// while a very large feed is streamed, without reading the feed into memory.
+package transform
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// ErrorPolicy determines how an item that cannot be transformed is handled.
+type ErrorPolicy string
+
+const (
+	// Fail stops the transformation of the feed.
+	Fail ErrorPolicy = "fail"
+
+	// SkipItem removes the item from the feed.
+	SkipItem ErrorPolicy = "skip-item"
+
+	// Passthrough keeps the item in the feed without transforming it.
+	Passthrough ErrorPolicy = "passthrough"
+)
+
+// ItemError describes an item that could not be transformed and that was
+// skipped or passed through because of the error policy.
+type ItemError struct {
+	Item feed.Item
+	Err  error
+}
+
+func (e ItemError) Error() string {
+	return e.Item.Link + ": " + e.Err.Error()
+}
+
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// Dates parses the pubDate field of every item using the layouts in extra
+// and the built-in layouts and rewrites the field using format. If loc is
+// not nil, the dates are converted to loc before they are rewritten.
+// Otherwise, the dates keep the offsets of the original feed. Items whose
+// pubDate field cannot be parsed are handled using policy, and the items
+// that were skipped or passed through are returned with their errors.
+func Dates(
+	items []feed.Item,
+	extra []string,
+	format string,
+	loc *time.Location,
+	policy ErrorPolicy,
+) ([]feed.Item, []ItemError, error) {
+	return apply(items, policy, func(item *feed.Item) error {
+		return rewriteDate(item, extra, format, loc)
+	})
+}
+
+// rewriteDate parses the pubDate field of item and rewrites the field like
+// Dates.
+func rewriteDate(
+	item *feed.Item,
+	extra []string,
+	format string,
+	loc *time.Location,
+) error {
+	published, err := ParsePubDate(item.PubDate, extra)
+	if err != nil {
+		return fmt.Errorf("failed to parse the pubDate field: %w", err)
+	}
+
+	if loc != nil {
+		published = published.In(loc)
+	}
+
+	item.Published = published
+	item.PubDate = FormatPubDate(published, format)
+	return nil
+}
+
+// Reformat parses the dates of items that were read from output that was
+// previously written using format and rewrites the dates using format. The
+// layouts in extra are also accepted. If loc is not nil, dates that were
+// written without a time zone are parsed as dates in loc, and the dates are
+// converted to loc like Dates. Items whose dates cannot be parsed are
+// handled using policy in the same way as Dates.
+func Reformat(
+	items []feed.Item,
+	format string,
+	extra []string,
+	loc *time.Location,
+	policy ErrorPolicy,
+) ([]feed.Item, []ItemError, error) {
+	return apply(items, policy, func(item *feed.Item) error {
+		parseLoc := loc
+		if parseLoc == nil {
+			parseLoc = time.UTC
+		}
+
+		published, err := parseFormattedDate(
+			item.PubDate,
+			format,
+			extra,
+			parseLoc,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to parse the date: %w", err)
+		}
+
+		if loc != nil {
+			published = published.In(loc)
+		}
+
+		item.Published = published
+		item.PubDate = FormatPubDate(published, format)
+		return nil
+	})
+}
+
+// apply calls fn for every item. If fn fails for an item, the item is
+// handled using policy. Passed through items keep the values that they had
+// before fn was called.
+func apply(
+	items []feed.Item,
+	policy ErrorPolicy,
+	fn func(item *feed.Item) error,
+) ([]feed.Item, []ItemError, error) {
+	var itemErrors []ItemError
+	result := items[:0]
+	for _, original := range items {
+		item := original
+		if err := fn(&item); err != nil {
+			switch policy {
+			case SkipItem:
+				itemErrors = append(itemErrors, ItemError{original, err})
+				continue
+			case Passthrough:
+				itemErrors = append(itemErrors, ItemError{original, err})
+				item = original
+			default:
+				return nil, nil, err
+			}
+		}
+
+		result = append(result, item)
+	}
+
+	return result, itemErrors, nil
+}
+
+// Limit returns the first n items. All of the items are returned if n is
+// zero.
+func Limit(items []feed.Item, n int) []feed.Item {
+	if n > 0 && len(items) > n {
+		return items[:n]
+	}
+
+	return items
+}
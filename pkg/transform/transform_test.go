@@ -0,0 +1,287 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+package transform
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/internal/feedtest"
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// benchmarkItems is the number of items in the feeds of the benchmarks.
+const benchmarkItems = 10_000
+
+// BenchmarkTransform measures the transforms that a run applies to the
+// items of a feed by default.
+func BenchmarkTransform(b *testing.B) {
+	items := feedtest.Feed(benchmarkItems).Channel.Items
+	allowed := TagSet(DefaultAllowedTags)
+	filter := Filter{ExcludeTags: HashtagSet([]string{"nsfw"})}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		result, _, err := Dates(
+			slices.Clone(items),
+			nil,
+			DefaultDateFormat,
+			nil,
+			Fail,
+		)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for i := range result {
+			SanitizeItem(&result[i], allowed)
+		}
+
+		result = slices.DeleteFunc(result, filter.Exclude)
+		if len(result) != benchmarkItems {
+			b.Fatalf("got %d items", len(result))
+		}
+	}
+}
+
+func BenchmarkStreamDates(b *testing.B) {
+	var buf bytes.Buffer
+	err := xml.NewEncoder(&buf).Encode(feedtest.Feed(benchmarkItems))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.SetBytes(int64(buf.Len()))
+	b.ReportAllocs()
+	for b.Loop() {
+		items, _, err := StreamDates(
+			io.Discard,
+			bytes.NewReader(buf.Bytes()),
+			nil,
+			DefaultDateFormat,
+			nil,
+			Fail,
+		)
+		if err != nil || len(items) != benchmarkItems {
+			b.Fatalf("got %d items: %v", len(items), err)
+		}
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	items, _, err := Dates(
+		feedtest.Feed(benchmarkItems).Channel.Items,
+		nil,
+		DefaultDateFormat,
+		nil,
+		Fail,
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Half of the fetched items are already in the existing output.
+	existing := slices.Clone(items[benchmarkItems/2:])
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if merged := Merge(items, existing); len(merged) != benchmarkItems {
+			b.Fatalf("got %d items", len(merged))
+		}
+	}
+}
+
+// blobCIDOf returns the CID of a blob with the content data.
+func blobCIDOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	raw := append([]byte{1, 0x55, 0x12, sha256.Size}, sum[:]...)
+	return "b" + strings.ToLower(
+		base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw),
+	)
+}
+
+func TestImageMirrorCDN(t *testing.T) {
+	// The CDN re-encodes the images, so the bytes that it serves do not
+	// match the CID in the URL.
+	cid := blobCIDOf([]byte("original image"))
+	image := []byte("re-encoded image")
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write(image)
+		},
+	))
+	defer server.Close()
+
+	u := server.URL + "/img/feed_fullsize/plain/did:plc:alice/" + cid + "@jpeg"
+	dir := t.TempDir()
+	mirror := ImageMirror{
+		Fetcher: &feed.Fetcher{Client: server.Client()},
+		Dir:     dir,
+		BaseURL: "/images",
+	}
+
+	for run := range 2 {
+		items := []feed.Item{{
+			Description: `<img src="` + u + `">`,
+			Media:       []feed.Media{{Medium: "image", URL: u}},
+		}}
+		if errs := mirror.Mirror(context.Background(), items); len(errs) > 0 {
+			t.Fatalf("run %d: %v", run, errs[0].Err)
+		}
+
+		local := "/images/" + cid + ".jpeg"
+		if got := items[0].Media[0].URL; got != local {
+			t.Errorf("run %d: URL = %q, want %q", run, got, local)
+		}
+
+		if got := items[0].Media[0].Size; got != int64(len(image)) {
+			t.Errorf("run %d: Size = %d, want %d", run, got, len(image))
+		}
+
+		if !strings.Contains(items[0].Description, local) {
+			t.Errorf("run %d: Description = %q", run, items[0].Description)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, cid+".jpeg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, image) {
+		t.Errorf("file = %q, want %q", data, image)
+	}
+
+	// The image already exists when the items are mirrored again.
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestImageMirrorBlobChecksum(t *testing.T) {
+	cid := blobCIDOf([]byte("original image"))
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("corrupt image"))
+		},
+	))
+	defer server.Close()
+
+	u := server.URL + "/xrpc/com.atproto.sync.getBlob?did=did:plc:alice&cid=" +
+		cid
+	dir := t.TempDir()
+	mirror := ImageMirror{
+		Fetcher: &feed.Fetcher{Client: server.Client()},
+		Dir:     dir,
+		BaseURL: "/images",
+	}
+
+	items := []feed.Item{{Media: []feed.Media{{Medium: "image", URL: u}}}}
+	if errs := mirror.Mirror(context.Background(), items); len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1", len(errs))
+	}
+
+	if got := items[0].Media[0].URL; got != u {
+		t.Errorf("URL = %q, want %q", got, u)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, cid)); !os.IsNotExist(err) {
+		t.Errorf("the corrupt blob was kept: %v", err)
+	}
+}
+
+func TestParsePubDate(t *testing.T) {
+	want := time.Date(2025, 3, 9, 10, 0, 0, 0, time.UTC)
+	tests := []string{
+		"09 Mar 2025 10:00 +0000",
+		"9 Mar 2025 10:00 +0000",
+		"09 Mar 2025 10:00:00 +0000",
+		"9 Mar 2025 10:00:00 +0000",
+		"Sun, 09 Mar 2025 10:00 +0000",
+		"Sun, 9 Mar 2025 10:00 +0000",
+		"Sun, 09 Mar 2025 10:00:00 +0000",
+		"Sun, 9 Mar 2025 10:00:00 +0000",
+		"Sun, 09 Mar 2025 10:00:00 UTC",
+		"Sun, 9 Mar 2025 10:00:00 UTC",
+		"09 Mar 25 10:00 +0000",
+		"2025-03-09T10:00:00Z",
+		"2025-03-09T10:00:00.000Z",
+		"2025-03-09T10:00:00+0000",
+		"2025-03-09 10:00:00",
+	}
+
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			got, err := ParsePubDate(value, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !got.Equal(want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTextShortcodes(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"emoji", "Pizza 🍕 night", "Pizza :pizza: night"},
+		{"presentation selector", "I ❤️ Hugo", "I :heart: Hugo"},
+		{"skin tone", "Nice 👍🏽 work", "Nice :+1: work"},
+		{"skin tones", "Thanks 👍🏻👍🏿", "Thanks :+1::+1:"},
+		{"zero width joiner", "Flag 🏳️‍🌈 up", "Flag :rainbow_flag: up"},
+		{"zero width joiner tail", "On ❤️‍🔥 fire", "On :heart: fire"},
+		{"zero width joiner without shortcode", "Dev 👩🏽‍💻 work", "Dev work"},
+		{"flag", "Made in 🇺🇸", "Made in :us:"},
+		{"flag without shortcode", "Hello 🇦🇶 there", "Hello there"},
+		{"no shortcode", "Hello 🫨 there", "Hello there"},
+		{"text", "No emoji here", "No emoji here"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeText(tt.text, false, ShortcodeEmoji)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,517 @@
+// Copyright 2025 Michael F. Collins, III
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to
+// deal in the Software without restriction, including without limitation the
+// rights to use, copy, modify, merge, publish, distribute, sublicense, and/or
+// sell copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING
+// FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS
+// IN THE SOFTWARE.
+
+package transform
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/mfcollins3/hugoify-bluesky-rss-feed/pkg/feed"
+)
+
+// EmojiPolicy determines what happens to the emoji in the text of the
+// posts.
+type EmojiPolicy string
+
+const (
+	// KeepEmoji keeps the emoji.
+	KeepEmoji EmojiPolicy = "keep"
+
+	// StripEmoji removes the emoji.
+	StripEmoji EmojiPolicy = "strip"
+
+	// ShortcodeEmoji replaces the emoji with shortcodes such as :smile:,
+	// which Hugo converts back into emoji when enableEmoji is set. An emoji
+	// with a skin tone or a zero width joiner sequence that does not have a
+	// shortcode of its own uses the shortcode of its base emoji, and the
+	// emoji that do not have a shortcode are removed.
+	ShortcodeEmoji EmojiPolicy = "shortcode"
+)
+
+// tagPattern matches the tags of an HTML fragment, which are not changed
+// when the text of the fragment is normalized.
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// NormalizeItem normalizes the text of item using NormalizeText. The text
+// of HTML descriptions is normalized without changing the markup.
+func NormalizeItem(item *feed.Item, nfc bool, emoji EmojiPolicy) {
+	normalize := func(s string) string {
+		return NormalizeText(s, nfc, emoji)
+	}
+
+	if item.IsHTML {
+		item.Description = normalizeHTML(item.Description, normalize)
+	} else {
+		item.Description = normalize(item.Description)
+	}
+
+	item.Text = normalize(item.Text)
+	item.Markdown = normalize(item.Markdown)
+	item.Summary = normalize(item.Summary)
+	if item.Quote != nil {
+		quote := *item.Quote
+		quote.Text = normalize(quote.Text)
+		item.Quote = &quote
+	}
+
+	if item.LinkCard != nil {
+		card := *item.LinkCard
+		card.Title = normalize(card.Title)
+		card.Description = normalize(card.Description)
+		item.LinkCard = &card
+	}
+}
+
+// normalizeHTML applies normalize to the text between the tags of the HTML
+// fragment s.
+func normalizeHTML(s string, normalize func(string) string) string {
+	var b strings.Builder
+	pos := 0
+	for _, loc := range tagPattern.FindAllStringIndex(s, -1) {
+		b.WriteString(normalize(s[pos:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		pos = loc[1]
+	}
+
+	b.WriteString(normalize(s[pos:]))
+	return b.String()
+}
+
+// NormalizeText converts s into Unicode Normalization Form C if nfc is
+// true, and removes the emoji or replaces them with shortcodes depending
+// on emoji. The space next to an emoji that is removed is removed as well
+// so that the words around the emoji are separated by a single space.
+func NormalizeText(s string, nfc bool, emoji EmojiPolicy) string {
+	if nfc {
+		s = norm.NFC.String(s)
+	}
+
+	if emoji != StripEmoji && emoji != ShortcodeEmoji {
+		return s
+	}
+
+	var b []byte
+	for len(s) > 0 {
+		n := nextGrapheme(s)
+		cluster := s[:n]
+		s = s[n:]
+		if !isEmoji(cluster) {
+			b = append(b, cluster...)
+			continue
+		}
+
+		if emoji == ShortcodeEmoji {
+			if code, ok := emojiShortcode(cluster); ok {
+				b = append(b, ':')
+				b = append(b, code...)
+				b = append(b, ':')
+				continue
+			}
+		}
+
+		if len(b) == 0 || b[len(b)-1] == ' ' || b[len(b)-1] == '\n' {
+			s = strings.TrimPrefix(s, " ")
+		}
+
+		if s == "" || s[0] == '\n' {
+			for len(b) > 0 && b[len(b)-1] == ' ' {
+				b = b[:len(b)-1]
+			}
+		}
+	}
+
+	return string(b)
+}
+
+// Truncate returns the first n grapheme clusters of s. A grapheme cluster
+// is what a reader perceives as a single character, such as a letter with
+// combining accents, a flag, or an emoji with a skin tone, so Truncate
+// never splits a character into its code points.
+func Truncate(s string, n int) string {
+	end := 0
+	for i := 0; i < n && end < len(s); i++ {
+		end += nextGrapheme(s[end:])
+	}
+
+	return s[:end]
+}
+
+// GraphemeCount returns the number of grapheme clusters in s.
+func GraphemeCount(s string) int {
+	count := 0
+	for len(s) > 0 {
+		s = s[nextGrapheme(s):]
+		count++
+	}
+
+	return count
+}
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	textPresentation  = '\ufe0e'
+	emojiPresentation = '\ufe0f'
+	combiningKeycap   = '\u20e3'
+	firstRegional     = '\U0001f1e6'
+	lastRegional      = '\U0001f1ff'
+	firstSkinTone     = '\U0001f3fb'
+	lastSkinTone      = '\U0001f3ff'
+	firstTag          = '\U000e0020'
+	lastTag           = '\U000e007f'
+	firstVariation    = '\ufe00'
+	lastVariation     = '\ufe0f'
+	firstSupVariation = '\U000e0100'
+	lastSupVariation  = '\U000e01ef'
+)
+
+// nextGrapheme returns the length in bytes of the grapheme cluster at the
+// beginning of s. It implements the rules of Unicode Standard Annex #29
+// that matter for the text of posts: combining marks, variation
+// selectors, skin tones, emoji tag sequences, and zero width joiner
+// sequences extend a cluster, flags are pairs of regional indicators, and
+// CR LF is a single cluster.
+func nextGrapheme(s string) int {
+	r, n := utf8.DecodeRuneInString(s)
+	if r == '\r' && strings.HasPrefix(s[n:], "\n") {
+		return n + 1
+	}
+
+	if unicode.IsControl(r) {
+		return n
+	}
+
+	if isRegional(r) {
+		if next, size := utf8.DecodeRuneInString(s[n:]); isRegional(next) {
+			n += size
+		}
+	}
+
+	prev := r
+	for n < len(s) {
+		next, size := utf8.DecodeRuneInString(s[n:])
+		if !extendsGrapheme(next) &&
+			(prev != zeroWidthJoiner || !isPictographic(next)) {
+			break
+		}
+
+		prev = next
+		n += size
+	}
+
+	return n
+}
+
+// extendsGrapheme reports whether r belongs to the grapheme cluster of the
+// character before it.
+func extendsGrapheme(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == zeroWidthJoiner ||
+		(r >= firstVariation && r <= lastVariation) ||
+		(r >= firstSupVariation && r <= lastSupVariation) ||
+		(r >= firstSkinTone && r <= lastSkinTone) ||
+		(r >= firstTag && r <= lastTag)
+}
+
+// isRegional reports whether r is a regional indicator, which are used in
+// pairs to write flags.
+func isRegional(r rune) bool {
+	return r >= firstRegional && r <= lastRegional
+}
+
+// isPictographic reports whether r is a pictographic character that is
+// displayed as an emoji by default.
+func isPictographic(r rune) bool {
+	switch {
+	case r >= 0x1f000 && r <= 0x1faff,
+		r >= 0x2600 && r <= 0x27bf,
+		r == 0x231a, r == 0x231b, r == 0x2328, r == 0x23cf,
+		r >= 0x23e9 && r <= 0x23f3,
+		r >= 0x23f8 && r <= 0x23fa,
+		r >= 0x2b05 && r <= 0x2b07,
+		r == 0x2b1b, r == 0x2b1c, r == 0x2b50, r == 0x2b55,
+		r == 0x3030, r == 0x303d, r == 0x3297, r == 0x3299:
+		return true
+	default:
+		return false
+	}
+}
+
+// isEmoji reports whether the grapheme cluster is an emoji. Characters
+// such as © and digits are emoji when they are followed by the emoji
+// presentation selector or the combining keycap, and pictographic
+// characters are not emoji when they are followed by the text presentation
+// selector.
+func isEmoji(cluster string) bool {
+	if strings.ContainsRune(cluster, textPresentation) {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(cluster)
+	return isPictographic(r) ||
+		strings.ContainsRune(cluster, emojiPresentation) ||
+		strings.ContainsRune(cluster, combiningKeycap)
+}
+
+// emojiKey returns the key of the emoji in emojiShortcodes, which is the
+// emoji without the emoji presentation selector.
+func emojiKey(cluster string) string {
+	return strings.ReplaceAll(cluster, string(emojiPresentation), "")
+}
+
+// emojiShortcode returns the shortcode of the emoji. If the emoji does not
+// have a shortcode, the shortcode of its base emoji is returned, which is
+// the emoji without its skin tones and without the characters that follow
+// the first zero width joiner. The second result is false if neither has a
+// shortcode.
+func emojiShortcode(cluster string) (string, bool) {
+	key := emojiKey(cluster)
+	if code, ok := emojiShortcodes[key]; ok {
+		return code, true
+	}
+
+	base, _, _ := strings.Cut(key, string(zeroWidthJoiner))
+	base = strings.Map(func(r rune) rune {
+		if r >= firstSkinTone && r <= lastSkinTone {
+			return -1
+		}
+
+		return r
+	}, base)
+	code, ok := emojiShortcodes[base]
+	return code, ok
+}
+
+// emojiShortcodes maps the emoji, without the emoji presentation selector,
+// to the shortcodes that Hugo supports when enableEmoji is set.
+var emojiShortcodes = map[string]string{
+	"😀":   "grinning",
+	"😃":   "smiley",
+	"😄":   "smile",
+	"😁":   "grin",
+	"😆":   "laughing",
+	"😅":   "sweat_smile",
+	"😂":   "joy",
+	"🤣":   "rofl",
+	"😊":   "blush",
+	"😇":   "innocent",
+	"🙂":   "slightly_smiling_face",
+	"🙃":   "upside_down_face",
+	"😉":   "wink",
+	"😍":   "heart_eyes",
+	"🥰":   "smiling_face_with_three_hearts",
+	"😘":   "kissing_heart",
+	"😋":   "yum",
+	"😛":   "stuck_out_tongue",
+	"😜":   "stuck_out_tongue_winking_eye",
+	"🤪":   "zany_face",
+	"🤓":   "nerd_face",
+	"😎":   "sunglasses",
+	"🤩":   "star_struck",
+	"🥳":   "partying_face",
+	"😏":   "smirk",
+	"😒":   "unamused",
+	"😞":   "disappointed",
+	"😔":   "pensive",
+	"😟":   "worried",
+	"😕":   "confused",
+	"🥺":   "pleading_face",
+	"😢":   "cry",
+	"😭":   "sob",
+	"😤":   "triumph",
+	"😠":   "angry",
+	"😡":   "rage",
+	"🤯":   "exploding_head",
+	"😳":   "flushed",
+	"😱":   "scream",
+	"😨":   "fearful",
+	"😓":   "sweat",
+	"🤗":   "hugs",
+	"🤔":   "thinking",
+	"🤭":   "hand_over_mouth",
+	"🤫":   "shushing_face",
+	"🤐":   "zipper_mouth_face",
+	"😐":   "neutral_face",
+	"😑":   "expressionless",
+	"😶":   "no_mouth",
+	"🙄":   "roll_eyes",
+	"😬":   "grimacing",
+	"😴":   "sleeping",
+	"🤤":   "drooling_face",
+	"😷":   "mask",
+	"🤢":   "nauseated_face",
+	"🤮":   "vomiting_face",
+	"🥵":   "hot_face",
+	"🥶":   "cold_face",
+	"😵":   "dizzy_face",
+	"🤠":   "cowboy_hat_face",
+	"🫠":   "melting_face",
+	"💀":   "skull",
+	"👻":   "ghost",
+	"👽":   "alien",
+	"🤖":   "robot",
+	"💩":   "hankey",
+	"👍":   "+1",
+	"👎":   "-1",
+	"👌":   "ok_hand",
+	"✌":   "v",
+	"🤞":   "crossed_fingers",
+	"🤘":   "metal",
+	"👈":   "point_left",
+	"👉":   "point_right",
+	"👆":   "point_up_2",
+	"👇":   "point_down",
+	"👏":   "clap",
+	"🙌":   "raised_hands",
+	"👐":   "open_hands",
+	"🤝":   "handshake",
+	"🙏":   "pray",
+	"💪":   "muscle",
+	"👋":   "wave",
+	"✋":   "hand",
+	"👀":   "eyes",
+	"🧠":   "brain",
+	"🤷":   "shrug",
+	"🤦":   "facepalm",
+	"❤":   "heart",
+	"🧡":   "orange_heart",
+	"💛":   "yellow_heart",
+	"💚":   "green_heart",
+	"💙":   "blue_heart",
+	"💜":   "purple_heart",
+	"🖤":   "black_heart",
+	"🤍":   "white_heart",
+	"💔":   "broken_heart",
+	"💕":   "two_hearts",
+	"💖":   "sparkling_heart",
+	"💯":   "100",
+	"💥":   "boom",
+	"💫":   "dizzy",
+	"💬":   "speech_balloon",
+	"💤":   "zzz",
+	"🔥":   "fire",
+	"✨":   "sparkles",
+	"⭐":   "star",
+	"🌟":   "star2",
+	"⚡":   "zap",
+	"🌈":   "rainbow",
+	"☀":   "sunny",
+	"🌙":   "crescent_moon",
+	"☁":   "cloud",
+	"❄":   "snowflake",
+	"🌊":   "ocean",
+	"🌸":   "cherry_blossom",
+	"🌹":   "rose",
+	"🌻":   "sunflower",
+	"🌱":   "seedling",
+	"🌲":   "evergreen_tree",
+	"🍂":   "fallen_leaf",
+	"🦋":   "butterfly",
+	"🐱":   "cat",
+	"🐶":   "dog",
+	"🐦":   "bird",
+	"🐛":   "bug",
+	"🐍":   "snake",
+	"🦀":   "crab",
+	"☕":   "coffee",
+	"🍵":   "tea",
+	"🍺":   "beer",
+	"🍷":   "wine_glass",
+	"🍕":   "pizza",
+	"🍔":   "hamburger",
+	"🍰":   "cake",
+	"🎂":   "birthday",
+	"🍎":   "apple",
+	"🎉":   "tada",
+	"🎊":   "confetti_ball",
+	"🎁":   "gift",
+	"🎈":   "balloon",
+	"🎵":   "musical_note",
+	"🎶":   "notes",
+	"🎮":   "video_game",
+	"🎨":   "art",
+	"🎬":   "clapper",
+	"📷":   "camera",
+	"📸":   "camera_flash",
+	"📚":   "books",
+	"📖":   "book",
+	"📝":   "memo",
+	"📌":   "pushpin",
+	"📎":   "paperclip",
+	"📅":   "date",
+	"📈":   "chart_with_upwards_trend",
+	"📉":   "chart_with_downwards_trend",
+	"📢":   "loudspeaker",
+	"📣":   "mega",
+	"🔔":   "bell",
+	"🔗":   "link",
+	"🔒":   "lock",
+	"🔑":   "key",
+	"🔍":   "mag",
+	"💡":   "bulb",
+	"💻":   "computer",
+	"📱":   "iphone",
+	"⌨":   "keyboard",
+	"🖥":   "desktop_computer",
+	"🛠":   "hammer_and_wrench",
+	"🔧":   "wrench",
+	"⚙":   "gear",
+	"🧪":   "test_tube",
+	"🧵":   "thread",
+	"🚀":   "rocket",
+	"✈":   "airplane",
+	"🚗":   "car",
+	"🚲":   "bike",
+	"🏠":   "house",
+	"🌍":   "earth_africa",
+	"🌎":   "earth_americas",
+	"🌏":   "earth_asia",
+	"⏰":   "alarm_clock",
+	"⌛":   "hourglass",
+	"✅":   "white_check_mark",
+	"✔":   "heavy_check_mark",
+	"❌":   "x",
+	"❗":   "exclamation",
+	"❓":   "question",
+	"⚠":   "warning",
+	"🚫":   "no_entry_sign",
+	"➡":   "arrow_right",
+	"⬅":   "arrow_left",
+	"⬆":   "arrow_up",
+	"⬇":   "arrow_down",
+	"🆕":   "new",
+	"🆗":   "ok",
+	"🏆":   "trophy",
+	"🥇":   "1st_place_medal",
+	"🎯":   "dart",
+	"💰":   "moneybag",
+	"💸":   "money_with_wings",
+	"🏳‍🌈": "rainbow_flag",
+	"🇺🇸":  "us",
+	"🇬🇧":  "uk",
+	"🇨🇦":  "canada",
+	"🇩🇪":  "de",
+	"🇫🇷":  "fr",
+	"🇯🇵":  "jp",
+}